@@ -13,6 +13,20 @@ import (
 	"github.com/taskctl/taskctl/internal/iox"
 )
 
+// TestMain points HOME at a scratch directory so runs recorded by the tests
+// land in a throwaway history store rather than the developer's own.
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "taskctl-home")
+	if err != nil {
+		panic(err)
+	}
+	_ = os.Setenv("HOME", home)
+
+	code := m.Run()
+	_ = os.RemoveAll(home)
+	os.Exit(code)
+}
+
 type appTest struct {
 	args        []string
 	errored     bool
@@ -105,3 +105,14 @@ func minArgs(n int, msg string) cobra.PositionalArgs {
 		return nil
 	}
 }
+
+// rangeArgs requires between lowest and highest positional args, returning msg
+// otherwise.
+func rangeArgs(lowest, highest int, msg string) cobra.PositionalArgs {
+	return func(_ *cobra.Command, args []string) error {
+		if len(args) < lowest || len(args) > highest {
+			return errors.New(msg)
+		}
+		return nil
+	}
+}
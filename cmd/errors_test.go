@@ -23,6 +23,10 @@ func TestPresent(t *testing.T) {
 		{"usage/missing-arg", []string{"show"}, 2, []string{"Error:", "show requires exactly one", "Usage:"}, nil},
 		{"usage/unknown-flag", []string{"--bogus"}, 2, []string{"Error:", "unknown flag", "Usage:"}, nil},
 		{"runtime/missing-config", []string{"-c", "testdata/does-not-exist.yaml", "show", "graph:task1"}, 1, []string{"Error:"}, []string{"Usage:"}},
+		{"usage/malformed-set", []string{"-c", "testdata/overrides.yaml", "--set", "Channel", "show", "info"}, 2, []string{"Error:", "--set Channel: expected KEY=value", "Usage:"}, nil},
+		{"runtime/unknown-scope", []string{"-c", "testdata/overrides.yaml", "--env", "nope.TARGET=x", "show", "info"}, 1, []string{"Error:", "--env nope.TARGET: no task or stage nope"}, []string{"Usage:"}},
+		{"runtime/missing-var-file", []string{"-c", "testdata/overrides.yaml", "--var-file", "testdata/missing.yaml", "show", "info"}, 1, []string{"Error:", "--var-file testdata/missing.yaml"}, []string{"Usage:"}},
+		{"runtime/unknown-profile", []string{"-c", "testdata/profiles.yaml", "--profile", "staging", "list"}, 1, []string{"Error:", "profile staging is not defined, want one of ci, release"}, []string{"Usage:"}},
 		{"runtime/unknown-target", []string{"-c", "testdata/graph.yaml", "show", "nope"}, 1, []string{"Error:", `unknown task or pipeline "nope"`}, []string{"Usage:"}},
 	}
 
@@ -0,0 +1,385 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/taskctl/taskctl/internal/config"
+	"github.com/taskctl/taskctl/internal/output"
+	"github.com/taskctl/taskctl/internal/tui"
+	"github.com/taskctl/taskctl/scheduler"
+	"github.com/taskctl/taskctl/task"
+	"github.com/taskctl/taskctl/variables"
+)
+
+func newExplainCommand(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "explain TASK|PIPELINE[/STAGE]",
+		Short: "shows where each variable and env value of a task comes from",
+		Long: "Lists every variable and env entry a task would run with, the layer and config file its value comes from, " +
+			"and the values it overrides. Layers, lowest first: predefined variables, the config's variables, --set; " +
+			"for env the host environment; then the context, the task and the pipeline stage, and the variations. " +
+			"A pipeline explains each of its task stages; PIPELINE/STAGE one stage, nested pipelines included. " +
+			"Values are shown unrendered, and the env exported by earlier tasks at run time is not known. " +
+			"With --output json, emits a schema-versioned document.",
+		Example: "  taskctl explain build\n" +
+			"  taskctl explain release/build\n" +
+			"  taskctl explain build --output json",
+		GroupID:           groupInspect,
+		Args:              exactArgs(1, "explain requires exactly one task, pipeline or pipeline/stage name"),
+		ValidArgsFunction: targetCompletion(cfg),
+		RunE: func(_ *cobra.Command, args []string) error {
+			targets, err := explainTargets(cfg, args[0])
+			if err != nil {
+				return err
+			}
+
+			explanations := make([]explanation, 0, len(targets))
+			for _, t := range targets {
+				explanations = append(explanations, explain(cfg, t))
+			}
+
+			if cfg.Output == output.FormatJSON {
+				return json.NewEncoder(os.Stdout).Encode(struct {
+					SchemaVersion int           `json:"schema_version"`
+					Targets       []explanation `json:"targets"`
+				}{1, explanations})
+			}
+
+			renderExplanations(os.Stdout, explanations)
+			return nil
+		},
+	}
+}
+
+// explainTarget is a task to explain, with the pipeline stage running it.
+type explainTarget struct {
+	name     string
+	task     *task.Task
+	pipeline string
+	stage    *scheduler.Stage
+}
+
+// explainTargets resolves a task, a pipeline or a pipeline/stage path to the
+// tasks it runs.
+func explainTargets(cfg *config.Config, name string) ([]explainTarget, error) {
+	if t := cfg.Tasks[name]; t != nil {
+		return []explainTarget{{name: name, task: t}}, nil
+	}
+
+	first, rest, _ := strings.Cut(name, "/")
+	g := cfg.Pipelines[first]
+	if g == nil {
+		return nil, fmt.Errorf("unknown task or pipeline %q", name)
+	}
+
+	pipeline := first
+	for rest != "" {
+		var stageName string
+		stageName, rest, _ = strings.Cut(rest, "/")
+
+		stage, err := g.Node(stageName)
+		if err != nil {
+			return nil, fmt.Errorf("pipeline %q has no stage %q", pipeline, stageName)
+		}
+
+		if stage.Task != nil {
+			if rest != "" {
+				return nil, fmt.Errorf("stage %q of pipeline %q runs a task, not a pipeline", stageName, pipeline)
+			}
+			return []explainTarget{{name: name, task: stage.Task, pipeline: pipeline, stage: stage}}, nil
+		}
+
+		g, pipeline = stage.Pipeline, stageName
+	}
+
+	return pipelineTargets(g, pipeline, name), nil
+}
+
+// pipelineTargets lists the task stages of g, and of the pipelines nested in
+// it, named "prefix/stage".
+func pipelineTargets(g *scheduler.ExecutionGraph, pipeline, prefix string) []explainTarget {
+	var targets []explainTarget
+	for _, name := range slices.Sorted(maps.Keys(g.Nodes())) {
+		stage := g.Nodes()[name]
+		if stage.Task != nil {
+			targets = append(targets, explainTarget{name: prefix + "/" + name, task: stage.Task, pipeline: pipeline, stage: stage})
+			continue
+		}
+		targets = append(targets, pipelineTargets(stage.Pipeline, name, prefix+"/"+name)...)
+	}
+
+	return targets
+}
+
+// explainSource is one layer's value for a variable or env entry.
+type explainSource struct {
+	Layer string `json:"layer"`
+	File  string `json:"file,omitempty"`
+	Value string `json:"value"`
+}
+
+// explainEntry is a variable or env entry with the source whose value wins and
+// the ones it overrides, most recent first.
+type explainEntry struct {
+	Name     string          `json:"name"`
+	Value    string          `json:"value"`
+	Source   explainSource   `json:"source"`
+	Shadowed []explainSource `json:"shadowed"`
+}
+
+type explanation struct {
+	Target  string `json:"target"`
+	Task    string `json:"task"`
+	Context string `json:"context,omitempty"`
+	// EnvInherit is the task's env_inherit setting: all, none or the
+	// inherited host variables.
+	EnvInherit string         `json:"env_inherit"`
+	Variables  []explainEntry `json:"variables"`
+	Env        []explainEntry `json:"env"`
+}
+
+// layers collects the sources of every entry in precedence order, lowest first.
+type layers map[string][]explainSource
+
+func (l layers) add(name string, src explainSource) {
+	l[name] = append(l[name], src)
+}
+
+// addContainer adds every entry of c to layer, attributed to the config files
+// that declared it under path, or to no file when none did, followed by the
+// command line values that replaced them in c.
+func (l layers) addContainer(cfg *config.Config, layer string, c variables.Container, replaced []config.Override, path ...string) {
+	if c == nil {
+		return
+	}
+
+	for name, v := range c.Map() {
+		origins := cfg.Origins(append(path[:len(path):len(path)], name)...)
+		if len(origins) == 0 {
+			if !slices.ContainsFunc(replaced, func(o config.Override) bool { return o.Key == name }) {
+				l.add(name, explainSource{Layer: layer, Value: variables.Format(v)})
+			}
+			continue
+		}
+		for _, o := range origins {
+			l.add(name, explainSource{Layer: layer, File: o.File, Value: o.Value})
+		}
+	}
+	for _, o := range replaced {
+		l.add(o.Key, overrideSource(o))
+	}
+}
+
+// overrides returns the command line values of cfg matching keep, in order.
+func overrides(cfg *config.Config, keep func(config.Override) bool) []config.Override {
+	var matched []config.Override
+	for _, o := range cfg.Overrides {
+		if keep(o) {
+			matched = append(matched, o)
+		}
+	}
+
+	return matched
+}
+
+// overrideSource attributes a command line value to its flag, and for a
+// --var-file to its file.
+func overrideSource(o config.Override) explainSource {
+	layer := o.Flag
+	if o.Scope != "" {
+		layer += " " + o.Scope
+	}
+
+	return explainSource{Layer: layer, File: o.File, Value: variables.Format(o.Value)}
+}
+
+func (l layers) entries() []explainEntry {
+	entries := make([]explainEntry, 0, len(l))
+	for _, name := range slices.Sorted(maps.Keys(l)) {
+		sources := l[name]
+		winner := sources[len(sources)-1]
+		shadowed := slices.Clone(sources[:len(sources)-1])
+		slices.Reverse(shadowed)
+
+		entries = append(entries, explainEntry{Name: name, Value: winner.Value, Source: winner, Shadowed: shadowed})
+	}
+
+	return entries
+}
+
+// explain attributes the variables and env of a task the way the runner and
+// scheduler layer them.
+func explain(cfg *config.Config, target explainTarget) explanation {
+	t := target.task
+	contextName := t.Context
+	if contextName == "" {
+		contextName = "default"
+	}
+	execContext := cfg.Contexts[contextName]
+	if execContext == nil {
+		contextName = ""
+	}
+
+	vars := layers{}
+	predefined := func(name string, value any) {
+		vars.add(name, explainSource{Layer: "predefined", Value: fmt.Sprint(value)})
+	}
+
+	predefined("TempDir", os.TempDir())
+	for name := range cfg.Variables.Map() {
+		for _, o := range cfg.Origins("variables", name) {
+			vars.add(name, explainSource{Layer: "global", File: o.File, Value: o.Value})
+		}
+	}
+	globalVars := overrides(cfg, func(o config.Override) bool { return o.Scope == "" && !o.Env() })
+	globalEnv := overrides(cfg, func(o config.Override) bool { return o.Scope == "" && o.Env() })
+	for _, name := range []string{"Root", "Dir"} {
+		if !slices.ContainsFunc(globalVars, func(o config.Override) bool { return o.Key == name }) && cfg.Variables.Has(name) {
+			predefined(name, cfg.Variables.Get(name))
+		}
+	}
+	for _, o := range globalVars {
+		vars.add(o.Key, overrideSource(o))
+	}
+	predefined("Args", "")
+	predefined("ArgsList", "[]")
+
+	env := layers{}
+	for name := range cfg.Env.Map() {
+		for _, o := range cfg.Origins("env", name) {
+			env.add(name, explainSource{Layer: "global", File: o.File, Value: o.Value})
+		}
+	}
+	for _, o := range globalEnv {
+		env.add(o.Key, overrideSource(o))
+	}
+	env.add("TASKCTL__ARGS", explainSource{Layer: "predefined"})
+
+	if execContext != nil {
+		layer := "context " + contextName
+		vars.addContainer(cfg, layer, execContext.Variables, nil, "contexts", contextName, "variables")
+		env.addContainer(cfg, layer, execContext.Env, nil, "contexts", contextName, "env")
+	}
+
+	env.add("TASKCTL__TASK_NAME", explainSource{Layer: "predefined", Value: t.Name})
+
+	taskVars := t.Variables
+	if taskVars != nil && taskVars.Has("ConfigFile") {
+		predefined("ConfigDir", taskVars.Get("ConfigDir"))
+		predefined("ConfigFile", taskVars.Get("ConfigFile"))
+		taskVars = without(taskVars, "ConfigDir", "ConfigFile")
+	}
+
+	layer := "task " + t.Name
+	vars.addContainer(cfg, layer, taskVars, overrides(cfg, func(o config.Override) bool { return o.AppliesToTask(t.Name) && !o.Env() }), "tasks", t.Name, "variables")
+	env.addContainer(cfg, layer, t.Env, overrides(cfg, func(o config.Override) bool { return o.AppliesToTask(t.Name) && o.Env() }), "tasks", t.Name, "env")
+
+	if s := target.stage; s != nil {
+		layer := "stage " + target.pipeline + "/" + s.Name
+		stageVars := s.Variables
+		if stageVars != nil && stageVars.Has("Stage") {
+			predefined("Stage", s.Name)
+			stageVars = without(stageVars, "Stage")
+		}
+		vars.addContainer(cfg, layer, stageVars, overrides(cfg, func(o config.Override) bool { return o.AppliesToStage(s) && !o.Env() }), "pipelines", target.pipeline, s.Name, "variables")
+		env.addContainer(cfg, layer, s.Env, overrides(cfg, func(o config.Override) bool { return o.AppliesToStage(s) && o.Env() }), "pipelines", target.pipeline, s.Name, "env")
+	}
+
+	variations := map[string][]string{}
+	for _, variant := range t.Variations {
+		for k, v := range variant {
+			variations[k] = append(variations[k], v)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(variations)) {
+		env.add(name, explainSource{Layer: "variations", Value: strings.Join(variations[name], ", ")})
+	}
+
+	// The inherited host environment is the base every declared entry
+	// overrides.
+	inherit := cfg.EnvInherit
+	if execContext != nil {
+		inherit = execContext.EnvInherit
+	}
+	inherit = inherit.Merge(t.EnvInherit)
+	for name, sources := range env {
+		if v, ok := os.LookupEnv(name); ok && inherit.Inherits(name) {
+			env[name] = append([]explainSource{{Layer: "host", Value: v}}, sources...)
+		}
+	}
+
+	return explanation{
+		Target:     target.name,
+		Task:       t.Name,
+		Context:    contextName,
+		EnvInherit: inherit.String(),
+		Variables:  vars.entries(),
+		Env:        env.entries(),
+	}
+}
+
+// without returns a copy of c lacking keys.
+func without(c variables.Container, keys ...string) variables.Container {
+	vars := variables.NewVariables()
+	for k, v := range c.Map() {
+		if !slices.Contains(keys, k) {
+			vars.Set(k, v)
+		}
+	}
+
+	return vars
+}
+
+// renderExplanations writes every entry as NAME = value with its source, and
+// the values it overrides underneath.
+func renderExplanations(w io.Writer, explanations []explanation) {
+	for i, e := range explanations {
+		if i > 0 {
+			tui.Println(w, "")
+		}
+
+		heading := e.Target
+		if e.Task != e.Target {
+			heading += tui.StyleFaint.Render(" (task " + e.Task + ")")
+		}
+		tui.Println(w, tui.StyleBold.Render(heading))
+		if e.Context != "" {
+			planLine(w, "  ", "context", e.Context)
+		}
+		planLine(w, "  ", "env_inherit", e.EnvInherit)
+
+		renderEntries(w, "Variables", e.Variables)
+		renderEntries(w, "Env", e.Env)
+	}
+}
+
+func renderEntries(w io.Writer, title string, entries []explainEntry) {
+	tui.Println(w, "  "+title)
+	if len(entries) == 0 {
+		tui.Println(w, tui.StyleFaint.Render("    (none)"))
+		return
+	}
+
+	for _, entry := range entries {
+		tui.Println(w, "    "+entry.Name+" = "+entry.Value+"  "+tui.StyleFaint.Render(formatSource(entry.Source)))
+		for _, s := range entry.Shadowed {
+			tui.Println(w, tui.StyleFaint.Render("      shadows "+s.Value+"  "+formatSource(s)))
+		}
+	}
+}
+
+func formatSource(s explainSource) string {
+	if s.File == "" {
+		return s.Layer
+	}
+
+	return s.Layer + " " + s.File
+}
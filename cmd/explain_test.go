@@ -0,0 +1,136 @@
+package cmd_test
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func Test_explainCommand(t *testing.T) {
+	runAppTest(t, appTest{
+		args: []string{"-c", "testdata/explain.yaml", "explain", "compile"},
+		output: []string{
+			"compile",
+			"context: build",
+			"Version = 2.0",
+			"shadows 1.0",
+			"explain/shared.yaml",
+			"Channel = nightly  task compile",
+			"shadows beta  context build",
+			"CGO_ENABLED = 0  task compile",
+			"GOOS = linux, darwin  variations",
+			"TASKCTL__TASK_NAME = compile  predefined",
+		},
+	})
+}
+
+func Test_explainCommandComputed(t *testing.T) {
+	runAppTest(t, appTest{
+		args:   []string{"-c", "testdata/computed.yaml", "explain", "greet"},
+		output: []string{"Greeting = sh: echo hello  global", "NAME = sh: printf world  task greet"},
+	})
+}
+
+func Test_explainCommandTyped(t *testing.T) {
+	runAppTest(t, appTest{
+		args:   []string{"-c", "testdata/typed.yaml", "--set", `Ports={"api":1}`, "explain", "release/ports"},
+		output: []string{`Packages = ["web"]  stage release/ports`, `shadows ["api","web"]  global`, `Ports = {"api":1}  --set`},
+	})
+}
+
+func Test_explainCommandImports(t *testing.T) {
+	runAppTest(t, appTest{
+		args:   []string{"-c", "testdata/imports.yaml", "explain", "backend:build"},
+		output: []string{"Service = api  task backend:build", "testdata/imports.yaml"},
+	})
+}
+
+func Test_explainCommandOverrides(t *testing.T) {
+	runAppTest(t, appTest{
+		args: []string{
+			"-c", "testdata/overrides.yaml", "--var-file", "testdata/overrides-vars.yaml",
+			"--set", "info.Channel=mine", "--set", "release/info.Channel=hotfix", "--env", "TARGET=ci", "--env", "info.TARGET=prod",
+			"explain", "release/info",
+		},
+		output: []string{
+			"Channel = hotfix  --set release/info",
+			"shadows mine  --set info",
+			"shadows edge  --var-file testdata/overrides-vars.yaml",
+			"TARGET = prod  --env info",
+			"shadows ci  --env",
+		},
+	})
+}
+
+func Test_explainCommandStage(t *testing.T) {
+	runAppTest(t, appTest{
+		args: []string{"-c", "testdata/explain.yaml", "--set", "Version=3.0", "explain", "release/compile"},
+		output: []string{
+			"release/compile (task compile)",
+			"Channel = rc  stage release/compile",
+			"CGO_ENABLED = 2  stage release/compile",
+			"Version = 3.0  --set",
+			"Stage = compile  predefined",
+		},
+	})
+}
+
+func Test_explainCommandPipeline(t *testing.T) {
+	runAppTest(t, appTest{
+		args:   []string{"-c", "testdata/explain.yaml", "explain", "release"},
+		output: []string{"release/compile (task compile)", "release/nested/compile (task compile)"},
+	})
+}
+
+func Test_explainCommandUnknown(t *testing.T) {
+	runAppTest(t, appTest{args: []string{"-c", "testdata/explain.yaml", "explain", "release/missing"}, errored: true})
+	runAppTest(t, appTest{args: []string{"-c", "testdata/explain.yaml", "explain", "release/compile/x"}, errored: true})
+}
+
+func Test_explainCommandJSON(t *testing.T) {
+	out, err := captureStdout(t, []string{"-c", "testdata/explain.yaml", "-o", "json", "explain", "compile"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		SchemaVersion int `json:"schema_version"`
+		Targets       []struct {
+			Target string `json:"target"`
+			Env    []struct {
+				Name   string `json:"name"`
+				Value  string `json:"value"`
+				Source struct {
+					Layer string `json:"layer"`
+				} `json:"source"`
+				Shadowed []struct {
+					Layer string `json:"layer"`
+					Value string `json:"value"`
+				} `json:"shadowed"`
+			} `json:"env"`
+		} `json:"targets"`
+	}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out, err)
+	}
+	if doc.SchemaVersion != 1 || len(doc.Targets) != 1 || doc.Targets[0].Target != "compile" {
+		t.Fatalf("unexpected document %+v", doc)
+	}
+
+	for _, e := range doc.Targets[0].Env {
+		if e.Name != "CGO_ENABLED" {
+			continue
+		}
+		if e.Value != "0" || e.Source.Layer != "task compile" || len(e.Shadowed) == 0 || e.Shadowed[0].Value != "1" {
+			t.Errorf("unexpected CGO_ENABLED entry %+v", e)
+		}
+		return
+	}
+	t.Error("CGO_ENABLED missing from env")
+}
+
+func Test_explainCommandProfile(t *testing.T) {
+	runAppTest(t, appTest{
+		args:   []string{"-c", "testdata/profiles.yaml", "--profile", "ci", "--env", "DEPLOY_ENV=prod", "explain", "target"},
+		output: []string{"DEPLOY_ENV = prod  --env", "shadows staging  global profile ci"},
+	})
+}
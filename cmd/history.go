@@ -0,0 +1,367 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/taskctl/taskctl/internal/collections"
+	"github.com/taskctl/taskctl/internal/config"
+	"github.com/taskctl/taskctl/internal/history"
+	"github.com/taskctl/taskctl/internal/output"
+	"github.com/taskctl/taskctl/internal/tui"
+	"github.com/taskctl/taskctl/scheduler"
+	"github.com/taskctl/taskctl/task"
+)
+
+func newHistoryCommand(cfg *config.Config) *cobra.Command {
+	var limit int
+	var target, status string
+
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "lists recent runs",
+		Long: "Lists the runs recorded in the local history store, newest first, with their targets, status and duration. " +
+			"With --output json, emits a schema-versioned document. Use `taskctl logs RUN_ID` to replay a run's output.",
+		Example: "  taskctl history\n" +
+			"  taskctl history --status failed --target test\n" +
+			"  taskctl history --output json",
+		GroupID: groupInspect,
+		Args:    cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			store, err := historyStore(cfg)
+			if err != nil {
+				return err
+			}
+
+			runs, err := store.List()
+			if err != nil {
+				return err
+			}
+
+			runs = slices.DeleteFunc(runs, func(r *history.Run) bool {
+				return (status != "" && r.Status != status) || (target != "" && !slices.Contains(r.Targets, target))
+			})
+			if limit > 0 && len(runs) > limit {
+				runs = runs[:limit]
+			}
+
+			if cfg.Output == output.FormatJSON {
+				return encodeHistoryJSON(runs)
+			}
+
+			renderHistory(os.Stdout, runs)
+			return nil
+		},
+	}
+	historyCmd.Flags().IntVarP(&limit, "limit", "n", 20, "maximum number of runs to list (0 for all)")
+	historyCmd.Flags().StringVar(&target, "target", "", "only list runs of this task or pipeline")
+	historyCmd.Flags().StringVar(&status, "status", "", "only list runs with this status (done or failed)")
+
+	var keep int
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "deletes old runs from the history",
+		Long:  "Applies the history retention settings (history.keep and history.max_age in the config) now, or keeps only the newest --keep runs.",
+		Example: "  taskctl history prune\n" +
+			"  taskctl history prune --keep 0",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			store, err := historyStore(cfg)
+			if err != nil {
+				return err
+			}
+
+			// Unlike keep: 0 in the config, which means "default", an explicit
+			// --keep 0 empties the history.
+			if cmd.Flags().Changed("keep") {
+				store.Keep = keep
+			}
+
+			n, err := store.Prune()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("pruned %d run(s)\n", n)
+			return nil
+		},
+	}
+	pruneCmd.Flags().IntVar(&keep, "keep", 0, "number of newest runs to keep")
+	historyCmd.AddCommand(pruneCmd)
+
+	return historyCmd
+}
+
+func newLogsCommand(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "logs RUN_ID [TASK]",
+		Short: "replays a recorded run's output",
+		Long: "Prints the captured output of a run from the history store: every task's, or only TASK's. " +
+			"RUN_ID may be a unique prefix of the id, or \"last\" for the most recent run. With --output json, emits a schema-versioned document.",
+		Example: "  taskctl logs last\n" +
+			"  taskctl logs 20250102T150405 test",
+		GroupID: groupInspect,
+		Args:    rangeArgs(1, 2, "logs requires a run id and optionally a task name"),
+		RunE: func(_ *cobra.Command, args []string) error {
+			store, err := historyStore(cfg)
+			if err != nil {
+				return err
+			}
+
+			run, err := store.Load(args[0])
+			if err != nil {
+				return err
+			}
+
+			tasks := run.Tasks
+			if len(args) == 2 {
+				tasks = slices.DeleteFunc(slices.Clone(tasks), func(t history.Task) bool {
+					return t.Name != args[1] && t.Task != args[1]
+				})
+				if len(tasks) == 0 {
+					return fmt.Errorf("run %s has no task %q", run.ID, args[1])
+				}
+			}
+
+			if cfg.Output == output.FormatJSON {
+				return json.NewEncoder(os.Stdout).Encode(struct {
+					SchemaVersion int            `json:"schema_version"`
+					RunID         string         `json:"run_id"`
+					Tasks         []history.Task `json:"tasks"`
+				}{1, run.ID, collections.OrEmpty(tasks)})
+			}
+
+			for _, t := range tasks {
+				renderTaskLog(os.Stdout, t, len(tasks) > 1)
+			}
+			return nil
+		},
+	}
+}
+
+// historyStore resolves the configured store: history.dir when set, otherwise
+// a per-config directory under ~/.taskctl/history.
+func historyStore(cfg *config.Config) (*history.Store, error) {
+	dir := cfg.History.Dir
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = history.DefaultDir(home, cfg.File)
+	}
+
+	store := history.NewStore(dir)
+	if cfg.History.Keep != 0 {
+		store.Keep = cfg.History.Keep
+	}
+	store.MaxAge = cfg.History.MaxAge
+
+	return store, nil
+}
+
+// historyEnabled reports whether runs should be recorded: history is on unless
+// the config disables it or --no-history is passed. Dry runs execute nothing
+// worth recording.
+func historyEnabled(cmd *cobra.Command, cfg *config.Config) bool {
+	if noHistory, _ := cmd.Flags().GetBool("no-history"); noHistory {
+		return false
+	}
+
+	if cfg.History.Enabled != nil && !*cfg.History.Enabled {
+		return false
+	}
+
+	return !cfg.DryRun
+}
+
+// recordRun saves the finished run to the history store. A failure to record
+// is logged rather than failing a run that otherwise completed.
+func recordRun(cmd *cobra.Command, cfg *config.Config, targets []string, start time.Time, graphs []*scheduler.ExecutionGraph, tasks []*task.Task, runErr error) {
+	if !historyEnabled(cmd, cfg) {
+		return
+	}
+
+	store, err := historyStore(cfg)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("run history: %s", err))
+		return
+	}
+
+	run := &history.Run{
+		ID:         history.NewRunID(start),
+		Config:     cfg.File,
+		ConfigHash: history.HashFile(cfg.File),
+		Targets:    targets,
+		Start:      start,
+		End:        time.Now(),
+		Status:     "done",
+	}
+
+	for _, g := range graphs {
+		run.Tasks = append(run.Tasks, historyStages(g, "")...)
+	}
+	for _, t := range tasks {
+		run.Tasks = append(run.Tasks, historyTask(t.Name, t, output.TaskStatus(t)))
+		run.Tasks = append(run.Tasks, historyUnits("", t)...)
+	}
+
+	for _, t := range run.Tasks {
+		if output.IsFailure(t.Status) || t.Status == "canceled" {
+			run.Status = "failed"
+		}
+	}
+	if runErr != nil {
+		run.Status = "failed"
+		run.Error = runErr.Error()
+	}
+
+	if err := store.Save(run); err != nil {
+		slog.Warn(fmt.Sprintf("run history: %s", err))
+	}
+}
+
+// historyStages flattens a pipeline's stages, recursing into nested pipelines
+// with their stages named "pipeline/stage".
+func historyStages(g *scheduler.ExecutionGraph, prefix string) []history.Task {
+	var records []history.Task
+	for _, name := range slices.Sorted(maps.Keys(g.Nodes())) {
+		stage := g.Nodes()[name]
+		status := stageStatus(stage)
+
+		if stage.Task != nil {
+			if status == "done" && stage.Task.Skipped {
+				status = "skipped"
+			}
+			records = append(records, historyTask(prefix+stage.Name, stage.Task, status))
+			records = append(records, historyUnits(prefix, stage.Task)...)
+			continue
+		}
+
+		records = append(records, history.Task{
+			Name:       prefix + stage.Name,
+			Status:     status,
+			Start:      stage.Start,
+			DurationMs: stage.Duration().Milliseconds(),
+		})
+		if stage.Pipeline != nil && status != "skipped" && status != "canceled" {
+			records = append(records, historyStages(stage.Pipeline, prefix+stage.Name+"/")...)
+		}
+	}
+
+	return records
+}
+
+func historyTask(name string, t *task.Task, status string) history.Task {
+	return history.Task{
+		Name:       name,
+		Task:       t.Name,
+		Status:     status,
+		ExitCode:   int(t.ExitCode),
+		Start:      t.Start,
+		DurationMs: t.Duration().Milliseconds(),
+		Stdout:     history.Tail(string(t.Log.Stdout.Tail(history.TailBytes))),
+		Stderr:     history.Tail(string(t.Log.Stderr.Tail(history.TailBytes))),
+		LogFiles:   t.LogFiles,
+	}
+}
+
+// historyUnits records the variations or commands a parallel task ran.
+func historyUnits(prefix string, t *task.Task) []history.Task {
+	records := make([]history.Task, 0, len(t.Units))
+	for _, u := range t.Units {
+		records = append(records, historyTask(prefix+u.Name, u, output.TaskStatus(u)))
+	}
+
+	return records
+}
+
+// historyRun is the JSON view of a stored run listed by `history`: the stored
+// record without the captured output, which `logs` serves.
+type historyRun struct {
+	ID         string        `json:"id"`
+	Targets    []string      `json:"targets"`
+	Status     string        `json:"status"`
+	Start      time.Time     `json:"start"`
+	DurationMs int64         `json:"duration_ms"`
+	Config     string        `json:"config,omitempty"`
+	ConfigHash string        `json:"config_hash,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Tasks      []historyStep `json:"tasks"`
+}
+
+type historyStep struct {
+	Name       string `json:"name"`
+	Task       string `json:"task,omitempty"`
+	Status     string `json:"status"`
+	ExitCode   int    `json:"exit_code"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+func encodeHistoryJSON(runs []*history.Run) error {
+	doc := make([]historyRun, 0, len(runs))
+	for _, r := range runs {
+		steps := make([]historyStep, 0, len(r.Tasks))
+		for _, t := range r.Tasks {
+			steps = append(steps, historyStep{Name: t.Name, Task: t.Task, Status: t.Status, ExitCode: t.ExitCode, DurationMs: t.DurationMs})
+		}
+		doc = append(doc, historyRun{
+			ID:         r.ID,
+			Targets:    collections.OrEmpty(r.Targets),
+			Status:     r.Status,
+			Start:      r.Start,
+			DurationMs: r.Duration().Milliseconds(),
+			Config:     r.Config,
+			ConfigHash: r.ConfigHash,
+			Error:      r.Error,
+			Tasks:      steps,
+		})
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(struct {
+		SchemaVersion int          `json:"schema_version"`
+		Runs          []historyRun `json:"runs"`
+	}{1, doc})
+}
+
+func renderHistory(w io.Writer, runs []*history.Run) {
+	if len(runs) == 0 {
+		tui.Println(w, tui.StyleFaint.Render("No runs recorded."))
+		return
+	}
+
+	for _, r := range runs {
+		tui.Printf(w, "%s %s  %s  %8s  %s\n",
+			output.StatusMark(r.Status),
+			r.ID,
+			tui.StyleFaint.Render(r.Start.Local().Format(time.DateTime)),
+			r.Duration().Round(10*time.Millisecond),
+			strings.Join(r.Targets, " "),
+		)
+	}
+}
+
+func renderTaskLog(w io.Writer, t history.Task, header bool) {
+	if header {
+		tui.Println(w, output.StatusMark(t.Status)+" "+tui.StyleBold.Render(t.Name))
+	}
+
+	for _, s := range []string{t.Stdout, t.Stderr} {
+		if s == "" {
+			continue
+		}
+		_, _ = io.WriteString(w, s)
+		if !strings.HasSuffix(s, "\n") {
+			_, _ = io.WriteString(w, "\n")
+		}
+	}
+}
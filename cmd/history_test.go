@@ -0,0 +1,58 @@
+package cmd_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func Test_historyCommand(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+
+	runAppTest(t, appTest{args: []string{"--raw", "-c", "testdata/graph.yaml", "graph:task1"}})
+	runAppTest(t, appTest{args: []string{"--raw", "-c", "testdata/failing.yaml", "boom"}, errored: true})
+	runAppTest(t, appTest{args: []string{"--raw", "--no-history", "-c", "testdata/graph.yaml", "graph:task1"}})
+
+	out, err := captureStdout(t, []string{"-c", "testdata/graph.yaml", "-o", "json", "history"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var resp struct {
+		SchemaVersion int `json:"schema_version"`
+		Runs          []struct {
+			ID      string   `json:"id"`
+			Targets []string `json:"targets"`
+			Status  string   `json:"status"`
+			Config  string   `json:"config"`
+			Tasks   []struct {
+				Name   string `json:"name"`
+				Status string `json:"status"`
+			} `json:"tasks"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		t.Fatalf("invalid json: %v\noutput: %s", err, out)
+	}
+
+	// Histories are kept per config file: the failing.yaml run and the
+	// --no-history run are not listed.
+	if len(resp.Runs) != 1 {
+		t.Fatalf("expected 1 recorded run, got %+v", resp.Runs)
+	}
+	run := resp.Runs[0]
+	if run.Status != "done" || run.Targets[0] != "graph:task1" || filepath.Base(run.Config) != "graph.yaml" {
+		t.Errorf("unexpected run record %+v", run)
+	}
+	if len(run.Tasks) != 1 || run.Tasks[0].Name != "graph:task1" || run.Tasks[0].Status != "done" {
+		t.Errorf("unexpected task records %+v", run.Tasks)
+	}
+
+	runAppTest(t, appTest{args: []string{"-c", "testdata/graph.yaml", "logs", "last"}, exactOutput: "hello, world!\n"})
+	runAppTest(t, appTest{args: []string{"-c", "testdata/graph.yaml", "logs", run.ID[:15], "graph:task1"}, exactOutput: "hello, world!\n"})
+	runAppTest(t, appTest{args: []string{"-c", "testdata/graph.yaml", "logs", run.ID, "nope"}, errored: true})
+	runAppTest(t, appTest{args: []string{"-c", "testdata/failing.yaml", "history", "--status", "failed"}, output: []string{"✗", "boom"}})
+	runAppTest(t, appTest{args: []string{"-c", "testdata/graph.yaml", "history", "prune", "--keep", "0"}, output: []string{"pruned 1 run(s)"}})
+	runAppTest(t, appTest{args: []string{"-c", "testdata/graph.yaml", "history"}, output: []string{"No runs recorded."}})
+}
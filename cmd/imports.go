@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/taskctl/taskctl/internal/config"
+	"github.com/taskctl/taskctl/internal/output"
+	"github.com/taskctl/taskctl/internal/tui"
+)
+
+// annotationRefreshImports marks the commands whose config is loaded with
+// every remote import fetched anew, bypassing the cache and the lock file.
+const annotationRefreshImports = "refresh-imports"
+
+// importStatus is a remote import as imports update and verify report it.
+type importStatus struct {
+	URL          string `json:"url"`
+	SHA256       string `json:"sha256,omitempty"`
+	LockedSHA256 string `json:"locked_sha256,omitempty"`
+	// Commit is the commit a git import was read at.
+	Commit string `json:"commit,omitempty"`
+	// Status is locked (update), or ok, changed, unlocked or unused (verify).
+	Status string `json:"status"`
+}
+
+func newImportsCommand(cfg *config.Config, cl *config.Loader) *cobra.Command {
+	importsCmd := &cobra.Command{
+		Use:   "imports",
+		Short: "manages the lock file of remote imports",
+		Long: "Remote imports are cached under ~/.taskctl/cache/imports and, once locked, must keep the content recorded in " +
+			config.LockFileName + " next to the config file. `imports update` fetches them and writes the lock file; " +
+			"`imports verify` checks them against it.",
+		GroupID: groupSetup,
+		Args:    cobra.NoArgs,
+	}
+
+	importsCmd.AddCommand(&cobra.Command{
+		Use:         "update",
+		Short:       "fetches remote imports and pins their content in the lock file",
+		Example:     "  taskctl imports update",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{annotationRefreshImports: "true"},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			file, err := lockFile(cfg)
+			if err != nil {
+				return err
+			}
+
+			lock := config.Lock{Imports: make(map[string]config.LockedImport)}
+			for _, r := range cl.Remotes() {
+				lock.Imports[r.URL] = config.LockedImport{SHA256: r.SHA256, Commit: r.Commit}
+			}
+			if err := lock.Write(file); err != nil {
+				return err
+			}
+
+			statuses := make([]importStatus, 0, len(lock.Imports))
+			for _, u := range slices.Sorted(maps.Keys(lock.Imports)) {
+				locked := lock.Imports[u]
+				statuses = append(statuses, importStatus{URL: u, SHA256: locked.SHA256, Commit: locked.Commit, Status: "locked"})
+			}
+
+			return reportImports(cfg, file, statuses)
+		},
+	})
+
+	importsCmd.AddCommand(&cobra.Command{
+		Use:   "verify",
+		Short: "checks that remote imports still have the content in the lock file",
+		Long: "Fetches every remote import, or with --offline reads the cached copy, and compares its content with the lock file. " +
+			"Fails when an import changed or isn't locked; locked URLs no longer imported are listed as unused.",
+		Example:     "  taskctl imports verify",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{annotationRefreshImports: "true"},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			file, err := lockFile(cfg)
+			if err != nil {
+				return err
+			}
+			lock, err := config.ReadLock(file)
+			if err != nil {
+				return err
+			}
+
+			fetched := make(map[string]config.RemoteImport)
+			for _, r := range cl.Remotes() {
+				fetched[r.URL] = r
+			}
+			urls := slices.Sorted(maps.Keys(fetched))
+			for u := range lock.Imports {
+				if _, ok := fetched[u]; !ok {
+					urls = append(urls, u)
+				}
+			}
+
+			var failed int
+			statuses := make([]importStatus, 0, len(urls))
+			for _, u := range urls {
+				s := importStatus{URL: u, SHA256: fetched[u].SHA256, LockedSHA256: lock.Imports[u].SHA256, Commit: fetched[u].Commit}
+				switch locked, ok := lock.Imports[u]; {
+				case s.SHA256 == "":
+					s.Status = "unused"
+				case !ok:
+					s.Status = "unlocked"
+					failed++
+				case locked.SHA256 != s.SHA256:
+					s.Status = "changed"
+					failed++
+				default:
+					s.Status = "ok"
+				}
+				statuses = append(statuses, s)
+			}
+
+			if err := reportImports(cfg, file, statuses); err != nil {
+				return err
+			}
+			if failed > 0 {
+				return reportedError{fmt.Errorf("%d remote import(s) don't match %s", failed, file)}
+			}
+			return nil
+		},
+	})
+
+	return importsCmd
+}
+
+// lockFile returns the lock file of the loaded config.
+func lockFile(cfg *config.Config) (string, error) {
+	if cfg.File == "" || strings.Contains(cfg.File, "://") {
+		return "", errors.New("remote imports are locked next to a local config file, and none was loaded")
+	}
+
+	return config.LockPath(cfg.File), nil
+}
+
+func reportImports(cfg *config.Config, file string, statuses []importStatus) error {
+	if cfg.Output == output.FormatJSON {
+		return json.NewEncoder(os.Stdout).Encode(struct {
+			SchemaVersion int            `json:"schema_version"`
+			LockFile      string         `json:"lock_file"`
+			Imports       []importStatus `json:"imports"`
+		}{1, file, statuses})
+	}
+
+	renderImports(os.Stdout, file, statuses)
+	return nil
+}
+
+func renderImports(w io.Writer, file string, statuses []importStatus) {
+	if len(statuses) == 0 {
+		tui.Println(w, "no remote imports")
+		return
+	}
+
+	for _, s := range statuses {
+		mark := tui.StyleSuccess.Render("✓")
+		switch s.Status {
+		case "changed", "unlocked":
+			mark = tui.StyleError.Render("✗")
+		case "unused":
+			mark = tui.StyleFaint.Render("-")
+		}
+
+		line := mark + " " + s.URL + "  " + s.Status
+		switch s.Status {
+		case "locked":
+			line += " sha256:" + s.SHA256
+		case "changed":
+			line += " sha256:" + s.SHA256 + ", locked sha256:" + s.LockedSHA256
+		}
+		tui.Println(w, line)
+	}
+	tui.Println(w, tui.StyleFaint.Render("lock file: "+file))
+}
@@ -0,0 +1,48 @@
+package cmd_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_importsCommand(t *testing.T) {
+	content := `{"tasks": {"remote": {"command": ["echo remote"]}}}`
+	srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintln(writer, content)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tasks.yaml")
+	u := srv.URL + "/remote.json"
+	if err := os.WriteFile(file, []byte("import:\n  - {path: "+u+", ttl: 1ns}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	runAppTest(t, appTest{args: []string{"-c", file, "imports", "verify"}, output: []string{u + "  unlocked"}, errored: true})
+	runAppTest(t, appTest{args: []string{"-c", file, "imports", "update"}, output: []string{u + "  locked sha256:", "taskctl.lock"}})
+	if _, err := os.Stat(filepath.Join(dir, "taskctl.lock")); err != nil {
+		t.Fatal(err)
+	}
+	runAppTest(t, appTest{args: []string{"-c", file, "imports", "verify"}, output: []string{u + "  ok"}})
+	runAppTest(t, appTest{args: []string{"-r", "-c", file, "remote"}, output: []string{"remote"}})
+
+	content = strings.Replace(content, "echo remote", "echo changed", 1)
+	runAppTest(t, appTest{args: []string{"-c", file, "imports", "verify"}, output: []string{u + "  changed"}, errored: true})
+	// The cached copy matches the lock, so it is used rather than the
+	// changed remote, and works offline.
+	runAppTest(t, appTest{args: []string{"-r", "-c", file, "remote"}, output: []string{"remote"}, absent: []string{"changed"}})
+	runAppTest(t, appTest{args: []string{"-r", "--offline", "-c", file, "remote"}, output: []string{"remote"}})
+	runAppTest(t, appTest{args: []string{"--offline", "-c", file, "imports", "verify"}, output: []string{u + "  ok"}})
+	runAppTest(t, appTest{args: []string{"-c", file, "-o", "json", "imports", "update"}, output: []string{`"status":"locked"`}})
+	// An update leaves the cache as is, so it holds the content locked
+	// before until the next run fetches the new one.
+	runAppTest(t, appTest{args: []string{"--offline", "-c", file, "imports", "verify"}, output: []string{u + "  changed"}, errored: true})
+	runAppTest(t, appTest{args: []string{"-r", "-c", file, "remote"}, output: []string{"changed"}})
+	runAppTest(t, appTest{args: []string{"--offline", "-c", file, "imports", "verify"}, output: []string{u + "  ok"}})
+}
@@ -107,7 +107,7 @@ func sortedKeys[V any](m map[string]V) func() []string {
 }
 
 // encodeListJSON writes the schema_version-tagged discovery document for
-// `taskctl --output json list`. All four keys are always present, even when
+// `taskctl --output json list`. All five lists are always present, even when
 // empty, so the sorted name slices are used to build non-nil summary slices
 // regardless of length.
 func encodeListJSON(cfg *config.Config, contexts, pipelineNames, taskNames, watchers []string) error {
@@ -127,6 +127,8 @@ func encodeListJSON(cfg *config.Config, contexts, pipelineNames, taskNames, watc
 		Pipelines:     pipelineSummaries,
 		Contexts:      collections.OrEmpty(contexts),
 		Watchers:      collections.OrEmpty(watchers),
+		Profiles:      collections.OrEmpty(cfg.Profiles),
+		Profile:       cfg.Profile,
 	}
 
 	return json.NewEncoder(os.Stdout).Encode(resp)
@@ -182,6 +184,17 @@ func renderList(w io.Writer, cfg *config.Config, contexts, pipelines, tasks, wat
 		}
 	}
 
+	if len(cfg.Profiles) > 0 {
+		header("Profiles")
+		for _, name := range cfg.Profiles {
+			if name == cfg.Profile {
+				tui.Printf(w, "  %s  %s\n", name, tui.StyleFaint.Render("(active)"))
+				continue
+			}
+			tui.Printf(w, "  %s\n", name)
+		}
+	}
+
 	if first {
 		tui.Println(w, tui.StyleFaint.Render("No tasks or pipelines found."))
 	}
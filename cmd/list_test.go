@@ -148,3 +148,15 @@ func Test_listCommand_json_subcommands(t *testing.T) {
 		})
 	}
 }
+
+func Test_listCommandProfiles(t *testing.T) {
+	tests := []appTest{
+		{args: []string{"-c", "testdata/profiles.yaml", "list"}, output: []string{"PROFILES", "ci", "release"}, absent: []string{"(active)"}},
+		{args: []string{"-c", "testdata/profiles.yaml", "--profile", "ci", "list"}, output: []string{"ci  (active)"}},
+		{args: []string{"-c", "testdata/profiles.yaml", "--profile", "ci", "-o", "json", "list"}, output: []string{`"profiles":["ci","release"]`, `"profile":"ci"`}},
+	}
+
+	for _, v := range tests {
+		runAppTest(t, v)
+	}
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/taskctl/taskctl/internal/collections"
+	"github.com/taskctl/taskctl/internal/config"
+	"github.com/taskctl/taskctl/internal/output"
+	"github.com/taskctl/taskctl/internal/tui"
+	"github.com/taskctl/taskctl/runner"
+	"github.com/taskctl/taskctl/scheduler"
+	"github.com/taskctl/taskctl/task"
+)
+
+// skippedStage is a stage or task a dry run did not plan, with the reason.
+type skippedStage struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// planEvent is the NDJSON event carrying a dry run's plan, emitted before
+// run_finished.
+type planEvent struct {
+	Event   string               `json:"event"`
+	Tasks   []runner.PlannedTask `json:"tasks"`
+	Skipped []skippedStage       `json:"skipped"`
+}
+
+// printPlan reports what a dry run would have run, in the order it ran: as a
+// plan event in json output mode, as a readable listing otherwise.
+func printPlan(cfg *config.Config, planned []runner.PlannedTask, graphs []*scheduler.ExecutionGraph, tasks []*task.Task) {
+	var skipped []skippedStage
+	for _, g := range graphs {
+		skipped = append(skipped, skippedStages(g, "")...)
+	}
+	for _, t := range tasks {
+		if t.Skipped {
+			skipped = append(skipped, skippedStage{Name: t.Name, Reason: "condition not met"})
+		}
+	}
+
+	if cfg.Output == output.FormatJSON {
+		data, err := json.Marshal(planEvent{
+			Event:   "plan",
+			Tasks:   collections.OrEmpty(planned),
+			Skipped: collections.OrEmpty(skipped),
+		})
+		if err == nil {
+			_, _ = fmt.Fprintf(os.Stdout, "%s\n", data)
+		}
+		return
+	}
+
+	_, _ = fmt.Fprint(os.Stdout, "\r\n")
+	renderPlan(os.Stdout, planned, skipped)
+}
+
+// skippedStages lists the stages of g, and of the pipelines nested in it named
+// "pipeline/stage", that did not run.
+func skippedStages(g *scheduler.ExecutionGraph, prefix string) []skippedStage {
+	var skipped []skippedStage
+	for _, name := range slices.Sorted(maps.Keys(g.Nodes())) {
+		stage := g.Nodes()[name]
+		switch stage.ReadStatus() {
+		case scheduler.StatusSkipped:
+			skipped = append(skipped, skippedStage{Name: prefix + name, Reason: "condition not met"})
+		case scheduler.StatusCanceled:
+			skipped = append(skipped, skippedStage{Name: prefix + name, Reason: "canceled"})
+		default:
+			if stage.Pipeline != nil {
+				skipped = append(skipped, skippedStages(stage.Pipeline, prefix+name+"/")...)
+			}
+		}
+	}
+
+	return skipped
+}
+
+// renderPlan writes every planned task with its commands per variation,
+// followed by the stages that would not run.
+func renderPlan(w io.Writer, planned []runner.PlannedTask, skipped []skippedStage) {
+	tui.Println(w, tui.StyleBold.Render("Plan"))
+	for i, pt := range planned {
+		heading := fmt.Sprintf("%d. %s", i+1, pt.Name)
+		if pt.Task != pt.Name {
+			heading += tui.StyleFaint.Render(" (task " + pt.Task + ")")
+		}
+		tui.Println(w, heading)
+
+		if pt.Context != "" {
+			planLine(w, "  ", "context", pt.Context)
+		}
+		if pt.Condition != "" {
+			status := " (not evaluated)"
+			if pt.ConditionEvaluated {
+				status = " (met)"
+			}
+			planLine(w, "  ", "condition", pt.Condition+tui.StyleFaint.Render(status))
+		}
+		if pt.Parallel {
+			limit := "unlimited"
+			if pt.ParallelLimit > 0 {
+				limit = fmt.Sprint(pt.ParallelLimit)
+			}
+			planLine(w, "  ", "parallel", limit)
+		}
+		for _, c := range pt.Before {
+			planLine(w, "  ", "before", c)
+		}
+
+		for _, v := range pt.Variations {
+			indent := "  "
+			if len(v.Variation) > 0 {
+				tui.Println(w, "  "+tui.StylePrefix.Render("["+formatEnv(v.Variation)+"]"))
+				indent = "    "
+			}
+			planLine(w, indent, "dir", v.Dir)
+			if len(v.Env) > 0 {
+				planLine(w, indent, "env", formatEnv(v.Env))
+			}
+			for _, c := range v.Commands {
+				tui.Println(w, indent+"$ "+strings.ReplaceAll(c, "\n", "\n"+indent+"  "))
+			}
+		}
+
+		for _, c := range pt.After {
+			planLine(w, "  ", "after", c)
+		}
+	}
+
+	if len(skipped) == 0 {
+		return
+	}
+
+	tui.Println(w, "")
+	tui.Println(w, tui.StyleBold.Render("Skipped"))
+	for _, s := range skipped {
+		tui.Println(w, "  "+s.Name+tui.StyleFaint.Render(" ("+s.Reason+")"))
+	}
+}
+
+func planLine(w io.Writer, indent, label, value string) {
+	tui.Println(w, indent+tui.StyleFaint.Render(label+":")+" "+value)
+}
+
+// formatEnv renders env as sorted KEY=value pairs.
+func formatEnv(env map[string]string) string {
+	pairs := make([]string, 0, len(env))
+	for _, k := range slices.Sorted(maps.Keys(env)) {
+		pairs = append(pairs, k+"="+env[k])
+	}
+
+	return strings.Join(pairs, " ")
+}
@@ -12,10 +12,12 @@ import (
 	"slices"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
+	"github.com/taskctl/taskctl/executor"
 	"github.com/taskctl/taskctl/internal/config"
 	"github.com/taskctl/taskctl/internal/output"
 	"github.com/taskctl/taskctl/internal/tui"
@@ -123,14 +125,26 @@ func NewRootCommand(version string) *cobra.Command {
 	fs.StringP("output", "o", "", "output format (default, prefixed, raw or json)")
 	fs.BoolP("raw", "r", false, "shortcut for --output=raw")
 	fs.BoolP("quiet", "q", false, "quiet mode")
-	fs.StringSlice("set", nil, "set global variable value")
-	fs.Bool("dry-run", false, "dry run")
+	fs.StringArray("set", nil, "set a variable, KEY=value for every task or TASK.KEY=value or STAGE.KEY=value for one; a JSON list or object sets a structured one; KEY=value,KEY=value sets several")
+	fs.StringArray("env", nil, "set an environment variable, KEY=value for every task or TASK.KEY=value or STAGE.KEY=value for one")
+	fs.StringArray("var-file", nil, "set the variables of a YAML, JSON, TOML or dotenv file for every task")
+	fs.Bool("dry-run", false, "print the execution plan without running any command")
+	fs.Bool("dry-run-conditions", false, "with --dry-run, run the tasks' conditions so the plan leaves out the tasks they skip")
 	fs.BoolP("summary", "s", true, "show summary")
 	fs.Bool("no-input", false, "disable interactive prompts")
+	fs.String("log-dir", "", "write each task's output to its own log file in this directory")
+	fs.Bool("log-combined", false, "also write a combined log of all tasks to the log directory")
+	fs.String("log-memory-limit", "", "keep at most this much of each task's output in memory, spilling the rest to a temporary file (default 16MiB)")
+	fs.Bool("no-history", false, "do not record the run in the history store")
+	fs.Duration("timeout", 0, "stop the run after this long, reporting the tasks still running as timed out")
+	fs.Bool("offline", false, "read remote imports from the cache only, without fetching them")
+	fs.String("profile", "", "overlay a profile of the config on its tasks, contexts, variables and env")
+	fs.Bool("cgroup-leaf", false, "move taskctl into a cgroup beneath its own when needed to enforce memory and cpus limits; the move outlives the run")
 
 	_ = root.RegisterFlagCompletionFunc("output", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
 		return []string{output.FormatDefault, output.FormatPrefixed, output.FormatRaw, output.FormatJSON}, cobra.ShellCompDirectiveNoFileComp
 	})
+	_ = root.RegisterFlagCompletionFunc("profile", completionFunc(cfg, func() []string { return cfg.Profiles }))
 
 	root.SetFlagErrorFunc(func(_ *cobra.Command, e error) error { return usageError{e} })
 
@@ -147,10 +161,15 @@ func NewRootCommand(version string) *cobra.Command {
 		newInitCommand(cfg),
 		newListCommand(cfg),
 		newShowCommand(cfg),
+		newExplainCommand(cfg),
 		newWatchCommand(cfg),
 		newGraphCommand(cfg),
 		newValidateCommand(cfg),
+		newHistoryCommand(cfg),
+		newLogsCommand(cfg),
+		newStatsCommand(cfg),
 		newSkillCommand(),
+		newImportsCommand(cfg, cl),
 	)
 
 	markUsageErrors(root)
@@ -168,12 +187,22 @@ func resolveConfig(cmd *cobra.Command, cfg *config.Config, cl *config.Loader) er
 		{"debug", "TASKCTL_DEBUG"},
 		{"config", "TASKCTL_CONFIG_FILE"},
 		{"no-input", "TASKCTL_NO_INPUT"},
+		{"no-history", "TASKCTL_NO_HISTORY"},
+		{"timeout", "TASKCTL_TIMEOUT"},
+		{"offline", "TASKCTL_OFFLINE"},
+		{"profile", "TASKCTL_PROFILE"},
+		{"cgroup-leaf", "TASKCTL_CGROUP_LEAF"},
 	} {
 		if err := bindEnv(fs, b.name, b.env); err != nil {
 			return err
 		}
 	}
 
+	offline, _ := fs.GetBool("offline")
+	cl.SetRemoteOptions(config.RemoteOptions{Offline: offline, Refresh: cmd.Annotations[annotationRefreshImports] != ""})
+	profile, _ := fs.GetString("profile")
+	cl.SetProfile(profile)
+
 	configFile, _ := fs.GetString("config")
 	if _, err := cl.Load(configFile); err != nil {
 		if !errors.Is(err, config.ErrConfigNotFound) || fs.Changed("config") {
@@ -181,10 +210,31 @@ func resolveConfig(cmd *cobra.Command, cfg *config.Config, cl *config.Loader) er
 		}
 	}
 
-	set, _ := fs.GetStringSlice("set")
-	for _, kv := range set {
-		if k, v, ok := strings.Cut(kv, "="); ok {
-			cfg.Variables.Set(k, v)
+	// Values given on the command line override the config's: variable
+	// files in order, then --set and --env.
+	varFiles, _ := fs.GetStringArray("var-file")
+	for _, name := range varFiles {
+		if err := cl.LoadVarFile(name); err != nil {
+			return fmt.Errorf("--var-file %s: %w", name, err)
+		}
+	}
+	for _, flag := range []string{"set", "env"} {
+		values, _ := fs.GetStringArray(flag)
+		if flag == "set" {
+			var split []string
+			for _, kv := range values {
+				split = append(split, config.SplitSet(kv)...)
+			}
+			values = split
+		}
+		for _, kv := range values {
+			o, err := config.ParseOverride("--"+flag, kv)
+			if err != nil {
+				return usageError{err}
+			}
+			if err := cfg.Override(o); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -242,6 +292,23 @@ func resolveConfig(cmd *cobra.Command, cfg *config.Config, cl *config.Loader) er
 	}
 	cfg.DryRun = dryRun
 
+	// Like dry-run, the log flags override the config file's log_dir:,
+	// log_combined: and log_memory_limit: only when they are passed
+	// explicitly.
+	if fs.Changed("log-dir") {
+		cfg.LogDir, _ = fs.GetString("log-dir")
+	}
+	if fs.Changed("log-combined") {
+		cfg.LogCombined, _ = fs.GetBool("log-combined")
+	}
+	if fs.Changed("log-memory-limit") {
+		limit, _ := fs.GetString("log-memory-limit")
+		var err error
+		if cfg.LogMemoryLimit, err = config.ParseLogMemoryLimit(limit); err != nil {
+			return fmt.Errorf("invalid --log-memory-limit: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -277,13 +344,22 @@ func buildTaskRunner(cmd *cobra.Command, cfg *config.Config) (*runner.TaskRunner
 	variables := cfg.Variables.With("Args", strings.Join(passArgs, " "))
 	variables.Set("ArgsList", passArgs)
 
-	taskRunner, err := runner.NewTaskRunner(runner.WithContexts(cfg.Contexts), runner.WithVariables(variables))
+	taskRunner, err := runner.NewTaskRunner(runner.WithContexts(cfg.Contexts), runner.WithVariables(variables), runner.WithEnv(cfg.Env))
 	if err != nil {
 		return nil, err
 	}
 
 	taskRunner.OutputFormat = cfg.Output
 	taskRunner.DryRun = cfg.DryRun
+	taskRunner.DryRunConditions, _ = cmd.Flags().GetBool("dry-run-conditions")
+	taskRunner.LogDir = cfg.LogDir
+	taskRunner.LogCombined = cfg.LogCombined
+	taskRunner.LogMemoryLimit = cfg.LogMemoryLimit
+	taskRunner.EnvInherit = cfg.EnvInherit
+	executor.MoveToCgroupLeaf, _ = cmd.Flags().GetBool("cgroup-leaf")
+	if timeout, _ := cmd.Flags().GetDuration("timeout"); timeout > 0 {
+		taskRunner.Deadline = time.Now().Add(timeout)
+	}
 
 	if cfg.Quiet {
 		taskRunner.Stdout = io.Discard
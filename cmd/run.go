@@ -10,6 +10,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/taskctl/taskctl/executor"
 	"github.com/taskctl/taskctl/internal/config"
 	"github.com/taskctl/taskctl/internal/output"
 	"github.com/taskctl/taskctl/runner"
@@ -90,6 +91,8 @@ func runTargets(cmd *cobra.Command, cfg *config.Config, targets []string, tasksO
 	}
 
 	summary := summaryEnabled(cmd, cfg)
+	output.SetEstimates(runEstimates(cmd, cfg, targets))
+	start := time.Now()
 	emitRunStarted(cfg, targets)
 
 	var graphs []*scheduler.ExecutionGraph
@@ -108,14 +111,38 @@ func runTargets(cmd *cobra.Command, cfg *config.Config, targets []string, tasksO
 		}
 	}
 
+	recordRun(cmd, cfg, targets, start, graphs, tasks, err)
+	if cfg.DryRun {
+		printPlan(cfg, taskRunner.Plan(), graphs, tasks)
+	}
+
 	// When finishRun surfaced the failure (summary or JSON run_finished event),
 	// mark it reported so the top-level presenter doesn't print it again.
-	if reported := finishRun(cfg, graphs, tasks, summary, err); err != nil && reported {
+	reported := finishRun(cfg, graphs, tasks, summary, err)
+	closeTasks(graphs, tasks)
+	if err != nil && reported {
 		return reportedError{err}
 	}
 	return err
 }
 
+// closeTasks releases the output of the tasks a run ran, and of the stages of
+// its pipelines, once the history and the summary are written.
+func closeTasks(graphs []*scheduler.ExecutionGraph, tasks []*task.Task) {
+	for _, g := range graphs {
+		for _, stage := range g.Nodes() {
+			if stage.Pipeline != nil {
+				closeTasks([]*scheduler.ExecutionGraph{stage.Pipeline}, nil)
+			} else if stage.Task != nil {
+				stage.Task.Close()
+			}
+		}
+	}
+	for _, t := range tasks {
+		t.Close()
+	}
+}
+
 // runTarget runs the pipeline or task named by name and reports back
 // whichever of the two it ran, so callers can aggregate results for the
 // NDJSON run_finished event.
@@ -149,6 +176,7 @@ func runTarget(cfg *config.Config, taskRunner *runner.TaskRunner, name string, t
 // summary must print after that teardown.
 func runPipeline(g *scheduler.ExecutionGraph, taskRunner *runner.TaskRunner) error {
 	sd := scheduler.NewScheduler(taskRunner)
+	sd.Deadline = taskRunner.Deadline
 
 	err := sd.Schedule(g)
 	sd.Finish()
@@ -178,7 +206,8 @@ func emitRunStarted(cfg *config.Config, targets []string) {
 // emitRunFinished writes the run_finished NDJSON event when running in json
 // output mode; it is a no-op otherwise. It builds per-task results from both
 // executed pipeline graphs and directly-run tasks, and derives an overall
-// status of "failed" if err is non-nil or any task/stage failed.
+// status of "failed" if err is non-nil or any task/stage failed, or
+// "timed_out" if the run was stopped by a timeout.
 func emitRunFinished(cfg *config.Config, graphs []*scheduler.ExecutionGraph, tasks []*task.Task, runErr error) {
 	if cfg.Output != output.FormatJSON {
 		return
@@ -193,7 +222,7 @@ func emitRunFinished(cfg *config.Config, graphs []*scheduler.ExecutionGraph, tas
 		for _, name := range names {
 			stage := g.Nodes()[name]
 			status := stageStatus(stage)
-			if status == "failed" || status == "canceled" {
+			if output.IsFailure(status) || status == "canceled" {
 				failed = true
 			}
 
@@ -214,13 +243,16 @@ func emitRunFinished(cfg *config.Config, graphs []*scheduler.ExecutionGraph, tas
 				ExitCode:   exitCode,
 				DurationMs: durationMs,
 			})
+			if stage.Task != nil {
+				results = append(results, unitResults(stage.Task)...)
+			}
 		}
 		totalDuration += g.Duration()
 	}
 
 	for _, t := range tasks {
 		status := output.TaskStatus(t)
-		if status == "failed" {
+		if output.IsFailure(status) {
 			failed = true
 		}
 
@@ -230,11 +262,15 @@ func emitRunFinished(cfg *config.Config, graphs []*scheduler.ExecutionGraph, tas
 			ExitCode:   int(t.ExitCode),
 			DurationMs: t.Duration().Milliseconds(),
 		})
+		results = append(results, unitResults(t)...)
 		totalDuration += t.Duration()
 	}
 
 	status := "done"
-	if failed {
+	switch {
+	case errors.Is(runErr, executor.ErrTimedOut):
+		status = "timed_out"
+	case failed:
 		status = "failed"
 	}
 
@@ -246,6 +282,21 @@ func emitRunFinished(cfg *config.Config, graphs []*scheduler.ExecutionGraph, tas
 	_ = output.EmitRunFinished(os.Stdout, status, totalDuration.Milliseconds(), results, errMsg)
 }
 
+// unitResults reports the variations or commands a parallel task ran.
+func unitResults(t *task.Task) []output.TaskResult {
+	results := make([]output.TaskResult, 0, len(t.Units))
+	for _, u := range t.Units {
+		results = append(results, output.TaskResult{
+			Task:       u.Name,
+			Status:     output.TaskStatus(u),
+			ExitCode:   int(u.ExitCode),
+			DurationMs: u.Duration().Milliseconds(),
+		})
+	}
+
+	return results
+}
+
 // stageStatus maps a scheduler stage status to the NDJSON status vocabulary.
 func stageStatus(stage *scheduler.Stage) string {
 	switch stage.ReadStatus() {
@@ -253,6 +304,8 @@ func stageStatus(stage *scheduler.Stage) string {
 		return "done"
 	case scheduler.StatusError:
 		return "failed"
+	case scheduler.StatusTimedOut:
+		return "timed_out"
 	case scheduler.StatusSkipped:
 		return "skipped"
 	default:
@@ -343,6 +396,9 @@ func summarizeGraph(g *scheduler.ExecutionGraph) []output.StageSummary {
 		}
 
 		items = append(items, s)
+		if stage.Task != nil {
+			items = append(items, output.SummarizeTasks(stage.Task.Units)...)
+		}
 	}
 	return items
 }
@@ -98,6 +98,66 @@ func Test_runCommandSummary(t *testing.T) {
 			args:   []string{"--output=prefixed", "-c", "testdata/summary-off.yaml", "hello"},
 			output: []string{"hello, world!"}, absent: []string{"succeeded", "total"},
 		},
+		// Output past --log-memory-limit spills to disk, and the summary
+		// still reads the end of it.
+		{
+			args:    []string{"--output=prefixed", "--log-memory-limit=1KiB", "-c", "testdata/spill.yaml", "noisy"},
+			output:  []string{"line 199", "1 failed", "it broke"},
+			errored: true,
+		},
+		{
+			args:    []string{"--log-memory-limit=lots", "-c", "testdata/spill.yaml", "noisy"},
+			errored: true,
+			absent:  []string{"line 0"},
+		},
+		// Computed variables and env run their command when the task runs.
+		{args: []string{"-c", "testdata/computed.yaml", "greet"}, output: []string{"hello, world!"}},
+		{
+			args:    []string{"-c", "testdata/computed.yaml", "broken"},
+			output:  []string{`variable Tag: sh "echo no tags >&2; exit 3": exit status 3: no tags`},
+			errored: true,
+		},
+		// Variables keep their type: lists and maps can be ranged over, at
+		// every layer and from --set JSON.
+		{args: []string{"-c", "testdata/typed.yaml", "ports"}, output: []string{"api:8080 web:3000"}},
+		{args: []string{"-c", "testdata/typed.yaml", "flags"}, output: []string{"debug=on retries=002"}},
+		{args: []string{"-c", "testdata/typed.yaml", "release"}, output: []string{"web:3000"}, absent: []string{"api:8080"}},
+		{args: []string{"-c", "testdata/typed.yaml", "--set", `Packages=["api"]`, "--set", `Ports={"api": 1}`, "ports"}, output: []string{"api:1"}},
+		// Variable files, --set and --env override the config, globally or
+		// for a task or stage.
+		{
+			args:   []string{"-r", "-c", "testdata/overrides.yaml", "--var-file", "testdata/overrides-vars.yaml", "--var-file", "testdata/overrides-vars.env", "info"},
+			output: []string{"channel=from-dotenv target=local packages=api"},
+		},
+		{
+			args:   []string{"-r", "-c", "testdata/overrides.yaml", "--var-file", "testdata/overrides-vars.yaml", "--set", `info.Packages=["web"]`, "--env", "TARGET=ci", "info"},
+			output: []string{"channel=edge target=local packages=web"},
+		},
+		// A --set value is split on commas only when every piece is
+		// KEY=value.
+		{args: []string{"-r", "-c", "testdata/overrides.yaml", "--set", "Channel=a,info.Channel=b", "other"}, output: []string{"other channel=a target="}},
+		{args: []string{"-r", "-c", "testdata/overrides.yaml", "--set", "Channel=a,b", "other"}, output: []string{"other channel=a,b target="}},
+		{
+			args:   []string{"-r", "-c", "testdata/overrides.yaml", "--set", "release/info.Channel=hotfix", "--env", "info.TARGET=prod", "--env", "TARGET=ci", "release"},
+			output: []string{"channel=hotfix target=prod packages=api", "other channel=stable target=ci"},
+		},
+		// An import's tasks and pipelines are addressed by its namespace and
+		// get its variables.
+		{args: []string{"-r", "-c", "testdata/imports.yaml", "build"}, output: []string{"root build"}, absent: []string{"build api"}},
+		{args: []string{"-r", "-c", "testdata/imports.yaml", "backend:release"}, output: []string{"build api", "migrate db"}},
+		// An imported task runs in its file's directory by default.
+		{args: []string{"-r", "-c", "testdata/imports.yaml", "backend:where"}, output: []string{"file=backend.yaml dir=imports", "pwd=imports"}},
+		// A profile overlays its tasks and env, and --env overrides its env.
+		{args: []string{"-r", "-c", "testdata/profiles.yaml", "build"}, output: []string{"build local"}},
+		{args: []string{"-r", "-c", "testdata/profiles.yaml", "--profile", "ci", "build"}, output: []string{"build ci"}},
+		{args: []string{"-r", "-c", "testdata/profiles.yaml", "--profile", "ci", "target"}, output: []string{"deploy to staging"}},
+		{args: []string{"-r", "-c", "testdata/profiles.yaml", "--profile", "ci", "--env", "DEPLOY_ENV=prod", "target"}, output: []string{"deploy to prod"}},
+		// --timeout stops the run and reports the task as timed out.
+		{
+			args:    []string{"--output=prefixed", "--timeout=100ms", "-c", "testdata/timeout.yaml", "slow"},
+			output:  []string{"1 timed out", "run deadline exceeded"},
+			errored: true,
+		},
 	}
 
 	for _, v := range tests {
@@ -111,9 +171,26 @@ func Test_runCommandSummary(t *testing.T) {
 func Test_runCommandDryRun(t *testing.T) {
 	tests := []appTest{
 		// Sanity: without dry-run the fixture really produces output.
-		{args: []string{"--raw", "-c", "testdata/dryrun.yaml", "echo-task"}, output: []string{"dry-run-marker"}},
-		// --dry-run skips execution: no command output.
-		{args: []string{"--raw", "-c", "testdata/dryrun.yaml", "--dry-run", "echo-task"}, absent: []string{"dry-run-marker"}},
+		{args: []string{"--raw", "-c", "testdata/dryrun.yaml", "echo-task"}, output: []string{"dry-run-marker-42"}, absent: []string{"Plan"}},
+		// --dry-run skips execution: no command output, only the plan listing
+		// the unexpanded command.
+		{
+			args:   []string{"--raw", "-c", "testdata/dryrun.yaml", "--dry-run", "echo-task"},
+			output: []string{"Plan", "1. echo-task", "$ echo dry-run-marker-$((40+2))"},
+			absent: []string{"dry-run-marker-42"},
+		},
+		// A task's condition is not run, so the task is planned.
+		{
+			args:   []string{"--raw", "-c", "testdata/dryrun.yaml", "--dry-run", "skipped-task"},
+			output: []string{"1. skipped-task", "condition: exit 1 (not evaluated)"},
+			absent: []string{"Skipped"},
+		},
+		// --dry-run-conditions runs it, and the plan lists the task as skipped.
+		{
+			args:   []string{"--raw", "-c", "testdata/dryrun.yaml", "--dry-run", "--dry-run-conditions", "skipped-task"},
+			output: []string{"Skipped", "skipped-task (condition not met)"},
+			absent: []string{"1. skipped-task"},
+		},
 		// A command that would fail is not run, so the task still completes.
 		{args: []string{"--raw", "-c", "testdata/dryrun.yaml", "--dry-run", "fail-task"}},
 		// Config dryrun: true enables it without the flag.
@@ -127,6 +204,66 @@ func Test_runCommandDryRun(t *testing.T) {
 	}
 }
 
+// Test_runCommandDryRunPlan asserts the plan renders each variation's command
+// as wrapped by its context, with the env it adds to the host's, and lists the
+// stages that would be skipped; and that json output carries it as a plan event.
+func Test_runCommandDryRunPlan(t *testing.T) {
+	runAppTest(t, appTest{
+		args: []string{"--raw", "-c", "testdata/dryrun.yaml", "--dry-run", "plan"},
+		output: []string{
+			"1. build",
+			"context: wrapped",
+			"before: /bin/sh -c 'mkdir -p bin'",
+			"[GOOS=linux]",
+			"env: GOOS=linux",
+			"$ /bin/sh -c 'go build -o bin/app-$GOOS'",
+			"[GOOS=darwin]",
+			"Skipped",
+			"deploy (condition not met)",
+		},
+	})
+
+	out, err := captureStdout(t, []string{"-c", "testdata/dryrun.yaml", "-o", "json", "--dry-run", "run", "plan"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var plan struct {
+		Event string `json:"event"`
+		Tasks []struct {
+			Name       string `json:"name"`
+			Variations []struct {
+				Variation map[string]string `json:"variation"`
+				Env       map[string]string `json:"env"`
+				Commands  []string          `json:"commands"`
+			} `json:"variations"`
+		} `json:"tasks"`
+		Skipped []struct {
+			Name   string `json:"name"`
+			Reason string `json:"reason"`
+		} `json:"skipped"`
+	}
+	for _, line := range splitLines(out) {
+		if err := json.Unmarshal(line, &plan); err != nil {
+			t.Fatalf("invalid ndjson line %q: %v", line, err)
+		}
+		if plan.Event == "plan" {
+			break
+		}
+	}
+
+	if plan.Event != "plan" || len(plan.Tasks) != 1 || len(plan.Tasks[0].Variations) != 2 {
+		t.Fatalf("unexpected plan event %+v", plan)
+	}
+	v := plan.Tasks[0].Variations[0]
+	if v.Variation["GOOS"] != "linux" || v.Env["GOOS"] != "linux" || v.Commands[0] != "/bin/sh -c 'go build -o bin/app-$GOOS'" {
+		t.Errorf("unexpected planned variation %+v", v)
+	}
+	if len(plan.Skipped) != 1 || plan.Skipped[0].Name != "deploy" {
+		t.Errorf("expected the deploy stage to be skipped, got %+v", plan.Skipped)
+	}
+}
+
 func splitLines(b []byte) [][]byte {
 	var lines [][]byte
 	start := 0
@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -14,6 +17,7 @@ import (
 	"github.com/taskctl/taskctl/internal/schema"
 	"github.com/taskctl/taskctl/internal/tui"
 	"github.com/taskctl/taskctl/task"
+	"github.com/taskctl/taskctl/variables"
 )
 
 func newShowCommand(cfg *config.Config) *cobra.Command {
@@ -35,10 +39,11 @@ func newShowCommand(cfg *config.Config) *cobra.Command {
 					vars := cfg.Variables.Merge(t.Variables).Map()
 					return json.NewEncoder(os.Stdout).Encode(struct {
 						SchemaVersion int               `json:"schema_version"`
+						Profile       string            `json:"profile,omitempty"`
 						Task          schema.TaskDetail `json:"task"`
-					}{1, schema.NewTaskDetail(t, vars)})
+					}{1, cfg.Profile, schema.NewTaskDetail(t, vars)})
 				}
-				renderTask(os.Stdout, t)
+				renderTask(os.Stdout, t, cfg.Profile)
 				return nil
 			}
 
@@ -47,8 +52,9 @@ func newShowCommand(cfg *config.Config) *cobra.Command {
 				if cfg.Output == output.FormatJSON {
 					return json.NewEncoder(os.Stdout).Encode(struct {
 						SchemaVersion int                   `json:"schema_version"`
+						Profile       string                `json:"profile,omitempty"`
 						Pipeline      schema.PipelineDetail `json:"pipeline"`
-					}{1, detail})
+					}{1, cfg.Profile, detail})
 				}
 				renderPipeline(os.Stdout, detail)
 				return nil
@@ -59,7 +65,7 @@ func newShowCommand(cfg *config.Config) *cobra.Command {
 	}
 }
 
-func renderTask(w io.Writer, t *task.Task) {
+func renderTask(w io.Writer, t *task.Task, profile string) {
 	title := tui.StyleBold.Render(t.Name)
 	if t.Description != "" {
 		title += "  " + tui.StyleFaint.Render(t.Description)
@@ -69,7 +75,7 @@ func renderTask(w io.Writer, t *task.Task) {
 	tui.Println(w, "")
 
 	row := func(k, v string) {
-		tui.Printf(w, "  %s  %s\n", tui.StyleFaint.Render(fmt.Sprintf("%-13s", k)), v)
+		tui.Printf(w, "  %s  %s\n", tui.StyleFaint.Render(fmt.Sprintf("%-15s", k)), v)
 	}
 
 	ctx := t.Context
@@ -77,6 +83,9 @@ func renderTask(w io.Writer, t *task.Task) {
 		ctx = tui.StyleFaint.Render("(default)")
 	}
 	row("Context", ctx)
+	if profile != "" {
+		row("Profile", profile)
+	}
 
 	tui.Printf(w, "  %s\n", tui.StyleFaint.Render("Commands"))
 	for _, c := range t.Commands {
@@ -89,7 +98,46 @@ func renderTask(w io.Writer, t *task.Task) {
 	if t.Timeout != nil {
 		row("Timeout", t.Timeout.String())
 	}
-	row("Allow failure", fmt.Sprintf("%t", t.AllowFailure))
+	if t.CommandTimeout != nil {
+		row("Command timeout", t.CommandTimeout.String())
+	}
+	if len(t.SuccessCodes) > 0 {
+		row("Success codes", joinCodes(t.SuccessCodes))
+	}
+	allowFailure := fmt.Sprintf("%t", t.AllowFailure)
+	if t.AllowFailure && len(t.AllowFailureExitCodes) > 0 {
+		allowFailure = "exit codes " + joinCodes(t.AllowFailureExitCodes)
+	}
+	row("Allow failure", allowFailure)
+
+	renderValues(w, "Variables", t.Variables)
+	renderValues(w, "Env", t.Env)
+}
+
+// renderValues lists the entries of c as NAME = value, with lists and maps
+// as JSON and a computed value as sh: command.
+func renderValues(w io.Writer, title string, c variables.Container) {
+	if c == nil {
+		return
+	}
+	values := c.Map()
+	if len(values) == 0 {
+		return
+	}
+
+	tui.Printf(w, "  %s\n", tui.StyleFaint.Render(title))
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		tui.Printf(w, "    %s = %s\n", name, variables.Format(values[name]))
+	}
+}
+
+func joinCodes(codes []int) string {
+	s := make([]string, len(codes))
+	for i, c := range codes {
+		s[i] = strconv.Itoa(c)
+	}
+
+	return strings.Join(s, ", ")
 }
 
 func renderPipeline(w io.Writer, detail schema.PipelineDetail) {
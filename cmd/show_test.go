@@ -15,6 +15,8 @@ func Test_showCommand(t *testing.T) {
 		{args: []string{"-c", "testdata/graph.yaml", "show", "graph:task1"}, output: []string{"graph:task1", "echo 'hello, world!'"}},
 		// Text mode now renders pipelines too (previously errored "unknown task").
 		{args: []string{"-c", "testdata/graph.yaml", "show", "graph:pipeline1"}, output: []string{"graph:pipeline1", "graph:task1"}},
+		{args: []string{"-c", "testdata/computed.yaml", "show", "greet"}, output: []string{"Env", "NAME = sh: printf world"}},
+		{args: []string{"-c", "testdata/profiles.yaml", "--profile", "ci", "show", "build"}, output: []string{"Profile", "ci", "echo build ci"}},
 	}
 
 	for _, v := range tests {
@@ -86,3 +88,22 @@ func Test_showCommand_json_unknown(t *testing.T) {
 		t.Errorf("unexpected error message: %v", err)
 	}
 }
+
+func Test_showCommand_profileEnv(t *testing.T) {
+	t.Setenv("TASKCTL_PROFILE", "ci")
+	out, err := captureStdout(t, []string{"-c", "testdata/profiles.yaml", "-o", "json", "show", "build"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var resp struct {
+		Profile string            `json:"profile"`
+		Task    schema.TaskDetail `json:"task"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		t.Fatalf("invalid json: %v\noutput: %s", err, out)
+	}
+	if resp.Profile != "ci" || len(resp.Task.Commands) != 1 || resp.Task.Commands[0] != "echo build ci" {
+		t.Errorf("TASKCTL_PROFILE=ci: got profile %q, commands %v", resp.Profile, resp.Task.Commands)
+	}
+}
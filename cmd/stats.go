@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/taskctl/taskctl/internal/config"
+	"github.com/taskctl/taskctl/internal/history"
+	"github.com/taskctl/taskctl/internal/output"
+	"github.com/taskctl/taskctl/internal/tui"
+)
+
+func newStatsCommand(cfg *config.Config) *cobra.Command {
+	var limit int
+
+	statsCmd := &cobra.Command{
+		Use:   "stats [TARGET]",
+		Short: "shows duration statistics from the run history",
+		Long: "Summarizes the runs in the history store: the median (p50) and 95th percentile durations, failure rate and duration trend " +
+			"of the runs and of every task and stage they executed. With TARGET, only runs of that pipeline or task are considered; " +
+			"a task that was only ever run as a pipeline stage is matched by name across all runs. " +
+			"The trend compares the median duration of the newer half of the successful runs against the older half. " +
+			"With --output json, emits a schema-versioned document.",
+		Example: "  taskctl stats\n" +
+			"  taskctl stats release\n" +
+			"  taskctl stats --limit 20 --output json",
+		GroupID:           groupInspect,
+		Args:              rangeArgs(0, 1, "stats accepts at most one task or pipeline name"),
+		ValidArgsFunction: targetCompletion(cfg),
+		RunE: func(_ *cobra.Command, args []string) error {
+			store, err := historyStore(cfg)
+			if err != nil {
+				return err
+			}
+
+			runs, err := store.List()
+			if err != nil {
+				return err
+			}
+
+			var target string
+			if len(args) == 1 {
+				target = args[0]
+			}
+
+			total, tasks := runStats(runs, target, limit)
+			if cfg.Output == output.FormatJSON {
+				return encodeStatsJSON(target, total, tasks)
+			}
+
+			renderStats(os.Stdout, total, tasks)
+			return nil
+		},
+	}
+	statsCmd.Flags().IntVarP(&limit, "limit", "n", 0, "number of most recent runs to analyze (0 for all)")
+
+	return statsCmd
+}
+
+// runStats computes the whole-run and per-task statistics of the newest limit
+// runs of target (all runs when empty). A target no run was started with is
+// looked up among the tasks and stages of every run instead; total is nil then.
+func runStats(runs []*history.Run, target string, limit int) (*history.Stats, []history.Stats) {
+	selected := slices.DeleteFunc(slices.Clone(runs), func(r *history.Run) bool {
+		return target != "" && !slices.Contains(r.Targets, target)
+	})
+
+	if target != "" && len(selected) == 0 {
+		if limit > 0 && len(runs) > limit {
+			runs = runs[:limit]
+		}
+		tasks := history.TaskStats(runsOfTask(runs, target))
+		return nil, tasks
+	}
+
+	if limit > 0 && len(selected) > limit {
+		selected = selected[:limit]
+	}
+	if len(selected) == 0 {
+		return nil, nil
+	}
+
+	name := target
+	if name == "" {
+		name = "all runs"
+	}
+	total := history.RunStats(name, selected)
+
+	return &total, history.TaskStats(selected)
+}
+
+// runsOfTask narrows every run's records to those of the named task or stage.
+func runsOfTask(runs []*history.Run, name string) []*history.Run {
+	narrowed := make([]*history.Run, 0, len(runs))
+	for _, r := range runs {
+		n := *r
+		n.Tasks = slices.DeleteFunc(slices.Clone(r.Tasks), func(t history.Task) bool {
+			return t.Name != name && t.Task != name
+		})
+		narrowed = append(narrowed, &n)
+	}
+
+	return narrowed
+}
+
+// runEstimates derives the dashboard's expected durations from the history:
+// per task and stage across all recorded runs, and for the whole run from the
+// successful runs of the same targets. Without a history it returns none.
+func runEstimates(cmd *cobra.Command, cfg *config.Config, targets []string) output.Estimates {
+	if cfg.Output != output.FormatDefault || !historyEnabled(cmd, cfg) {
+		return output.Estimates{}
+	}
+
+	store, err := historyStore(cfg)
+	if err != nil {
+		return output.Estimates{}
+	}
+
+	runs, err := store.List()
+	if err != nil {
+		slog.Debug(fmt.Sprintf("run history: %s", err))
+		return output.Estimates{}
+	}
+
+	var totals []time.Duration
+	for _, r := range runs {
+		if r.Status == "done" && slices.Equal(r.Targets, targets) {
+			totals = append(totals, r.Duration())
+		}
+	}
+
+	return output.Estimates{
+		Tasks: history.Estimates(runs),
+		Total: history.Percentile(totals, 50),
+	}
+}
+
+// statsRow is the JSON view of history.Stats.
+type statsRow struct {
+	Name        string  `json:"name"`
+	Runs        int     `json:"runs"`
+	Failures    int     `json:"failures"`
+	FailureRate float64 `json:"failure_rate"`
+	P50Ms       int64   `json:"p50_ms"`
+	P95Ms       int64   `json:"p95_ms"`
+	Trend       float64 `json:"trend"`
+}
+
+func newStatsRow(s history.Stats) statsRow {
+	return statsRow{
+		Name:        s.Name,
+		Runs:        s.Runs,
+		Failures:    s.Failures,
+		FailureRate: s.FailureRate(),
+		P50Ms:       s.P50.Milliseconds(),
+		P95Ms:       s.P95.Milliseconds(),
+		Trend:       s.Trend,
+	}
+}
+
+func encodeStatsJSON(target string, total *history.Stats, tasks []history.Stats) error {
+	doc := struct {
+		SchemaVersion int        `json:"schema_version"`
+		Target        string     `json:"target,omitempty"`
+		Runs          *statsRow  `json:"runs,omitempty"`
+		Tasks         []statsRow `json:"tasks"`
+	}{SchemaVersion: 1, Target: target, Tasks: make([]statsRow, 0, len(tasks))}
+
+	if total != nil {
+		row := newStatsRow(*total)
+		doc.Runs = &row
+	}
+	for _, s := range tasks {
+		doc.Tasks = append(doc.Tasks, newStatsRow(s))
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(doc)
+}
+
+// renderStats writes the whole-run line followed by an aligned table of the
+// tasks and stages.
+func renderStats(w io.Writer, total *history.Stats, tasks []history.Stats) {
+	if total == nil && len(tasks) == 0 {
+		tui.Println(w, tui.StyleFaint.Render("No runs recorded."))
+		return
+	}
+
+	if total != nil {
+		tui.Printf(w, "%s  %d run(s)  %s failed  p50 %s  p95 %s  %s\n",
+			tui.StyleBold.Render(total.Name),
+			total.Runs,
+			formatRate(total.FailureRate()),
+			formatStatsDuration(total.P50),
+			formatStatsDuration(total.P95),
+			formatTrend(total.Trend),
+		)
+	}
+
+	if len(tasks) == 0 {
+		return
+	}
+
+	table := [][]string{{"NAME", "RUNS", "FAILED", "P50", "P95", "TREND"}}
+	for _, s := range tasks {
+		table = append(table, []string{
+			s.Name,
+			fmt.Sprint(s.Runs),
+			formatRate(s.FailureRate()),
+			formatStatsDuration(s.P50),
+			formatStatsDuration(s.P95),
+			formatTrend(s.Trend),
+		})
+	}
+
+	widths := make([]int, len(table[0]))
+	for _, row := range table {
+		for i, cell := range row {
+			widths[i] = max(widths[i], lipgloss.Width(cell))
+		}
+	}
+
+	if total != nil {
+		tui.Println(w, "")
+	}
+	for i, row := range table {
+		var b strings.Builder
+		for j, cell := range row {
+			b.WriteString(cell)
+			if j < len(row)-1 {
+				b.WriteString(strings.Repeat(" ", widths[j]-lipgloss.Width(cell)+2))
+			}
+		}
+
+		line := "  " + b.String()
+		if i == 0 {
+			line = tui.StyleFaint.Render(line)
+		}
+		tui.Println(w, line)
+	}
+}
+
+func formatRate(r float64) string {
+	return fmt.Sprintf("%.0f%%", r*100)
+}
+
+func formatStatsDuration(d time.Duration) string {
+	return d.Round(10 * time.Millisecond).String()
+}
+
+// formatTrend renders a relative duration change as a signed percentage, or a
+// dash when there are too few runs to tell.
+func formatTrend(t float64) string {
+	if t == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%+.0f%%", t*100)
+}
@@ -0,0 +1,48 @@
+package cmd_test
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func Test_statsCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	runAppTest(t, appTest{args: []string{"-c", "testdata/graph.yaml", "stats"}, output: []string{"No runs recorded."}})
+
+	runAppTest(t, appTest{args: []string{"--raw", "-c", "testdata/graph.yaml", "graph:pipeline1"}})
+	runAppTest(t, appTest{args: []string{"--raw", "-c", "testdata/graph.yaml", "graph:task2"}})
+
+	runAppTest(t, appTest{args: []string{"-c", "testdata/graph.yaml", "stats"}, output: []string{"all runs", "2 run(s)", "0% failed", "NAME", "P95", "graph:task1", "graph:pipeline2/graph:task1"}})
+	runAppTest(t, appTest{args: []string{"-c", "testdata/graph.yaml", "stats", "a", "b"}, errored: true})
+
+	out, err := captureStdout(t, []string{"-c", "testdata/graph.yaml", "-o", "json", "stats", "graph:task2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var resp struct {
+		SchemaVersion int    `json:"schema_version"`
+		Target        string `json:"target"`
+		Runs          *struct {
+			Runs int `json:"runs"`
+		} `json:"runs"`
+		Tasks []struct {
+			Name     string `json:"name"`
+			Runs     int    `json:"runs"`
+			Failures int    `json:"failures"`
+		} `json:"tasks"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		t.Fatalf("invalid json: %v\noutput: %s", err, out)
+	}
+	if resp.SchemaVersion != 1 || resp.Target != "graph:task2" || resp.Runs == nil || resp.Runs.Runs != 1 {
+		t.Errorf("unexpected stats document %s", out)
+	}
+	if len(resp.Tasks) != 1 || resp.Tasks[0].Name != "graph:task2" || resp.Tasks[0].Runs != 1 {
+		t.Errorf("unexpected task stats %+v", resp.Tasks)
+	}
+
+	// graph:task3 was only run as a stage: it is matched across all runs.
+	runAppTest(t, appTest{args: []string{"-c", "testdata/graph.yaml", "stats", "graph:task3"}, output: []string{"graph:task3"}})
+}
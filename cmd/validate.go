@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"strings"
 
@@ -16,40 +17,91 @@ func newValidateCommand(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
 		Use:     "validate CONFIG_FILE",
 		Short:   "validates config file",
-		Long:    "Loads the given config file and reports whether it parses and resolves cleanly, without running anything.",
+		Long:    "Loads the given config file, and then the config with each of its profiles, and reports whether they parse and resolve cleanly, without running anything.",
 		Example: "  taskctl validate tasks.yaml",
 		GroupID: groupInspect,
 		Args:    exactArgs(1, "validate requires exactly one config file path"),
 		RunE: func(_ *cobra.Command, args []string) error {
 			file := args[0]
-			loader := config.NewConfigLoader(config.NewConfig())
-			_, err := loader.Load(file)
+			loaded, err := validateConfig(file, "")
+			var profiles []profileValidation
+			if err == nil {
+				for _, name := range loaded.Profiles {
+					p := profileValidation{Name: name, Valid: true}
+					if _, p.err = validateConfig(file, name); p.err != nil {
+						p.Valid, p.Error = false, strings.TrimSpace(p.err.Error())
+					}
+					profiles = append(profiles, p)
+				}
+			}
+			if err == nil {
+				for _, p := range profiles {
+					if p.err != nil {
+						err = fmt.Errorf("profile %s: %w", p.Name, p.err)
+						break
+					}
+				}
+			}
 
 			if cfg.Output == output.FormatJSON {
-				return encodeValidateJSON(file, err)
+				return encodeValidateJSON(file, err, profiles)
 			}
 
-			if err != nil {
+			if err != nil && len(profiles) == 0 {
 				tui.Println(os.Stdout, tui.StyleError.Render("✗")+" "+file+" is invalid")
-				for line := range strings.SplitSeq(strings.TrimSpace(err.Error()), "\n") {
-					tui.Println(os.Stdout, tui.StyleFaint.Render("    "+line))
-				}
+				printValidateError(err)
 				return reportedError{err}
 			}
 
 			tui.Println(os.Stdout, tui.StyleSuccess.Render("✓")+" "+file+" is valid")
+			for _, p := range profiles {
+				if p.err != nil {
+					tui.Println(os.Stdout, tui.StyleError.Render("✗")+" profile "+p.Name+" is invalid")
+					printValidateError(p.err)
+					continue
+				}
+				tui.Println(os.Stdout, tui.StyleSuccess.Render("✓")+" profile "+p.Name+" is valid")
+			}
+			if err != nil {
+				return reportedError{err}
+			}
 			return nil
 		},
 	}
 }
 
-func encodeValidateJSON(file string, loadErr error) error {
+// profileValidation is the result of loading the config with one of its
+// profiles.
+type profileValidation struct {
+	Name  string `json:"name"`
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+
+	err error
+}
+
+// validateConfig loads file with profile overlaid, none when empty.
+func validateConfig(file, profile string) (*config.Config, error) {
+	loader := config.NewConfigLoader(config.NewConfig())
+	loader.SetProfile(profile)
+
+	return loader.Load(file)
+}
+
+func printValidateError(err error) {
+	for line := range strings.SplitSeq(strings.TrimSpace(err.Error()), "\n") {
+		tui.Println(os.Stdout, tui.StyleFaint.Render("    "+line))
+	}
+}
+
+func encodeValidateJSON(file string, loadErr error, profiles []profileValidation) error {
 	doc := struct {
-		SchemaVersion int    `json:"schema_version"`
-		Valid         bool   `json:"valid"`
-		File          string `json:"file"`
-		Error         string `json:"error,omitempty"`
-	}{SchemaVersion: 1, Valid: loadErr == nil, File: file}
+		SchemaVersion int                 `json:"schema_version"`
+		Valid         bool                `json:"valid"`
+		File          string              `json:"file"`
+		Error         string              `json:"error,omitempty"`
+		Profiles      []profileValidation `json:"profiles,omitempty"`
+	}{SchemaVersion: 1, Valid: loadErr == nil, File: file, Profiles: profiles}
 	if loadErr != nil {
 		doc.Error = strings.TrimSpace(loadErr.Error())
 	}
@@ -13,3 +13,15 @@ func Test_validateCommand(t *testing.T) {
 		runAppTest(t, v)
 	}
 }
+
+func Test_validateCommandProfiles(t *testing.T) {
+	tests := []appTest{
+		{args: []string{"validate", "testdata/profiles.yaml"}, output: []string{"is valid", "profile ci is valid", "profile release is valid"}},
+		{args: []string{"validate", "testdata/profiles-invalid.yaml"}, errored: true, output: []string{"testdata/profiles-invalid.yaml is valid", "profile ci is invalid", "task biuld is not defined"}},
+		{args: []string{"-o", "json", "validate", "testdata/profiles-invalid.yaml"}, errored: true, output: []string{`"valid":false`, `"profiles":[{"name":"ci","valid":false`}},
+	}
+
+	for _, v := range tests {
+		runAppTest(t, v)
+	}
+}
@@ -0,0 +1,162 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"mvdan.cc/sh/v3/interp"
+
+	"github.com/taskctl/taskctl/process"
+)
+
+// Annotation is a note a command attaches to a location in a file; see
+// process.Annotation.
+type Annotation = process.Annotation
+
+// Reporter receives what a job's commands report through taskctl's builtin
+// commands, which run inside the shell without an external binary:
+//
+//	taskctl-set-output KEY [VALUE]     # VALUE read from stdin when omitted
+//	taskctl-log LEVEL MESSAGE...       # LEVEL is debug, info, warn or error
+//	taskctl-annotate FILE[:LINE] MESSAGE...
+//	taskctl-progress PERCENT           # 0 to 100
+//
+// Its methods may be called concurrently, e.g. from a background job.
+type Reporter interface {
+	SetOutput(key, value string)
+	Log(level, message string)
+	Annotate(a Annotation)
+	Progress(percent int)
+}
+
+// BuiltinSets are the sets of commands a job can opt in to running inside the
+// shell in place of binaries on PATH, through Job.Builtins: "coreutils" are
+// Go implementations of common utilities like rm, mkdir, cp and cat, for
+// systems without them.
+var BuiltinSets = []string{"coreutils"}
+
+// LogLevels are the levels taskctl-log accepts.
+var LogLevels = []string{"debug", "info", "warn", "error"}
+
+// builtins are taskctl's builtin commands, by name. Each returns the error
+// reported on the command's stderr, exiting with status 2.
+var builtins = map[string]func(hc interp.HandlerContext, r Reporter, args []string) error{
+	"taskctl-set-output": setOutputBuiltin,
+	"taskctl-log":        logBuiltin,
+	"taskctl-annotate":   annotateBuiltin,
+	"taskctl-progress":   progressBuiltin,
+}
+
+// builtinsMiddleware runs taskctl's builtin commands in place of external
+// ones, reporting to the Reporter reporter returns; with none, they do
+// nothing.
+func builtinsMiddleware(reporter func() Reporter) func(interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			builtin, ok := builtins[args[0]]
+			if !ok {
+				return next(ctx, args)
+			}
+
+			hc := interp.HandlerCtx(ctx)
+			r := reporter()
+			if r == nil {
+				r = discardReporter{}
+			}
+			if err := builtin(hc, r, args[1:]); err != nil {
+				_, _ = fmt.Fprintf(hc.Stderr, "%s: %s\n", args[0], err)
+				return interp.ExitStatus(2)
+			}
+
+			return nil
+		}
+	}
+}
+
+func setOutputBuiltin(hc interp.HandlerContext, r Reporter, args []string) error {
+	var value string
+	switch len(args) {
+	case 1:
+		if hc.Stdin == nil {
+			return errors.New("no value given and no stdin to read it from")
+		}
+		b, err := io.ReadAll(hc.Stdin)
+		if err != nil {
+			return err
+		}
+		value = strings.TrimSuffix(string(b), "\n")
+	case 2:
+		value = args[1]
+	default:
+		return errors.New("usage: taskctl-set-output KEY [VALUE]")
+	}
+
+	key := args[0]
+	if key == "" || strings.ContainsAny(key, " \t\n=") {
+		return fmt.Errorf("invalid key %q", key)
+	}
+	r.SetOutput(key, value)
+
+	return nil
+}
+
+func logBuiltin(_ interp.HandlerContext, r Reporter, args []string) error {
+	if len(args) < 2 {
+		return errors.New("usage: taskctl-log LEVEL MESSAGE...")
+	}
+
+	level := strings.ToLower(args[0])
+	if !slices.Contains(LogLevels, level) {
+		return fmt.Errorf("unknown level %q, want one of %s", args[0], strings.Join(LogLevels, ", "))
+	}
+	r.Log(level, strings.Join(args[1:], " "))
+
+	return nil
+}
+
+func annotateBuiltin(_ interp.HandlerContext, r Reporter, args []string) error {
+	if len(args) < 2 {
+		return errors.New("usage: taskctl-annotate FILE[:LINE] MESSAGE...")
+	}
+
+	// The last colon, so a Windows path keeps its drive letter.
+	a := Annotation{File: args[0], Message: strings.Join(args[1:], " ")}
+	if i := strings.LastIndexByte(args[0], ':'); i >= 0 {
+		if n, err := strconv.Atoi(args[0][i+1:]); err == nil && n > 0 {
+			a.File, a.Line = args[0][:i], n
+		}
+	}
+	if a.File == "" {
+		return fmt.Errorf("missing file in %q", args[0])
+	}
+	r.Annotate(a)
+
+	return nil
+}
+
+func progressBuiltin(_ interp.HandlerContext, r Reporter, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: taskctl-progress PERCENT")
+	}
+
+	percent, err := strconv.Atoi(strings.TrimSuffix(args[0], "%"))
+	if err != nil || percent < 0 || percent > 100 {
+		return fmt.Errorf("invalid percentage %q, want 0 to 100", args[0])
+	}
+	r.Progress(percent)
+
+	return nil
+}
+
+// discardReporter ignores what a job without a Reporter reports.
+type discardReporter struct{}
+
+func (discardReporter) SetOutput(string, string) {}
+func (discardReporter) Log(string, string)       {}
+func (discardReporter) Annotate(Annotation)      {}
+func (discardReporter) Progress(int)             {}
@@ -0,0 +1,156 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type testReporter struct {
+	mu          sync.Mutex
+	outputs     map[string]string
+	logs        []string
+	annotations []Annotation
+	progress    []int
+}
+
+func (r *testReporter) SetOutput(key, value string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.outputs == nil {
+		r.outputs = make(map[string]string)
+	}
+	r.outputs[key] = value
+}
+
+func (r *testReporter) Log(level, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, level+": "+message)
+}
+
+func (r *testReporter) Annotate(a Annotation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.annotations = append(r.annotations, a)
+}
+
+func (r *testReporter) Progress(percent int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.progress = append(r.progress, percent)
+}
+
+func TestBuiltins(t *testing.T) {
+	e, err := NewDefaultExecutor(nil, io.Discard, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &testReporter{}
+	for _, command := range []string{
+		"taskctl-set-output version 1.2.3",
+		"printf 'abc\\n' | taskctl-set-output commit",
+		`taskctl-log WARN "cache" miss`,
+		"taskctl-annotate main.go:12 unused variable",
+		"taskctl-annotate 'C:\\src\\main.go:3' windows path",
+		"taskctl-annotate README.md typo",
+		"taskctl-progress 40%",
+	} {
+		job := NewJobFromCommand(command)
+		job.Reporter = r
+		if _, err := e.Execute(context.Background(), job); err != nil {
+			t.Fatalf("%s: %v", command, err)
+		}
+	}
+
+	if r.outputs["version"] != "1.2.3" || r.outputs["commit"] != "abc" {
+		t.Errorf("outputs = %v", r.outputs)
+	}
+	if !slices.Equal(r.logs, []string{"warn: cache miss"}) {
+		t.Errorf("logs = %v", r.logs)
+	}
+	want := []Annotation{
+		{File: "main.go", Line: 12, Message: "unused variable"},
+		{File: `C:\src\main.go`, Line: 3, Message: "windows path"},
+		{File: "README.md", Message: "typo"},
+	}
+	if !slices.Equal(r.annotations, want) {
+		t.Errorf("annotations = %+v", r.annotations)
+	}
+	if !slices.Equal(r.progress, []int{40}) {
+		t.Errorf("progress = %v", r.progress)
+	}
+}
+
+func TestBuiltins_Invalid(t *testing.T) {
+	for _, command := range []string{
+		"taskctl-set-output",
+		"taskctl-set-output 'a key' value",
+		"taskctl-log verbose message",
+		"taskctl-log warn",
+		"taskctl-annotate :3 message",
+		"taskctl-progress 101",
+		"taskctl-progress half",
+	} {
+		var stderr bytes.Buffer
+		e, err := NewDefaultExecutor(nil, io.Discard, &stderr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		job := NewJobFromCommand(command)
+		job.Reporter = &testReporter{}
+		_, err = e.Execute(context.Background(), job)
+		if code, ok := IsExitStatus(err); !ok || code != 2 {
+			t.Errorf("%s: expected exit status 2, got %v", command, err)
+		}
+		name, _, _ := strings.Cut(command, " ")
+		if !strings.HasPrefix(stderr.String(), name+": ") {
+			t.Errorf("%s: stderr = %q", command, stderr.String())
+		}
+	}
+}
+
+// Without a Reporter, the builtins still succeed so commands using them run
+// anywhere, e.g. in a task's before commands.
+func TestBuiltins_NoReporter(t *testing.T) {
+	e, err := NewDefaultExecutor(nil, io.Discard, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := e.Execute(context.Background(), NewJobFromCommand("taskctl-progress 10 && taskctl-log info hi")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDefaultExecutor_Coreutils(t *testing.T) {
+	var stdout bytes.Buffer
+	e, err := NewDefaultExecutor(nil, &stdout, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// With no binaries on PATH, only the builtin utilities can run.
+	job := NewJobFromCommand("mkdir -p a/b && touch a/b/c && ls a/b")
+	job.Dir = t.TempDir()
+	job.Env.Set("PATH", t.TempDir())
+	job.Builtins = []string{"coreutils"}
+	if _, err := e.Execute(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "c\n" {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+
+	job.Builtins = nil
+	_, err = e.Execute(context.Background(), job)
+	if code, ok := IsExitStatus(err); !ok || code != 127 {
+		t.Errorf("without builtins the utilities must come from PATH, got %v", err)
+	}
+}
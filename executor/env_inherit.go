@@ -0,0 +1,7 @@
+package executor
+
+import "github.com/taskctl/taskctl/process"
+
+// EnvInherit selects the host environment variables a job's environment
+// starts from; see process.EnvInherit.
+type EnvInherit = process.EnvInherit
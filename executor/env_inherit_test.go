@@ -0,0 +1,32 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/taskctl/taskctl/variables"
+)
+
+func TestDefaultExecutor_EnvInherit(t *testing.T) {
+	t.Setenv("TASKCTL_TEST_HOST", "host")
+	t.Setenv("TASKCTL_TEST_KEEP", "keep")
+
+	e, err := NewDefaultExecutor(nil, io.Discard, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job := NewJobFromCommand(`echo "[${TASKCTL_TEST_HOST:-unset}] [${TASKCTL_TEST_KEEP:-unset}] [$DECLARED]"`)
+	job.Env = variables.FromMap(map[string]string{"DECLARED": "declared"})
+	job.EnvInherit = &EnvInherit{Names: []string{"TASKCTL_TEST_KEEP"}}
+
+	out, err := e.Execute(context.Background(), job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "[unset] [keep] [declared]" {
+		t.Errorf("unexpected environment %q", got)
+	}
+}
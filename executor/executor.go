@@ -2,7 +2,6 @@
 package executor
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -10,14 +9,18 @@ import (
 	"log/slog"
 	"maps"
 	"os"
+	"slices"
 	"strings"
+	"sync/atomic"
 
 	"mvdan.cc/sh/v3/expand"
 
 	"mvdan.cc/sh/v3/interp"
 	"mvdan.cc/sh/v3/syntax"
 
+	"github.com/taskctl/taskctl/internal/coreutils"
 	"github.com/taskctl/taskctl/internal/envutil"
+	"github.com/taskctl/taskctl/internal/iox"
 	"github.com/taskctl/taskctl/internal/tmpl"
 )
 
@@ -32,16 +35,34 @@ type DefaultExecutor struct {
 	// DryRun makes Execute render and parse the command to validate it, then
 	// return without executing it.
 	DryRun bool
-
-	dir     string
-	env     []string
-	stdin   io.Reader
-	stdout  io.Writer
-	stderr  io.Writer
-	buf     bytes.Buffer
-	interp  *interp.Runner
-	lastEnv map[string]string
-	lastDir string
+	// DiscardOutput makes Execute return no output, so a command's output
+	// isn't held when nothing reads it.
+	DiscardOutput bool
+	// OutputLimit caps how much of the output Execute returns is kept in
+	// memory while the command runs, the rest spilling to a temporary file
+	// (see iox.SpillBuffer); 0 uses iox.DefaultSpillLimit.
+	OutputLimit int
+
+	dir         string
+	env         []string
+	stdin       io.Reader
+	stdout      io.Writer
+	stderr      io.Writer
+	buf         capture
+	interp      *interp.Runner
+	lastEnv     map[string]string
+	lastDir     string
+	lastLimits  *Limits
+	lastInherit *EnvInherit
+	// lastBuiltins are the builtin sets the interpreter was built with.
+	lastBuiltins []string
+	lastTTY      bool
+	// reporter is the Reporter of the job being executed.
+	reporter Reporter
+	// out is the stdout given to NewDefaultExecutor, and tty the terminal
+	// of the TTY job being executed.
+	out io.Writer
+	tty atomic.Pointer[os.File]
 }
 
 // NewDefaultExecutor creates new default executor
@@ -65,6 +86,7 @@ func NewDefaultExecutor(stdin io.Reader, stdout, stderr io.Writer) (*DefaultExec
 	}
 
 	e.stdin = stdin
+	e.out = stdout
 	e.stdout = io.MultiWriter(&e.buf, stdout)
 	e.stderr = io.MultiWriter(&e.buf, stderr)
 
@@ -74,7 +96,8 @@ func NewDefaultExecutor(stdin io.Reader, stdout, stderr io.Writer) (*DefaultExec
 // Execute executes given job with provided context
 // Returns job output
 func (e *DefaultExecutor) Execute(ctx context.Context, job *Job) ([]byte, error) {
-	command, err := tmpl.RenderString(job.Command, job.Vars.Map())
+	env := job.Environ(e.env)
+	command, err := tmpl.RenderStringEnv(job.Command, job.Vars.Map(), env)
 	if err != nil {
 		return nil, err
 	}
@@ -101,20 +124,36 @@ func (e *DefaultExecutor) Execute(ctx context.Context, job *Job) ([]byte, error)
 	// shell state (functions, variables, cwd) carries across a task's commands;
 	// rebuild it when either changes (a new variation) so each variation runs
 	// with its own environment/directory and a clean state.
-	if e.interp == nil || job.Dir != e.lastDir || !maps.Equal(jobEnv, e.lastEnv) {
-		env := envutil.OverlayEnviron(e.env, jobEnv)
-		e.interp, err = interp.New(
+	if e.interp == nil || job.Dir != e.lastDir || !maps.Equal(jobEnv, e.lastEnv) || job.Limits != e.lastLimits || job.EnvInherit != e.lastInherit || !slices.Equal(job.Builtins, e.lastBuiltins) || job.TTY != e.lastTTY {
+		middlewares := []func(interp.ExecHandlerFunc) interp.ExecHandlerFunc{
+			builtinsMiddleware(func() Reporter { return e.reporter }),
+		}
+		if slices.Contains(job.Builtins, "coreutils") {
+			middlewares = append(middlewares, coreutils.ExecHandler)
+		}
+		if !job.Limits.IsZero() || job.TTY {
+			middlewares = append(middlewares, processMiddleware(job.Limits, e.tty.Load))
+		}
+		opts := []interp.RunnerOption{
 			interp.StdIO(e.stdin, e.stdout, e.stderr),
 			interp.Dir(job.Dir),
 			interp.Env(expand.ListEnviron(env...)),
-		)
+			interp.ExecHandlers(middlewares...),
+		}
+
+		e.interp, err = interp.New(opts...)
 		if err != nil {
 			return nil, err
 		}
 		e.lastEnv = jobEnv
 		e.lastDir = job.Dir
+		e.lastLimits = job.Limits
+		e.lastInherit = job.EnvInherit
+		e.lastBuiltins = job.Builtins
+		e.lastTTY = job.TTY
 	}
 
+	parent := ctx
 	var cancelFn context.CancelFunc
 	if job.Timeout != nil {
 		ctx, cancelFn = context.WithTimeout(ctx, *job.Timeout)
@@ -125,13 +164,81 @@ func (e *DefaultExecutor) Execute(ctx context.Context, job *Job) ([]byte, error)
 		}
 	}()
 
-	offset := e.buf.Len()
+	e.reporter = job.Reporter
+	// The buffer only holds the output of this command; the previous one
+	// belongs to the caller.
+	e.buf.reset(e.OutputLimit, e.DiscardOutput)
+	defer e.buf.reset(e.OutputLimit, e.DiscardOutput)
+
+	var term *terminal
+	if job.TTY {
+		term, err = openTerminal(io.MultiWriter(iox.StripANSI(&e.buf), e.out))
+		switch {
+		case errors.Is(err, errTTYUnsupported):
+			ttyWarning.Do(func() { slog.Warn(err.Error() + "; commands run without one") })
+		case err != nil:
+			return nil, err
+		default:
+			// The shell writes to the terminal as well, so the output of its
+			// builtins stays in order with the commands'.
+			if err := interp.StdIO(e.stdin, term.tty, term.tty)(e.interp); err != nil {
+				term.close()
+				return nil, err
+			}
+			e.tty.Store(term.tty)
+		}
+	}
+
 	err = e.interp.Run(ctx, cmd)
+	if term != nil {
+		e.tty.Store(nil)
+		term.close()
+	}
 	if err != nil {
-		return e.buf.Bytes()[offset:], err
+		// A command interrupted by a deadline may exit with any status; the
+		// expired context is what tells a timeout apart.
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			timeoutErr := &TimeoutError{Err: err}
+			if parent.Err() == nil {
+				timeoutErr.Timeout = *job.Timeout
+			}
+			err = timeoutErr
+		}
+		return e.buf.Bytes(), err
+	}
+
+	return e.buf.Bytes(), nil
+}
+
+// capture collects the output of the command being executed, unless discard
+// is set.
+type capture struct {
+	buf     iox.SpillBuffer
+	discard bool
+}
+
+// reset empties the capture, removing the file its output spilled to.
+func (c *capture) reset(limit int, discard bool) {
+	c.buf.Reset()
+	c.buf.Limit = limit
+	c.discard = discard
+}
+
+func (c *capture) Write(p []byte) (int, error) {
+	if c.discard {
+		return len(p), nil
+	}
+
+	return c.buf.Write(p)
+}
+
+// Bytes returns the output collected, read back from its file if it spilled.
+func (c *capture) Bytes() []byte {
+	if c.buf.Len() == 0 {
+		return nil
 	}
 
-	return e.buf.Bytes()[offset:], nil
+	return []byte(c.buf.String())
 }
 
 // IsExitStatus checks if given `err` is an exit status
@@ -3,6 +3,7 @@ package executor
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io"
 	"strings"
 	"testing"
@@ -54,6 +55,55 @@ func TestDefaultExecutor_Execute(t *testing.T) {
 	}
 }
 
+// TestDefaultExecutor_DiscardOutput verifies that Execute returns the output
+// of its command only, and none of it with DiscardOutput, while the writers
+// given to the executor still get all of it.
+func TestDefaultExecutor_DiscardOutput(t *testing.T) {
+	var stdout bytes.Buffer
+	e, err := NewDefaultExecutor(nil, &stdout, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := e.Execute(context.Background(), NewJobFromCommand("echo first"))
+	if err != nil || string(out) != "first\n" {
+		t.Fatalf("unexpected output %q, %v", out, err)
+	}
+
+	second, err := e.Execute(context.Background(), NewJobFromCommand("echo second"))
+	if err != nil || string(second) != "second\n" {
+		t.Fatalf("the output of a command must not include the previous one's, got %q, %v", second, err)
+	}
+	if string(out) != "first\n" {
+		t.Errorf("the next command overwrote the output returned, got %q", out)
+	}
+
+	e.DiscardOutput = true
+	if out, err := e.Execute(context.Background(), NewJobFromCommand("echo third")); err != nil || len(out) != 0 {
+		t.Errorf("unexpected output %q, %v with DiscardOutput", out, err)
+	}
+	if stdout.String() != "first\nsecond\nthird\n" {
+		t.Errorf("unexpected stdout %q", stdout.String())
+	}
+}
+
+// TestDefaultExecutor_OutputLimit verifies that output past OutputLimit,
+// spilled to a file while the command runs, is still returned whole.
+func TestDefaultExecutor_OutputLimit(t *testing.T) {
+	e, err := NewDefaultExecutor(nil, io.Discard, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.OutputLimit = 4
+
+	for _, want := range []string{"spilled output\n", "again\n"} {
+		out, err := e.Execute(context.Background(), NewJobFromCommand("printf '"+strings.TrimSuffix(want, "\n")+"\\n'"))
+		if err != nil || string(out) != want {
+			t.Errorf("got %q, %v, want %q", out, err, want)
+		}
+	}
+}
+
 // TestDefaultExecutor_DryRun verifies that a dry run validates the command
 // (template render + shell parse) but never executes it.
 func TestDefaultExecutor_DryRun(t *testing.T) {
@@ -183,3 +233,26 @@ func TestDefaultExecutor_Execute_PerJobDir(t *testing.T) {
 		}
 	}
 }
+
+func TestDefaultExecutor_Execute_Timeout(t *testing.T) {
+	e, err := NewDefaultExecutor(nil, io.Discard, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job := NewJobFromCommand("sleep 5")
+	to := 50 * time.Millisecond
+	job.Timeout = &to
+
+	start := time.Now()
+	_, err = e.Execute(context.Background(), job)
+	if !errors.Is(err, ErrTimedOut) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("the command was not stopped, it ran for %s", elapsed)
+	}
+	if got, want := err.Error(), "command timed out after 50ms"; got != want {
+		t.Errorf("error = %q, want %q", got, want)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"io"
 	"time"
 
+	"github.com/taskctl/taskctl/internal/envutil"
 	"github.com/taskctl/taskctl/variables"
 )
 
@@ -14,6 +15,22 @@ type Job struct {
 	Env     variables.Container
 	Vars    variables.Container
 	Timeout *time.Duration
+	// Limits restricts the resources of the processes the job starts.
+	Limits *Limits
+	// EnvInherit selects the host environment variables the job's environment
+	// starts from; nil inherits all of them.
+	EnvInherit *EnvInherit
+	// Builtins lists the BuiltinSets the job runs in place of binaries on
+	// PATH.
+	Builtins []string
+	// TTY runs the job's commands with their stdout and stderr attached to a
+	// pseudo-terminal, on Linux, so tools keep the colors and progress they
+	// show on a terminal. The terminal's output goes to Stdout, merging
+	// stderr into it; the output Execute returns has no escape sequences.
+	TTY bool
+	// Reporter receives what the job's commands report through taskctl's
+	// builtin commands; nil ignores it.
+	Reporter Reporter
 
 	Stdout, Stderr io.Writer
 	Stdin          io.Reader
@@ -21,6 +38,17 @@ type Job struct {
 	Next *Job
 }
 
+// Environ returns the environment the job's commands run in: the entries of
+// host that EnvInherit inherits, overlaid with Env.
+func (j *Job) Environ(host []string) []string {
+	env := map[string]string{}
+	if j.Env != nil {
+		env = envutil.ConvertToMapOfStrings(j.Env.Map())
+	}
+
+	return envutil.OverlayEnviron(j.EnvInherit.Filter(host), env)
+}
+
 // NewJobFromCommand creates new Job instance from given command
 func NewJobFromCommand(command string) *Job {
 	return &Job{
@@ -0,0 +1,164 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"syscall"
+	"time"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+
+	"github.com/taskctl/taskctl/process"
+)
+
+// killTimeout is how long a process gets to exit after the interrupt sent on
+// cancellation before it is killed, as in interp.DefaultExecHandler.
+const killTimeout = 2 * time.Second
+
+// Limits restricts the resources of every process a job starts; see
+// process.Limits.
+type Limits = process.Limits
+
+// MoveToCgroupLeaf lets the executor move the running program into a
+// "taskctl" cgroup beneath its own when that cgroup has processes of its own,
+// which keeps it from enabling the memory and cpu controllers that Memory and
+// CPUs limits need. This outlives the run: the "taskctl" cgroup stays, and
+// with the controllers enabled its parent can no longer hold processes of its
+// own, while the ones it has stay where they are. Without it, those limits
+// are not enforced in such a cgroup. Set it before running any job.
+var MoveToCgroupLeaf bool
+
+// LimitError occurs when a process was terminated for exceeding one of its
+// Limits. It wraps the process's exit status, so IsExitStatus still reports it.
+type LimitError struct {
+	// Limit names the exceeded limit, e.g. "memory" or "cpu time".
+	Limit string
+	// Value is the limit's configured value.
+	Value  string
+	Status interp.ExitStatus
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s limit of %s exceeded (exit status %d)", e.Limit, e.Value, uint8(e.Status))
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Status
+}
+
+// processMiddleware replaces the interpreter's exec handler with one that
+// starts every external command under l and, while tty returns a terminal,
+// with that terminal as its controlling terminal when it is one of the
+// command's standard streams.
+func processMiddleware(l *Limits, tty func() *os.File) func(interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			return execProcess(ctx, l, tty(), args)
+		}
+	}
+}
+
+// execProcess is interp.DefaultExecHandler with the process started under l
+// and attached to tty, translating a termination caused by a limit into a
+// LimitError. Either may be nil.
+func execProcess(ctx context.Context, l *Limits, tty *os.File, args []string) error {
+	hc := interp.HandlerCtx(ctx)
+	path, err := interp.LookPathDir(hc.Dir, hc.Env, args[0])
+	if err != nil {
+		_, _ = fmt.Fprintln(hc.Stderr, err)
+		return interp.ExitStatus(127)
+	}
+
+	cmd := &exec.Cmd{
+		Path:   path,
+		Args:   args,
+		Env:    execEnv(hc.Env),
+		Dir:    hc.Dir,
+		Stdin:  hc.Stdin,
+		Stdout: hc.Stdout,
+		Stderr: hc.Stderr,
+	}
+
+	if tty != nil {
+		setControllingTerminal(cmd, tty)
+	}
+
+	var p *limitedProcess
+	if l.IsZero() {
+		err = cmd.Start()
+	} else {
+		p, err = startLimited(cmd, l)
+	}
+	if err == nil {
+		stop := context.AfterFunc(ctx, func() {
+			if runtime.GOOS == "windows" {
+				_ = cmd.Process.Signal(os.Kill)
+				return
+			}
+			_ = cmd.Process.Signal(os.Interrupt)
+			time.Sleep(killTimeout)
+			_ = cmd.Process.Signal(os.Kill)
+		})
+		err = cmd.Wait()
+		stop()
+
+		if p != nil {
+			if lerr := p.finish(cmd.ProcessState); lerr != nil && ctx.Err() == nil {
+				_, _ = fmt.Fprintf(hc.Stderr, "taskctl: %s: %s\n", args[0], lerr)
+				return lerr
+			}
+		}
+	}
+
+	var exitErr *exec.ExitError
+	var execErr *exec.Error
+	switch {
+	case errors.As(err, &exitErr):
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return interp.ExitStatus(128 + int(status.Signal()))
+		}
+		return interp.ExitStatus(exitErr.ExitCode())
+	case errors.As(err, &execErr):
+		_, _ = fmt.Fprintf(hc.Stderr, "%v\n", err)
+		return interp.ExitStatus(127)
+	default:
+		return err
+	}
+}
+
+// execEnv lists the exported variables of env, as the interpreter passes them
+// to the commands it starts.
+func execEnv(env expand.Environ) []string {
+	list := make([]string, 0, 64)
+	for name, vr := range env.Each {
+		if vr.Exported && vr.Kind == expand.String && vr.IsSet() {
+			list = append(list, name+"="+vr.String())
+		}
+	}
+
+	return list
+}
+
+// formatBytes renders a byte count in the largest binary unit that divides it,
+// e.g. "4GiB".
+func formatBytes(n uint64) string {
+	for _, u := range []struct {
+		suffix string
+		size   uint64
+	}{{"TiB", 1 << 40}, {"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10}} {
+		if n >= u.size && n%u.size == 0 {
+			return strconv.FormatUint(n/u.size, 10) + u.suffix
+		}
+	}
+
+	return strconv.FormatUint(n, 10) + "B"
+}
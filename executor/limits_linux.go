@@ -0,0 +1,398 @@
+package executor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// ioPriorityClasses maps the I/O scheduling classes to their ioprio_set(2)
+// values.
+var ioPriorityClasses = map[string]int{
+	"realtime":    1,
+	"best-effort": 2,
+	"idle":        3,
+}
+
+// cgroups is the process-wide cgroup v2 setup, done once when the first
+// process with a cgroup limit starts.
+var cgroups struct {
+	once        sync.Once
+	base        string
+	controllers []string
+	err         error
+	seq         atomic.Uint64
+	warning     sync.Once
+}
+
+// limitedProcess is what startLimited set up for one process: its limits and,
+// for cgroup limits, the cgroup created for it.
+type limitedProcess struct {
+	l        *Limits
+	cgroup   string
+	cgroupFD *os.File
+}
+
+// startLimited starts cmd with l applied: the process is started directly in
+// its own cgroup when l has cgroup limits, and through taskctl's limits shim
+// when l has rlimits or priorities, so they are in place before the command
+// runs and every process it starts inherits them.
+func startLimited(cmd *exec.Cmd, l *Limits) (*limitedProcess, error) {
+	p := &limitedProcess{l: l}
+	if l.Memory != 0 || l.CPUs != 0 {
+		if err := p.createCgroup(); err != nil {
+			cgroups.warning.Do(func() {
+				slog.Warn(fmt.Sprintf("memory and cpus limits are not enforced: %s", err))
+			})
+		} else {
+			if cmd.SysProcAttr == nil {
+				cmd.SysProcAttr = &syscall.SysProcAttr{}
+			}
+			cmd.SysProcAttr.UseCgroupFD, cmd.SysProcAttr.CgroupFD = true, int(p.cgroupFD.Fd())
+		}
+	}
+
+	if l.AddressSpace != 0 || l.CPUTime != 0 || l.OpenFiles != 0 || l.Nice != nil || l.IOClass != "" {
+		spec, err := json.Marshal(shimSpec{Path: cmd.Path, Limits: *l})
+		if err != nil {
+			p.cleanup()
+			return nil, err
+		}
+		cmd.Args = slices.Concat([]string{cmd.Args[0], limitsShimArg, string(spec)}, cmd.Args[1:])
+		cmd.Path = "/proc/self/exe"
+	}
+
+	if err := cmd.Start(); err != nil {
+		p.cleanup()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// limitsShimArg, followed by a shimSpec and the command's arguments, starts
+// the program as the limits shim of a command: a copy of the program started
+// in the command's place, which applies the limits to itself and then
+// executes the command.
+const limitsShimArg = "__taskctl-limits-shim"
+
+// shimExitStatus is the exit status of a shim that could not apply the limits
+// or execute the command, as a shell's for a command that can't be executed.
+const shimExitStatus = 126
+
+type shimSpec struct {
+	Path   string
+	Limits Limits
+}
+
+// RunLimitsShim runs the limits shim of a command when the program was started
+// as one, and then never returns; otherwise it does nothing. A job with
+// rlimits or priorities in its Limits starts its commands through the shim,
+// by re-executing the running program, so a program running such jobs must
+// call RunLimitsShim first thing in main.
+func RunLimitsShim() {
+	if len(os.Args) < 3 || os.Args[1] != limitsShimArg {
+		return
+	}
+
+	err := execShim(os.Args[2], append([]string{os.Args[0]}, os.Args[3:]...))
+	_, _ = fmt.Fprintf(os.Stderr, "taskctl: %s\n", err)
+	os.Exit(shimExitStatus)
+}
+
+// execShim applies the limits of spec to the current process and replaces it
+// with the command, run with args, returning only on failure. The priorities
+// are per thread on Linux, so the thread setting them is the one executing
+// the command.
+func execShim(spec string, args []string) error {
+	var s shimSpec
+	if err := json.Unmarshal([]byte(spec), &s); err != nil {
+		return fmt.Errorf("limits shim: %w", err)
+	}
+
+	runtime.LockOSThread()
+	p := &limitedProcess{l: &s.Limits}
+	if err := p.apply(0); err != nil {
+		return fmt.Errorf("%s: applying limits: %w", s.Path, err)
+	}
+
+	if err := unix.Exec(s.Path, args, os.Environ()); err != nil {
+		return fmt.Errorf("%s: %w", s.Path, err)
+	}
+
+	return nil
+}
+
+// apply sets the rlimits and priorities of p to the process pid, 0 for the
+// calling thread.
+func (p *limitedProcess) apply(pid int) error {
+	l := p.l
+	if l.CPUTime != 0 {
+		// The soft limit signals SIGXCPU; the hard limit a second later kills
+		// a process that ignores it.
+		secs := uint64(math.Ceil(l.CPUTime.Seconds()))
+		if err := setRlimit(pid, unix.RLIMIT_CPU, secs, secs+1); err != nil {
+			return fmt.Errorf("cpu time: %w", err)
+		}
+	}
+
+	if l.OpenFiles != 0 {
+		if err := setRlimit(pid, unix.RLIMIT_NOFILE, l.OpenFiles, l.OpenFiles); err != nil {
+			return fmt.Errorf("open files: %w", err)
+		}
+	}
+
+	if l.Nice != nil {
+		if err := unix.Setpriority(unix.PRIO_PROCESS, pid, *l.Nice); err != nil {
+			return fmt.Errorf("nice %d: %w", *l.Nice, err)
+		}
+	}
+
+	if l.IOClass != "" {
+		class, ok := ioPriorityClasses[l.IOClass]
+		if !ok {
+			return fmt.Errorf("unknown I/O scheduling class %q", l.IOClass)
+		}
+
+		const ioprioWhoProcess = 1
+		prio := class<<13 | l.IOLevel
+		if _, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(pid), uintptr(prio)); errno != 0 {
+			return fmt.Errorf("ionice %s: %w", l.IOClass, errno)
+		}
+	}
+
+	// Last, so the shim maps no more memory under it.
+	if l.AddressSpace != 0 {
+		if err := setRlimit(pid, unix.RLIMIT_AS, l.AddressSpace, l.AddressSpace); err != nil {
+			return fmt.Errorf("address space: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// finish releases the process's cgroup and reports whether the process was
+// terminated for exceeding a limit.
+func (p *limitedProcess) finish(state *os.ProcessState) *LimitError {
+	defer p.cleanup()
+
+	if state == nil || state.Success() {
+		return nil
+	}
+
+	ws, ok := state.Sys().(syscall.WaitStatus)
+	if !ok {
+		return nil
+	}
+
+	status := ws.ExitStatus()
+	if ws.Signaled() {
+		status = 128 + int(ws.Signal())
+	}
+
+	// A process of the tree being OOM-killed fails the command whether or not
+	// it was the one started, so the cgroup is checked first.
+	if p.cgroup != "" && p.l.Memory != 0 && p.oomKilled() {
+		return &LimitError{Limit: "memory", Value: formatBytes(p.l.Memory), Status: interp.ExitStatus(status)}
+	}
+
+	if p.l.CPUTime != 0 && ws.Signaled() {
+		sig := ws.Signal()
+		if sig == syscall.SIGXCPU || (sig == syscall.SIGKILL && state.UserTime()+state.SystemTime() >= p.l.CPUTime) {
+			return &LimitError{Limit: "cpu time", Value: p.l.CPUTime.String(), Status: interp.ExitStatus(status)}
+		}
+	}
+
+	return nil
+}
+
+func (p *limitedProcess) oomKilled() bool {
+	data, err := os.ReadFile(filepath.Join(p.cgroup, "memory.events"))
+	if err != nil {
+		return false
+	}
+
+	for line := range strings.SplitSeq(string(data), "\n") {
+		if v, ok := strings.CutPrefix(line, "oom_kill "); ok {
+			n, _ := strconv.Atoi(v)
+			return n > 0
+		}
+	}
+
+	return false
+}
+
+// createCgroup creates the process's cgroup under the delegated one and sets
+// its limits.
+func (p *limitedProcess) createCgroup() (err error) {
+	cgroups.once.Do(setupCgroups)
+	if cgroups.err != nil {
+		return cgroups.err
+	}
+
+	for _, c := range []struct {
+		name string
+		set  bool
+	}{{"memory", p.l.Memory != 0}, {"cpu", p.l.CPUs != 0}} {
+		if c.set && !slices.Contains(cgroups.controllers, c.name) {
+			return fmt.Errorf("the %s controller is not delegated to %s", c.name, cgroups.base)
+		}
+	}
+
+	dir := filepath.Join(cgroups.base, fmt.Sprintf("taskctl-%d-%d", os.Getpid(), cgroups.seq.Add(1)))
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(dir)
+		}
+	}()
+
+	if p.l.Memory != 0 {
+		if err := os.WriteFile(filepath.Join(dir, "memory.max"), []byte(strconv.FormatUint(p.l.Memory, 10)), 0o644); err != nil {
+			return err
+		}
+		// Without swap disabled, the limit would only push the tree into swap.
+		// The file is missing when the kernel has no swap accounting.
+		if err := os.WriteFile(filepath.Join(dir, "memory.swap.max"), []byte("0"), 0o644); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	if p.l.CPUs != 0 {
+		const period = 100000
+		quota := fmt.Sprintf("%d %d", int(p.l.CPUs*period), period)
+		if err := os.WriteFile(filepath.Join(dir, "cpu.max"), []byte(quota), 0o644); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	p.cgroup, p.cgroupFD = dir, f
+	return nil
+}
+
+func (p *limitedProcess) cleanup() {
+	if p.cgroupFD == nil {
+		return
+	}
+
+	_ = p.cgroupFD.Close()
+	// Fails while background processes of the tree are still running.
+	if err := os.Remove(p.cgroup); err != nil {
+		slog.Debug(fmt.Sprintf("removing cgroup: %s", err))
+	}
+	p.cgroupFD = nil
+}
+
+// setupCgroups finds the cgroup v2 taskctl runs in and enables the memory and
+// cpu controllers for the cgroups it creates beneath it. A cgroup can only
+// enable controllers for its children while it has no processes of its own,
+// so when it has, taskctl moves itself into a "taskctl" leaf first if
+// MoveToCgroupLeaf allows it, and says so.
+func setupCgroups() {
+	cgroups.base, cgroups.controllers, cgroups.err = delegatedCgroup()
+	if cgroups.err != nil {
+		return
+	}
+
+	var enable []string
+	for _, c := range cgroups.controllers {
+		enable = append(enable, "+"+c)
+	}
+
+	subtree := filepath.Join(cgroups.base, "cgroup.subtree_control")
+	if err := os.WriteFile(subtree, []byte(strings.Join(enable, " ")), 0o644); err == nil {
+		return
+	} else if !MoveToCgroupLeaf {
+		cgroups.err = fmt.Errorf("enabling the controllers of %s: %w (see --cgroup-leaf)", cgroups.base, err)
+		return
+	}
+
+	leaf := filepath.Join(cgroups.base, "taskctl")
+	if err := os.Mkdir(leaf, 0o755); err != nil && !errors.Is(err, fs.ErrExist) {
+		cgroups.err = err
+		return
+	}
+	if err := os.WriteFile(filepath.Join(leaf, "cgroup.procs"), []byte(strconv.Itoa(os.Getpid())), 0o644); err != nil {
+		cgroups.err = err
+		return
+	}
+	slog.Info(fmt.Sprintf("moved taskctl into %s to enable the memory and cpu controllers of %s", leaf, cgroups.base))
+
+	cgroups.err = os.WriteFile(subtree, []byte(strings.Join(enable, " ")), 0o644)
+}
+
+// delegatedCgroup returns the directory of taskctl's cgroup v2 and which of
+// the memory and cpu controllers are available in it.
+func delegatedCgroup() (string, []string, error) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", nil, err
+	}
+
+	var rel string
+	for line := range strings.SplitSeq(strings.TrimSpace(string(data)), "\n") {
+		if p, ok := strings.CutPrefix(line, "0::"); ok {
+			rel = p
+		}
+	}
+	if rel == "" {
+		return "", nil, errors.New("not running in a cgroup v2 hierarchy")
+	}
+
+	base := filepath.Join("/sys/fs/cgroup", rel)
+	data, err = os.ReadFile(filepath.Join(base, "cgroup.controllers"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil, errors.New("no cgroup v2 hierarchy is mounted at /sys/fs/cgroup")
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	var controllers []string
+	for c := range strings.FieldsSeq(string(data)) {
+		if c == "memory" || c == "cpu" {
+			controllers = append(controllers, c)
+		}
+	}
+
+	if err := unix.Access(base, unix.W_OK); err != nil {
+		return "", nil, fmt.Errorf("%s is not delegated to this user", base)
+	}
+
+	return base, controllers, nil
+}
+
+// setRlimit sets a resource limit of the process, capped at its current hard
+// limit, which an unprivileged process cannot raise.
+func setRlimit(pid, resource int, soft, hard uint64) error {
+	var old unix.Rlimit
+	if err := unix.Prlimit(pid, resource, nil, &old); err != nil {
+		return err
+	}
+
+	hard = min(hard, old.Max)
+	return unix.Prlimit(pid, resource, &unix.Rlimit{Cur: min(soft, hard), Max: hard}, nil)
+}
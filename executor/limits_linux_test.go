@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMain runs the limits shim the tests start, like taskctl's main.
+func TestMain(m *testing.M) {
+	RunLimitsShim()
+	os.Exit(m.Run())
+}
+
+func TestDefaultExecutor_Limits(t *testing.T) {
+	var out bytes.Buffer
+	e, err := NewDefaultExecutor(nil, &out, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The limits are in place before the command runs, so the process it
+	// starts right away inherits them.
+	job := NewJobFromCommand(`sh -c 'sh -c "ulimit -n; nice"'`)
+	nice := 5
+	job.Limits = &Limits{OpenFiles: 64, Nice: &nice}
+	if _, err := e.Execute(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Fields(out.String()); len(got) != 2 || got[0] != "64" || got[1] != "5" {
+		t.Errorf("open files limit and niceness = %q, want 64 and 5", got)
+	}
+}
+
+func TestDefaultExecutor_LimitsShimError(t *testing.T) {
+	var stderr bytes.Buffer
+	e, err := NewDefaultExecutor(nil, io.Discard, &stderr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job := NewJobFromCommand("sh -c true")
+	job.Limits = &Limits{IOClass: "fast"}
+	_, err = e.Execute(context.Background(), job)
+	if status, ok := IsExitStatus(err); !ok || status != shimExitStatus {
+		t.Errorf("expected exit status %d, got %v", shimExitStatus, err)
+	}
+	if !strings.Contains(stderr.String(), `unknown I/O scheduling class "fast"`) {
+		t.Errorf("shim error not reported on stderr: %q", stderr.String())
+	}
+}
+
+func TestDefaultExecutor_LimitsExceeded(t *testing.T) {
+	var stderr bytes.Buffer
+	e, err := NewDefaultExecutor(nil, io.Discard, &stderr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job := NewJobFromCommand("sh -c 'while :; do :; done'")
+	job.Limits = &Limits{CPUTime: time.Second}
+	_, err = e.Execute(context.Background(), job)
+
+	var lerr *LimitError
+	if !errors.As(err, &lerr) || lerr.Limit != "cpu time" {
+		t.Fatalf("expected a cpu time LimitError, got %v", err)
+	}
+	if _, ok := IsExitStatus(err); !ok {
+		t.Error("a LimitError must still report the exit status")
+	}
+	if !strings.Contains(stderr.String(), "cpu time limit of 1s exceeded") {
+		t.Errorf("limit not reported on stderr: %q", stderr.String())
+	}
+}
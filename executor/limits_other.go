@@ -0,0 +1,35 @@
+//go:build !linux
+
+package executor
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+)
+
+var limitsWarning sync.Once
+
+// limitedProcess is empty: limits are only enforced on Linux.
+type limitedProcess struct{}
+
+// startLimited starts cmd without limits, warning once that they are not
+// enforced on this platform.
+func startLimited(cmd *exec.Cmd, _ *Limits) (*limitedProcess, error) {
+	limitsWarning.Do(func() {
+		slog.Warn(fmt.Sprintf("resource limits are not supported on %s and are not enforced", runtime.GOOS))
+	})
+
+	return &limitedProcess{}, cmd.Start()
+}
+
+// RunLimitsShim does nothing: limits are only enforced on Linux, where it
+// runs the limits shim of a command.
+func RunLimitsShim() {}
+
+func (*limitedProcess) finish(*os.ProcessState) *LimitError {
+	return nil
+}
@@ -0,0 +1,16 @@
+package executor
+
+import "testing"
+
+func TestFormatBytes(t *testing.T) {
+	for in, want := range map[uint64]string{
+		4 << 30:   "4GiB",
+		512 << 20: "512MiB",
+		1536:      "1536B",
+		1 << 40:   "1TiB",
+	} {
+		if got := formatBytes(in); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTimedOut is matched, with errors.Is, by the error of a job stopped
+// because its Timeout or the deadline of the context it ran in ran out.
+var ErrTimedOut = errors.New("timed out")
+
+// TimeoutError is returned by Execute when a job's command was stopped by a
+// timeout. Err is what the interrupted command returned.
+type TimeoutError struct {
+	// Timeout is the job's own Timeout that ran out; zero when it was the
+	// deadline of the context the job ran in.
+	Timeout time.Duration
+	Err     error
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Timeout > 0 {
+		return fmt.Sprintf("command timed out after %s", e.Timeout)
+	}
+
+	return ErrTimedOut.Error()
+}
+
+// Unwrap makes e match ErrTimedOut as well as the command's own error, so
+// IsExitStatus still finds its exit status.
+func (e *TimeoutError) Unwrap() []error {
+	return []error{ErrTimedOut, e.Err}
+}
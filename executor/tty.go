@@ -0,0 +1,48 @@
+package executor
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// ttyDrainTimeout bounds how long a command's terminal output is still read
+// after the command exits, while a background process it started holds the
+// terminal open.
+const ttyDrainTimeout = time.Second
+
+// Default size of a TTY job's terminal when taskctl does not run in one.
+const (
+	defaultTTYColumns = 80
+	defaultTTYRows    = 24
+)
+
+// errTTYUnsupported is returned by openTerminal on platforms without
+// pseudo-terminal support.
+var errTTYUnsupported = errors.New("pseudo-terminals are not supported on this platform")
+
+// ttyWarning reports errTTYUnsupported once.
+var ttyWarning sync.Once
+
+// terminal is the pseudo-terminal a command of a TTY job runs attached to:
+// what the command writes to tty is copied from the other end until close.
+type terminal struct {
+	tty    *os.File
+	pty    *os.File
+	copied chan struct{}
+	winch  chan os.Signal
+}
+
+// hostTerminalSize returns the size of the terminal taskctl runs in, if any.
+func hostTerminalSize() (columns, rows int, ok bool) {
+	for _, f := range []*os.File{os.Stdout, os.Stderr, os.Stdin} {
+		if columns, rows, err := term.GetSize(int(f.Fd())); err == nil {
+			return columns, rows, true
+		}
+	}
+
+	return 0, 0, false
+}
@@ -0,0 +1,138 @@
+package executor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// openTerminal opens a pseudo-terminal sized like the terminal taskctl runs
+// in, following its resizes, and copies what is written to it to w.
+func openTerminal(w io.Writer) (*terminal, error) {
+	pty, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("opening a pseudo-terminal: %w", err)
+	}
+
+	// The pty's own file stays non-blocking, so closing it stops the copy.
+	var n uint32
+	err = control(pty, func(fd int) error {
+		if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+			return err
+		}
+		n, err = unix.IoctlGetUint32(fd, unix.TIOCGPTN)
+		return err
+	})
+	if err != nil {
+		_ = pty.Close()
+		return nil, fmt.Errorf("opening a pseudo-terminal: %w", err)
+	}
+
+	tty, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		_ = pty.Close()
+		return nil, fmt.Errorf("opening a pseudo-terminal: %w", err)
+	}
+
+	// Keep "\n" line endings rather than have the terminal turn them into
+	// "\r\n".
+	termios, err := unix.IoctlGetTermios(int(tty.Fd()), unix.TCGETS)
+	if err == nil {
+		termios.Oflag &^= unix.ONLCR
+		err = unix.IoctlSetTermios(int(tty.Fd()), unix.TCSETS, termios)
+	}
+	if err != nil {
+		_ = tty.Close()
+		_ = pty.Close()
+		return nil, fmt.Errorf("configuring a pseudo-terminal: %w", err)
+	}
+
+	t := &terminal{tty: tty, pty: pty, copied: make(chan struct{}), winch: make(chan os.Signal, 1)}
+	t.resize()
+	signal.Notify(t.winch, syscall.SIGWINCH)
+	go func() {
+		for range t.winch {
+			t.resize()
+		}
+	}()
+	go func() {
+		// Reading fails with EIO once no process has the terminal open.
+		_, _ = io.Copy(w, pty)
+		close(t.copied)
+	}()
+
+	return t, nil
+}
+
+// resize sizes the terminal like the one taskctl runs in. The kernel signals
+// the change to the processes attached to it.
+func (t *terminal) resize() {
+	columns, rows, ok := hostTerminalSize()
+	if !ok {
+		columns, rows = defaultTTYColumns, defaultTTYRows
+	}
+
+	_ = control(t.pty, func(fd int) error {
+		return unix.IoctlSetWinsize(fd, unix.TIOCSWINSZ, &unix.Winsize{Col: uint16(columns), Row: uint16(rows)})
+	})
+}
+
+// close closes the terminal once its output is copied, or after
+// ttyDrainTimeout when a background process still holds it open.
+func (t *terminal) close() {
+	signal.Stop(t.winch)
+	close(t.winch)
+
+	_ = t.tty.Close()
+	select {
+	case <-t.copied:
+	case <-time.After(ttyDrainTimeout):
+	}
+	_ = t.pty.Close()
+	<-t.copied
+}
+
+// setControllingTerminal makes tty the controlling terminal of the process
+// cmd starts, in a session of its own, when it is one of its standard
+// streams; processes then get the terminal's window size changes.
+func setControllingTerminal(cmd *exec.Cmd, tty *os.File) {
+	fd := -1
+	switch tty {
+	case cmd.Stdin:
+		fd = 0
+	case cmd.Stdout:
+		fd = 1
+	case cmd.Stderr:
+		fd = 2
+	}
+	if fd < 0 {
+		return
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setsid, cmd.SysProcAttr.Setctty, cmd.SysProcAttr.Ctty = true, true, fd
+}
+
+// control runs fn with the file descriptor of f, without making f blocking
+// as f.Fd does.
+func control(f *os.File, fn func(fd int) error) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var fnErr error
+	if err := conn.Control(func(fd uintptr) { fnErr = fn(int(fd)) }); err != nil {
+		return err
+	}
+
+	return fnErr
+}
@@ -0,0 +1,51 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+)
+
+func TestDefaultExecutor_TTY(t *testing.T) {
+	if _, err := os.Stat("/dev/ptmx"); err != nil {
+		t.Skip("no pseudo-terminals:", err)
+	}
+
+	var out, stderr bytes.Buffer
+	e, err := NewDefaultExecutor(nil, &out, &stderr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, command := range []string{
+		"export greeting=hi",
+		`sh -c '[ -t 1 ] && [ -t 2 ] && echo "$greeting tty"'`,
+		`printf '\033[31mred\033[0m\n'; echo err >&2`,
+		"sh -c 'stty size </dev/tty'",
+	} {
+		job := NewJobFromCommand(command)
+		job.TTY = true
+		got, err := e.Execute(context.Background(), job)
+		if err != nil {
+			t.Fatalf("%s: %v", command, err)
+		}
+		if bytes.Contains(got, []byte("\x1b")) {
+			t.Errorf("%s: the returned output must have no escape sequences, got %q", command, got)
+		}
+	}
+
+	// Shell state carries across commands, the child gets the terminal as its
+	// controlling terminal, stderr goes to the terminal and
+	// without a terminal around taskctl, the size is the default.
+	want := "hi tty\n\x1b[31mred\x1b[0m\nerr\n24 80\n"
+	if out.String() != want || stderr.Len() != 0 {
+		t.Errorf("stdout = %q, stderr = %q; want stdout %q", out.String(), stderr.String(), want)
+	}
+
+	job := NewJobFromCommand("[ -t 1 ] || echo no tty")
+	got, err := e.Execute(context.Background(), job)
+	if err != nil || string(got) != "no tty\n" {
+		t.Errorf("a job without TTY must not get a terminal, got %q, %v", got, err)
+	}
+}
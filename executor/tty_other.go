@@ -0,0 +1,19 @@
+//go:build !linux
+
+package executor
+
+import (
+	"io"
+	"os"
+	"os/exec"
+)
+
+// openTerminal fails with errTTYUnsupported: pseudo-terminals are only
+// supported on Linux.
+func openTerminal(io.Writer) (*terminal, error) {
+	return nil, errTTYUnsupported
+}
+
+func (*terminal) close() {}
+
+func setControllingTerminal(*exec.Cmd, *os.File) {}
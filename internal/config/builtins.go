@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/taskctl/taskctl/executor"
+)
+
+// parseBuiltins reads the builtins key: one of executor.BuiltinSets, a list of
+// them, or none.
+func parseBuiltins(v any) ([]string, error) {
+	var sets []string
+	switch v := v.(type) {
+	case string:
+		if v != "none" {
+			sets = append(sets, v)
+		}
+	case []any:
+		for _, set := range v {
+			s, ok := set.(string)
+			if !ok {
+				return nil, fmt.Errorf("builtins: invalid set %v", set)
+			}
+			sets = append(sets, s)
+		}
+	default:
+		return nil, fmt.Errorf("builtins must be a set of builtins, a list of them or none, got %v", v)
+	}
+
+	for _, set := range sets {
+		if !slices.Contains(executor.BuiltinSets, set) {
+			return nil, fmt.Errorf("builtins: unknown set %q, want one of %s", set, strings.Join(executor.BuiltinSets, ", "))
+		}
+	}
+
+	// Not nil, so that none overrides the builtins of a task's context.
+	if sets == nil {
+		sets = []string{}
+	}
+
+	return sets, nil
+}
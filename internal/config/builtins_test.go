@@ -0,0 +1,64 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func Test_parseBuiltins(t *testing.T) {
+	tests := []struct {
+		in      any
+		want    []string
+		wantErr bool
+	}{
+		{in: "coreutils", want: []string{"coreutils"}},
+		{in: []any{"coreutils"}, want: []string{"coreutils"}},
+		{in: "none", want: []string{}},
+		{in: []any{}, want: []string{}},
+		{in: "busybox", wantErr: true},
+		{in: []any{1}, wantErr: true},
+		{in: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseBuiltins(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseBuiltins(%v) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (got == nil || !slices.Equal(got, tt.want)) {
+			t.Errorf("parseBuiltins(%v) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func Test_buildFromDefinitionBuiltins(t *testing.T) {
+	def := &configDefinition{
+		Contexts: map[string]*contextDefinition{
+			"scratch": {Builtins: "coreutils"},
+		},
+		Tasks: map[string]*taskDefinition{
+			"clean": {Command: []string{"rm -rf build"}, Builtins: "none"},
+			"build": {Command: []string{"true"}},
+		},
+	}
+
+	cfg, err := buildFromDefinition(def, &loaderContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := cfg.Contexts["scratch"].Builtins; !slices.Equal(got, []string{"coreutils"}) {
+		t.Errorf("context builtins = %v", got)
+	}
+	if got := cfg.Tasks["clean"].Builtins; got == nil || len(got) != 0 {
+		t.Errorf("builtins: none must be an empty list overriding the context, got %#v", got)
+	}
+	if got := cfg.Tasks["build"].Builtins; got != nil {
+		t.Errorf("a task without builtins must defer to its context, got %#v", got)
+	}
+
+	def.Contexts["scratch"].Builtins = "busybox"
+	if _, err := buildFromDefinition(def, &loaderContext{}); err == nil {
+		t.Error("an unknown builtins set must fail the config")
+	}
+}
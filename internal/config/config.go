@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/taskctl/taskctl/process"
 	"github.com/taskctl/taskctl/variables"
 
 	"dario.cat/mergo"
@@ -26,6 +29,8 @@ func NewConfig() *Config {
 		Tasks:     make(map[string]*task.Task),
 		Watchers:  make(map[string]*watch.Watcher),
 		Variables: defaultConfigVariables(),
+		Env:       variables.NewVariables(),
+		origins:   provenance{},
 	}
 
 	return cfg
@@ -33,7 +38,13 @@ func NewConfig() *Config {
 
 // Config is a taskctl internal config structure
 type Config struct {
-	Import    []string
+	// File is the config file that was loaded, empty when none was found.
+	File   string
+	Import []string
+	// Profile is the profile overlaid on the config, Profiles the ones it
+	// declares (see Loader.SetProfile).
+	Profile   string
+	Profiles  []string
 	Contexts  map[string]*runner.ExecutionContext
 	Pipelines map[string]*scheduler.ExecutionGraph
 	Tasks     map[string]*task.Task
@@ -46,7 +57,39 @@ type Config struct {
 	Summary *bool
 	Output  string
 
+	// LogDir is the directory per-task log files are written to; empty
+	// disables them. LogCombined adds a single run-wide log next to them.
+	LogDir      string
+	LogCombined bool
+	// LogMemoryLimit caps how much of each task's output is kept in memory
+	// before it spills to a temporary file; 0 uses the default.
+	LogMemoryLimit int
+
+	History HistoryConfig
+
+	// EnvInherit is the default host env inheritance, applied to contexts
+	// without their own and to tasks run without a context; nil inherits all.
+	EnvInherit *process.EnvInherit
+
 	Variables variables.Container
+	// Env is the env given on the command line for every task, under the
+	// env of contexts, tasks and stages.
+	Env variables.Container
+	// Overrides are the variables and env given on the command line, in the
+	// order they were applied (see Config.Override).
+	Overrides []Override
+
+	origins provenance
+}
+
+// HistoryConfig holds the run history settings. Zero values mean "use the
+// default": history enabled, stored under ~/.taskctl/history, keeping the
+// last history.DefaultKeep runs with no age limit.
+type HistoryConfig struct {
+	Enabled *bool
+	Dir     string
+	Keep    int
+	MaxAge  time.Duration
 }
 
 func (cfg *Config) merge(src *Config) error {
@@ -59,6 +102,11 @@ func (cfg *Config) merge(src *Config) error {
 	if err := mergo.Merge(cfg, src); err != nil {
 		return err
 	}
+	// mergo keeps cfg's non-nil container, so src's variables are layered on
+	// top explicitly.
+	if src.Variables != nil {
+		cfg.Variables = cfg.Variables.Merge(src.Variables)
+	}
 
 	return nil
 }
@@ -66,13 +114,23 @@ func (cfg *Config) merge(src *Config) error {
 func buildFromDefinition(def *configDefinition, lc *loaderContext) (cfg *Config, err error) {
 	cfg = NewConfig()
 
-	for k, v := range def.Contexts {
-		cfg.Contexts[k], err = buildContext(v)
+	if def.EnvInherit != nil {
+		cfg.EnvInherit, err = parseEnvInherit(def.EnvInherit)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	for k, v := range def.Contexts {
+		cfg.Contexts[k], err = buildContext(k, v, lc)
+		if err != nil {
+			return nil, fmt.Errorf("context %s: %w", k, err)
+		}
+		if cfg.Contexts[k].EnvInherit == nil {
+			cfg.Contexts[k].EnvInherit = cfg.EnvInherit
+		}
+	}
+
 	for k, v := range def.Tasks {
 		v.Name = k
 		cfg.Tasks[k], err = buildTask(v, lc)
@@ -86,7 +144,7 @@ func buildFromDefinition(def *configDefinition, lc *loaderContext) (cfg *Config,
 		if t == nil {
 			return nil, fmt.Errorf("no such task %s", v.Task)
 		}
-		cfg.Watchers[k], err = buildWatcher(k, v, cfg)
+		cfg.Watchers[k], err = buildWatcher(k, v, cfg, lc.configDir("watchers", k))
 		if err != nil {
 			return nil, err
 		}
@@ -101,18 +159,51 @@ func buildFromDefinition(def *configDefinition, lc *loaderContext) (cfg *Config,
 	}
 
 	for k, v := range def.Pipelines {
-		cfg.Pipelines[k], err = buildPipeline(cfg.Pipelines[k], v, cfg)
+		if v == nil {
+			continue
+		}
+		cfg.Pipelines[k].Timeout = v.Timeout
+		cfg.Pipelines[k], err = buildPipeline(cfg.Pipelines[k], v.Stages, cfg, lc.configDir("pipelines", k))
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	cfg.Import = def.Import
+	for _, v := range def.Import {
+		imp, err := parseImport(v)
+		if err != nil {
+			return nil, fmt.Errorf("import %v: %w", v, err)
+		}
+		cfg.Import = append(cfg.Import, imp.Path)
+	}
 	cfg.Debug = def.Debug
 	cfg.DryRun = def.DryRun
 	cfg.Summary = def.Summary
 	cfg.Output = def.Output
-	cfg.Variables = cfg.Variables.Merge(variables.FromMap(def.Variables))
+	cfg.LogDir = def.LogDir
+	if cfg.LogDir != "" && !filepath.IsAbs(cfg.LogDir) && lc.Dir != "" {
+		cfg.LogDir = filepath.Join(lc.Dir, cfg.LogDir)
+	}
+	cfg.LogCombined = def.LogCombined
+	if def.LogMemoryLimit != "" {
+		if cfg.LogMemoryLimit, err = ParseLogMemoryLimit(def.LogMemoryLimit); err != nil {
+			return nil, fmt.Errorf("log_memory_limit: %w", err)
+		}
+	}
+	cfg.History = HistoryConfig{
+		Enabled: def.History.Enabled,
+		Dir:     def.History.Dir,
+		Keep:    def.History.Keep,
+		MaxAge:  def.History.MaxAge,
+	}
+	if cfg.History.Dir != "" && !filepath.IsAbs(cfg.History.Dir) && lc.Dir != "" {
+		cfg.History.Dir = filepath.Join(lc.Dir, cfg.History.Dir)
+	}
+	vars, err := parseValues("variable", def.Variables)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Variables = cfg.Variables.Merge(vars)
 
 	return cfg, nil
 }
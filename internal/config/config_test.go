@@ -37,7 +37,7 @@ func TestConfig_decode(t *testing.T) {
 		t.Fatal("pipelines parsing error")
 	}
 
-	if len(def.Pipelines["pipeline2"]) != 2 {
+	if len(def.Pipelines["pipeline2"].Stages) != 2 {
 		t.Fatal("pipelines parsing failed")
 	}
 }
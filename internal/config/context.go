@@ -1,8 +1,6 @@
 package config
 
 import (
-	"path/filepath"
-
 	"github.com/taskctl/taskctl/internal/envutil"
 	"github.com/taskctl/taskctl/internal/fsutil"
 	"github.com/taskctl/taskctl/runner"
@@ -15,27 +13,34 @@ type contextDefinition struct {
 	Down       []string
 	Before     []string
 	After      []string
-	Env        map[string]string
+	Env        map[string]any
 	EnvFile    string `mapstructure:"env_file"`
-	Variables  map[string]string
+	Variables  map[string]any
 	Executable runner.Binary
 	Quote      string
+	Limits     *limitsDefinition
+	EnvInherit any `mapstructure:"env_inherit"`
+	// Builtins is a set of builtins, a list of them or none.
+	Builtins any
 }
 
-func buildContext(def *contextDefinition) (*runner.ExecutionContext, error) {
-	dir := def.Dir
+func buildContext(name string, def *contextDefinition, lc *loaderContext) (*runner.ExecutionContext, error) {
+	configDir := lc.configDir("contexts", name)
+	dir := resolvePath(configDir, def.Dir)
+	envFileDir := dir
+	if dir == "" {
+		dir, envFileDir = lc.defaultDir("contexts", name), configDir
+	}
 	if dir == "" {
 		dir = fsutil.MustGetwd()
 	}
 
-	envs := variables.FromMap(def.Env)
+	envs, err := parseValues("env", def.Env)
+	if err != nil {
+		return nil, err
+	}
 	if def.EnvFile != "" {
-		filename := def.EnvFile
-		if !filepath.IsAbs(filename) && dir != "" {
-			filename = filepath.Join(dir, filename)
-		}
-
-		fileEnvs, err := envutil.ReadEnvFile(filename)
+		fileEnvs, err := envutil.ReadEnvFile(resolvePath(envFileDir, def.EnvFile))
 		if err != nil {
 			return nil, err
 		}
@@ -43,6 +48,29 @@ func buildContext(def *contextDefinition) (*runner.ExecutionContext, error) {
 		envs = variables.FromMap(fileEnvs).Merge(envs)
 	}
 
+	opts := []runner.ExecutionContextOption{runner.WithQuote(def.Quote)}
+	if def.Limits != nil {
+		limits, err := buildLimits(def.Limits)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, runner.WithLimits(limits))
+	}
+	if def.EnvInherit != nil {
+		inherit, err := parseEnvInherit(def.EnvInherit)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, runner.WithEnvInherit(inherit))
+	}
+	if def.Builtins != nil {
+		builtins, err := parseBuiltins(def.Builtins)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, runner.WithBuiltins(builtins))
+	}
+
 	c := runner.NewExecutionContext(
 		&def.Executable,
 		dir,
@@ -51,9 +79,11 @@ func buildContext(def *contextDefinition) (*runner.ExecutionContext, error) {
 		def.Down,
 		def.Before,
 		def.After,
-		runner.WithQuote(def.Quote),
+		opts...,
 	)
-	c.Variables = variables.FromMap(def.Variables)
+	if c.Variables, err = parseValues("variable", def.Variables); err != nil {
+		return nil, err
+	}
 
 	return c, nil
 }
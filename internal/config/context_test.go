@@ -8,15 +8,15 @@ import (
 )
 
 func Test_buildContext_dir(t *testing.T) {
-	c, err := buildContext(&contextDefinition{
+	c, err := buildContext("local", &contextDefinition{
 		Up:        []string{"true"},
 		Down:      []string{"true"},
 		Before:    []string{"true"},
 		After:     []string{"true"},
-		Env:       map[string]string{},
-		Variables: map[string]string{},
+		Env:       map[string]any{},
+		Variables: map[string]any{},
 		Quote:     "'",
-	})
+	}, &loaderContext{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -28,11 +28,11 @@ func Test_buildContext_dir(t *testing.T) {
 }
 
 func Test_buildContext_env_file(t *testing.T) {
-	c, err := buildContext(&contextDefinition{
-		Env:       map[string]string{},
+	c, err := buildContext("local", &contextDefinition{
+		Env:       map[string]any{},
 		EnvFile:   "testdata/.env",
-		Variables: map[string]string{},
-	})
+		Variables: map[string]any{},
+	}, &loaderContext{})
 	if err != nil {
 		t.Fatal(err)
 	}
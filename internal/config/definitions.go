@@ -5,9 +5,10 @@ import (
 )
 
 type configDefinition struct {
-	Import    []string
+	// Import entries are paths, URLs or objects (see importDefinition).
+	Import    []any
 	Contexts  map[string]*contextDefinition
-	Pipelines map[string][]*stageDefinition
+	Pipelines map[string]*pipelineDefinition
 	Tasks     map[string]*taskDefinition
 	Watchers  map[string]*watcherDefinition
 
@@ -17,7 +18,31 @@ type configDefinition struct {
 	Summary *bool
 	Output  string
 
-	Variables map[string]string
+	LogDir         string `mapstructure:"log_dir"`
+	LogCombined    bool   `mapstructure:"log_combined"`
+	LogMemoryLimit string `mapstructure:"log_memory_limit"`
+
+	History historyDefinition
+
+	// EnvInherit is the env_inherit of contexts that don't set their own and
+	// of tasks run without a context.
+	EnvInherit any `mapstructure:"env_inherit"`
+
+	Variables map[string]any
+}
+
+type historyDefinition struct {
+	Enabled *bool
+	Dir     string
+	Keep    int
+	MaxAge  time.Duration `mapstructure:"max_age"`
+}
+
+// pipelineDefinition is a pipeline's stages, given either as the list itself
+// or, to set pipeline-wide settings, under stages (see pipelineDecodeHook).
+type pipelineDefinition struct {
+	Timeout time.Duration
+	Stages  []*stageDefinition
 }
 
 type stageDefinition struct {
@@ -26,30 +51,45 @@ type stageDefinition struct {
 	Task         string
 	Pipeline     string
 	DependsOn    []string `mapstructure:"depends_on"`
-	AllowFailure bool     `mapstructure:"allow_failure"`
+	SuccessCodes []int    `mapstructure:"success_codes"`
 	Dir          string
-	Env          map[string]string
+	Env          map[string]any
 	EnvFile      string `mapstructure:"env_file"`
-	Variables    map[string]string
+	Variables    map[string]any
+	Timeout      time.Duration
+	// AllowFailure is either a bool or {exit_codes: [...]}.
+	AllowFailure any `mapstructure:"allow_failure"`
 }
 
 type taskDefinition struct {
-	Name         string
-	Description  string
-	Condition    string
-	Command      []string
-	After        []string
-	Before       []string
-	Context      string
-	Variations   []map[string]string `yaml:",omitempty"`
-	Dir          string
-	Timeout      *time.Duration `yaml:",omitempty"`
-	AllowFailure bool           `mapstructure:"allow_failure"`
-	Interactive  bool
-	ExportAs     string
-	Env          map[string]string
-	EnvFile      string `mapstructure:"env_file"`
-	Variables    map[string]string
+	Name           string
+	Description    string
+	Condition      string
+	Command        []string
+	After          []string
+	Before         []string
+	OnFailure      []string `mapstructure:"on_failure"`
+	Finally        []string
+	Context        string
+	Variations     []map[string]string `yaml:",omitempty"`
+	Dir            string
+	Timeout        *time.Duration `yaml:",omitempty"`
+	CommandTimeout *time.Duration `mapstructure:"command_timeout" yaml:",omitempty"`
+	SuccessCodes   []int          `mapstructure:"success_codes"`
+	Interactive    bool
+	TTY            bool `mapstructure:"tty"`
+	ExportAs       string
+	Env            map[string]any
+	EnvFile        string `mapstructure:"env_file"`
+	Variables      map[string]any
+	Limits         *limitsDefinition
+	EnvInherit     any `mapstructure:"env_inherit"`
+	// Builtins is a set of builtins, a list of them or none.
+	Builtins any
+	// AllowFailure is either a bool or {exit_codes: [...]}.
+	AllowFailure any `mapstructure:"allow_failure"`
+	// Parallel is either a bool or the maximum number of concurrent runs.
+	Parallel any
 }
 
 type watcherDefinition struct {
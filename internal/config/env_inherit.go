@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/taskctl/taskctl/process"
+)
+
+// parseEnvInherit reads the env_inherit key: "all", "none" or the list of
+// host variables to inherit.
+func parseEnvInherit(v any) (*process.EnvInherit, error) {
+	switch v := v.(type) {
+	case string:
+		switch v {
+		case "all":
+			return &process.EnvInherit{All: true}, nil
+		case "none":
+			return &process.EnvInherit{}, nil
+		}
+	case []any:
+		names := make([]string, 0, len(v))
+		for _, name := range v {
+			s, ok := name.(string)
+			if !ok || s == "" || strings.ContainsAny(s, "= ") {
+				return nil, fmt.Errorf("env_inherit: invalid variable name %v", name)
+			}
+			names = append(names, s)
+		}
+		return &process.EnvInherit{Names: names}, nil
+	}
+
+	return nil, fmt.Errorf("env_inherit must be all, none or a list of variable names, got %v", v)
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func Test_parseEnvInherit(t *testing.T) {
+	tests := []struct {
+		in      any
+		all     bool
+		names   []string
+		wantErr bool
+	}{
+		{in: "all", all: true},
+		{in: "none", names: nil},
+		{in: []any{"PATH", "HOME"}, names: []string{"PATH", "HOME"}},
+		{in: []any{}, names: nil},
+		{in: "some", wantErr: true},
+		{in: true, wantErr: true},
+		{in: []any{"PATH", 1}, wantErr: true},
+		{in: []any{"A=B"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseEnvInherit(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseEnvInherit(%v) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got.All != tt.all || !slices.Equal(got.Names, tt.names) {
+			t.Errorf("parseEnvInherit(%v) = %+v", tt.in, got)
+		}
+	}
+}
+
+func Test_buildFromDefinitionEnvInherit(t *testing.T) {
+	def := &configDefinition{
+		EnvInherit: []any{"PATH"},
+		Contexts: map[string]*contextDefinition{
+			"hermetic": {EnvInherit: "none"},
+			"plain":    {},
+		},
+		Tasks: map[string]*taskDefinition{
+			"build": {Command: []string{"true"}, EnvInherit: "all"},
+		},
+	}
+
+	cfg, err := buildFromDefinition(def, &loaderContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := cfg.EnvInherit.String(); got != "PATH" {
+		t.Errorf("global env_inherit = %q", got)
+	}
+	if got := cfg.Contexts["hermetic"].EnvInherit.String(); got != "none" {
+		t.Errorf("a context's own env_inherit must be kept, got %q", got)
+	}
+	if got := cfg.Contexts["plain"].EnvInherit.String(); got != "PATH" {
+		t.Errorf("a context without env_inherit must get the global one, got %q", got)
+	}
+	if got := cfg.Tasks["build"].EnvInherit.String(); got != "all" {
+		t.Errorf("task env_inherit = %q", got)
+	}
+
+	def.Tasks["build"].EnvInherit = "some"
+	if _, err := buildFromDefinition(def, &loaderContext{}); err == nil {
+		t.Error("an invalid env_inherit must fail the config")
+	}
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// parseAllowFailure reads the allow_failure key: true or false, or an object
+// whose exit_codes list the only exit codes a command is allowed to fail with.
+func parseAllowFailure(v any) (allow bool, exitCodes []int, err error) {
+	switch v := v.(type) {
+	case nil:
+		return false, nil, nil
+	case bool:
+		return v, nil, nil
+	case string:
+		allow, err := strconv.ParseBool(v)
+		if err == nil {
+			return allow, nil, nil
+		}
+	case map[string]any:
+		for key := range v {
+			if key != "exit_codes" {
+				return false, nil, fmt.Errorf("allow_failure: unknown key %s", key)
+			}
+		}
+
+		list, _ := v["exit_codes"].([]any)
+		if len(list) == 0 {
+			return false, nil, fmt.Errorf("allow_failure: exit_codes must list at least one exit code")
+		}
+
+		exitCodes = make([]int, 0, len(list))
+		for _, c := range list {
+			code, err := strconv.Atoi(fmt.Sprint(c))
+			if err != nil {
+				return false, nil, fmt.Errorf("allow_failure: invalid exit code %v", c)
+			}
+			exitCodes = append(exitCodes, code)
+		}
+		if err := checkExitCodes("allow_failure", exitCodes); err != nil {
+			return false, nil, err
+		}
+
+		return true, exitCodes, nil
+	}
+
+	return false, nil, fmt.Errorf("allow_failure must be true, false or {exit_codes: [...]}, got %v", v)
+}
+
+// checkExitCodes rejects codes a process can't exit with.
+func checkExitCodes(key string, codes []int) error {
+	for _, code := range codes {
+		if code < 0 || code > 255 {
+			return fmt.Errorf("%s: exit code %d out of range 0-255", key, code)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func Test_parseAllowFailure(t *testing.T) {
+	tests := []struct {
+		in        any
+		allow     bool
+		exitCodes []int
+		wantErr   bool
+	}{
+		{in: nil},
+		{in: true, allow: true},
+		{in: false},
+		{in: "true", allow: true},
+		{in: map[string]any{"exit_codes": []any{1, int64(2), float64(3)}}, allow: true, exitCodes: []int{1, 2, 3}},
+		{in: map[string]any{"exit_codes": []any{}}, wantErr: true},
+		{in: map[string]any{}, wantErr: true},
+		{in: map[string]any{"exit_codes": []any{1}, "codes": []any{2}}, wantErr: true},
+		{in: map[string]any{"exit_codes": []any{"one"}}, wantErr: true},
+		{in: map[string]any{"exit_codes": []any{256}}, wantErr: true},
+		{in: "sometimes", wantErr: true},
+	}
+	for _, tt := range tests {
+		allow, exitCodes, err := parseAllowFailure(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseAllowFailure(%v) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if allow != tt.allow || !slices.Equal(exitCodes, tt.exitCodes) {
+			t.Errorf("parseAllowFailure(%v) = %v, %v, want %v, %v", tt.in, allow, exitCodes, tt.allow, tt.exitCodes)
+		}
+	}
+}
+
+func Test_buildTaskExitCodes(t *testing.T) {
+	tsk, err := buildTask(&taskDefinition{
+		Name:         "lint",
+		SuccessCodes: []int{0, 3},
+		AllowFailure: map[string]any{"exit_codes": []any{1}},
+	}, &loaderContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(tsk.SuccessCodes, []int{0, 3}) || !tsk.AllowFailure || !slices.Equal(tsk.AllowFailureExitCodes, []int{1}) {
+		t.Errorf("unexpected task %+v", tsk)
+	}
+
+	_, err = buildTask(&taskDefinition{Name: "lint", SuccessCodes: []int{-1}}, &loaderContext{})
+	if err == nil {
+		t.Error("expected an out of range success code to be rejected")
+	}
+}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gitImport is a config file in a git repository, imported as
+// git+SCHEME://REPO//PATH?ref=REF, e.g.
+// git+https://example.com/ci.git//tasks/ci.yaml?ref=v1.2 or
+// git+file:///srv/ci.git//tasks.yaml. Without a ref, the repository's default
+// branch is read.
+type gitImport struct {
+	Repo string
+	Path string
+	Ref  string
+}
+
+func isGitURL(s string) bool {
+	return strings.HasPrefix(s, "git+")
+}
+
+func parseGitURL(s string) (gitImport, error) {
+	u, err := url.Parse(strings.TrimPrefix(s, "git+"))
+	if err != nil {
+		return gitImport{}, err
+	}
+
+	var imp gitImport
+	imp.Ref = u.Query().Get("ref")
+	u.RawQuery = ""
+	repo, file, ok := strings.Cut(strings.TrimPrefix(u.String(), u.Scheme+"://"), "//")
+	if !ok || repo == "" || strings.Trim(file, "/") == "" {
+		return gitImport{}, fmt.Errorf("%s: expected git+SCHEME://REPO//PATH[?ref=REF]", s)
+	}
+	imp.Repo, imp.Path = u.Scheme+"://"+repo, strings.Trim(file, "/")
+
+	return imp, nil
+}
+
+// readGit reads the git import u from a bare clone of its repository kept in
+// the cache. The ref is fetched again once the last fetch is older than the
+// import's ttl, unless the commit the lock file pins is already there; offline,
+// the cached clone is used as is. Its content is verified like that of any
+// remote import.
+func (cl *Loader) readGit(u string, imp importDefinition) (map[string]any, error) {
+	g, err := parseGitURL(u)
+	if err != nil {
+		return nil, err
+	}
+	if cl.remote.CacheDir == "" {
+		return nil, fmt.Errorf("%s: git imports need an import cache", u)
+	}
+
+	sum := sha256.Sum256([]byte(g.Repo))
+	dir := filepath.Join(cl.remote.CacheDir, "git", hex.EncodeToString(sum[:]))
+	ref := g.Ref
+	if ref == "" {
+		ref = "refs/taskctl/HEAD"
+	}
+
+	ttl := DefaultImportTTL
+	if imp.TTL != 0 {
+		ttl = imp.TTL
+	}
+	locked := cl.locked(u).Commit
+	commit, resolveErr := "", errors.New("not fetched")
+	switch {
+	case locked != "":
+		if _, err := cl.git(imp, dir, nil, "cat-file", "-e", locked+"^{commit}"); err == nil {
+			commit, resolveErr = locked, nil
+		}
+	case cl.remote.Offline || !cl.remote.Refresh && fetchedWithin(dir, ttl):
+		commit, resolveErr = cl.gitResolve(imp, dir, ref)
+	}
+
+	if resolveErr != nil {
+		if cl.remote.Offline {
+			return nil, fmt.Errorf("%s: not in the import cache, run without --offline to fetch it", u)
+		}
+		if err := cl.gitFetch(imp, dir, g.Repo); err != nil {
+			return nil, fmt.Errorf("%s: %w", u, err)
+		}
+		if commit, err = cl.gitResolve(imp, dir, ref); err != nil {
+			return nil, fmt.Errorf("%s: ref %s: %w", u, ref, err)
+		}
+		if locked != "" && commit != locked {
+			if _, err := cl.git(imp, dir, nil, "cat-file", "-e", locked+"^{commit}"); err != nil {
+				return nil, fmt.Errorf("%s: commit %s locked in %s is gone, run taskctl imports update", u, locked, LockFileName)
+			}
+			commit = locked
+		}
+	}
+
+	data, err := cl.git(imp, dir, nil, "show", commit+":"+g.Path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s at %s: %w", u, g.Path, commit, err)
+	}
+	got, err := cl.verifyRemote(u, imp, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return cl.remoteImport(RemoteImport{URL: u, SHA256: got, Commit: commit}, data, filepath.Ext(g.Path))
+}
+
+// fetchedFile marks, by its modification time, when a cached clone was last
+// fetched.
+const fetchedFile = "taskctl-fetched"
+
+func fetchedWithin(dir string, ttl time.Duration) bool {
+	fi, err := os.Stat(filepath.Join(dir, fetchedFile))
+	return err == nil && time.Since(fi.ModTime()) < ttl
+}
+
+// gitFetch fetches the branches, tags and default branch of repo into the bare
+// clone dir, creating it first, sending the import's headers.
+func (cl *Loader) gitFetch(imp importDefinition, dir, repo string) error {
+	headers, err := importHeaders(imp)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "HEAD")); err != nil {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		if _, err := cl.git(imp, dir, nil, "init", "--quiet", "--bare"); err != nil {
+			return err
+		}
+	}
+
+	_, err = cl.git(imp, dir, gitHeaderEnv(headers), "fetch", "--quiet", "--force", "--prune", repo,
+		"+HEAD:refs/taskctl/HEAD", "+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, fetchedFile), nil, 0o644)
+}
+
+func (cl *Loader) gitResolve(imp importDefinition, dir, ref string) (string, error) {
+	out, err := cl.git(imp, dir, nil, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", errors.New("no such branch, tag or commit")
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// gitHeaderEnv returns the environment making git send headers with its
+// requests as http.extraHeader, after the GIT_CONFIG_COUNT entries of the
+// environment if any: given as arguments instead, credentials would show in
+// the process list.
+func gitHeaderEnv(headers map[string]string) []string {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	n, _ := strconv.Atoi(os.Getenv("GIT_CONFIG_COUNT"))
+	n = max(n, 0)
+	env := make([]string, 0, 2*len(headers)+1)
+	for _, k := range slices.Sorted(maps.Keys(headers)) {
+		env = append(env, fmt.Sprintf("GIT_CONFIG_KEY_%d=http.extraHeader", n), fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s: %s", n, k, headers[k]))
+		n++
+	}
+
+	return append(env, fmt.Sprintf("GIT_CONFIG_COUNT=%d", n))
+}
+
+// git runs git on the bare clone dir with the import's timeout and env added
+// to the environment, returning its output or an error with its stderr.
+func (cl *Loader) git(imp importDefinition, dir string, env []string, args ...string) ([]byte, error) {
+	timeout := DefaultImportTimeout
+	if imp.Timeout != 0 {
+		timeout = imp.Timeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append([]string{"--git-dir", dir}, args...)...)
+	cmd.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0"), env...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %w: %s", args[0], err, msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitRepo creates a bare repository in dir holding tasks.yaml with content,
+// tagged v1, and returns a function committing new content to its main
+// branch.
+func gitRepo(t *testing.T, dir, content string) func(content string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	work := filepath.Join(dir, "work")
+	git := func(args ...string) {
+		t.Helper()
+		args = append([]string{"-C", work, "-c", "user.name=taskctl", "-c", "user.email=taskctl@example.com", "-c", "commit.gpgsign=false", "-c", "tag.gpgsign=false"}, args...)
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	commit := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(work, "tasks.yaml"), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		git("add", "tasks.yaml")
+		git("commit", "--quiet", "-m", "tasks")
+		git("push", "--quiet", "origin", "HEAD:main")
+	}
+
+	if out, err := exec.Command("git", "init", "--quiet", "--bare", "--initial-branch=main", filepath.Join(dir, "repo.git")).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	if out, err := exec.Command("git", "clone", "--quiet", filepath.Join(dir, "repo.git"), work).CombinedOutput(); err != nil {
+		t.Fatalf("git clone: %v: %s", err, out)
+	}
+	commit(content)
+	git("tag", "v1")
+	git("push", "--quiet", "origin", "v1")
+
+	return commit
+}
+
+func Test_parseGitURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    gitImport
+		wantErr bool
+	}{
+		{
+			url:  "git+https://example.com/org/ci.git//tasks/ci.yaml?ref=v1.2",
+			want: gitImport{Repo: "https://example.com/org/ci.git", Path: "tasks/ci.yaml", Ref: "v1.2"},
+		},
+		{
+			url:  "git+file:///srv/ci.git//tasks.yaml",
+			want: gitImport{Repo: "file:///srv/ci.git", Path: "tasks.yaml"},
+		},
+		{url: "git+https://example.com/org/ci.git", wantErr: true},
+		{url: "git+https://example.com/org/ci.git//", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got, err := parseGitURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseGitURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseGitURL() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoader_LoadGitImport(t *testing.T) {
+	dir := t.TempDir()
+	commit := gitRepo(t, dir, "tasks:\n  build:\n    command: echo v1\n")
+	repo := "git+file://" + filepath.ToSlash(filepath.Join(dir, "repo.git"))
+	file := filepath.Join(writeConfigFiles(t, map[string]string{
+		"tasks.yaml": "import:\n  - {path: \"" + repo + "//tasks.yaml?ref=v1\", as: ci}\n" +
+			"  - {path: \"" + repo + "//tasks.yaml\", as: main, ttl: 1ns}\n",
+	}), "tasks.yaml")
+	load := func(o RemoteOptions) (*Loader, *Config, error) {
+		cl := NewConfigLoader(NewConfig())
+		o.CacheDir = filepath.Join(dir, "cache")
+		cl.SetRemoteOptions(o)
+		cfg, err := cl.Load(file)
+		return &cl, cfg, err
+	}
+
+	if _, _, err := load(RemoteOptions{Offline: true}); err == nil || !strings.Contains(err.Error(), "not in the import cache") {
+		t.Fatalf("an uncached git import must fail offline, got %v", err)
+	}
+
+	cl, cfg, err := load(RemoteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"ci:build", "main:build"} {
+		if task := cfg.Tasks[name]; task == nil || task.Commands[0] != "echo v1" {
+			t.Errorf("task %s not imported: %v", name, task)
+		}
+	}
+	remotes := cl.Remotes()
+	if len(remotes) != 2 || len(remotes[0].Commit) != 40 || remotes[0].Commit != remotes[1].Commit {
+		t.Fatalf("unexpected remotes %v", remotes)
+	}
+
+	lock := Lock{Imports: make(map[string]LockedImport)}
+	for _, r := range remotes {
+		lock.Imports[r.URL] = LockedImport{SHA256: r.SHA256, Commit: r.Commit}
+	}
+	if err := lock.Write(LockPath(file)); err != nil {
+		t.Fatal(err)
+	}
+
+	commit("tasks:\n  build:\n    command: echo v2\n")
+	if _, cfg, err = load(RemoteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Tasks["main:build"].Commands[0]; got != "echo v1" {
+		t.Errorf("the locked commit must be read, got %q", got)
+	}
+
+	if _, cfg, err = load(RemoteOptions{Refresh: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Tasks["main:build"].Commands[0]; got != "echo v2" {
+		t.Errorf("a refresh must read the branch's new commit, got %q", got)
+	}
+	if got := cfg.Tasks["ci:build"].Commands[0]; got != "echo v1" {
+		t.Errorf("the tag must still be read at v1, got %q", got)
+	}
+
+	if _, _, err = load(RemoteOptions{Offline: true}); err != nil {
+		t.Errorf("the cached clone must be used offline, got %v", err)
+	}
+	if _, _, err = load(RemoteOptions{Offline: true, Refresh: true}); err != nil {
+		t.Errorf("the cached clone must be used offline by imports verify, got %v", err)
+	}
+}
+
+func TestLoader_gitHeaders(t *testing.T) {
+	dir := t.TempDir()
+	gitRepo(t, dir, "tasks:\n  build:\n    command: echo v1\n")
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "http.extraHeader")
+	t.Setenv("GIT_CONFIG_VALUE_0", "X-Host: ci")
+
+	cl := NewConfigLoader(NewConfig())
+	out, err := cl.git(importDefinition{}, filepath.Join(dir, "repo.git"), gitHeaderEnv(map[string]string{"X-Token": "secret"}), "config", "--get-all", "http.extraHeader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(out); got != "X-Host: ci\nX-Token: secret\n" {
+		t.Errorf("git must see the headers after those of the environment, got %q", got)
+	}
+
+	repo := "git+file://" + filepath.ToSlash(filepath.Join(dir, "repo.git"))
+	file := filepath.Join(writeConfigFiles(t, map[string]string{
+		"tasks.yaml": "import:\n  - {path: \"" + repo + "//tasks.yaml?ref=v1\", as: ci, headers: {X-Token: $TASKCTL_TEST_TOKEN}}\n",
+	}), "tasks.yaml")
+	load := func(o RemoteOptions) error {
+		cl := NewConfigLoader(NewConfig())
+		o.CacheDir = filepath.Join(dir, "cache")
+		cl.SetRemoteOptions(o)
+		_, err := cl.Load(file)
+		return err
+	}
+
+	if err := load(RemoteOptions{}); err == nil || !strings.Contains(err.Error(), "TASKCTL_TEST_TOKEN is not set") {
+		t.Fatalf("a fetch must expand the headers, got %v", err)
+	}
+	t.Setenv("TASKCTL_TEST_TOKEN", "secret")
+	if err := load(RemoteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Unsetenv("TASKCTL_TEST_TOKEN"); err != nil {
+		t.Fatal(err)
+	}
+	if err := load(RemoteOptions{Offline: true}); err != nil {
+		t.Errorf("reading the cached clone must not need the headers, got %v", err)
+	}
+}
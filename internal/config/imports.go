@@ -0,0 +1,243 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// importDefinition is an entry of import:, given either as the path or URL
+// alone or as an object. As namespaces the tasks, pipelines, contexts and
+// watchers of the import, so its build is addressed as backend:build.
+// Variables are set on every task it defines, under the task's own.
+type importDefinition struct {
+	Path      string
+	As        string
+	Optional  bool
+	Variables map[string]any
+
+	// SHA256 pins the content of a remote import. Headers are sent with its
+	// request, their $VAR references expanded from the environment; Timeout
+	// caps the request and TTL is how long the cached copy is used.
+	SHA256  string `mapstructure:"sha256"`
+	Headers map[string]string
+	Timeout time.Duration
+	TTL     time.Duration `mapstructure:"ttl"`
+
+	// expandHeaders is set for an import declared in a local file: a remote
+	// config could otherwise send the environment to any host.
+	expandHeaders bool
+}
+
+func parseImport(v any) (importDefinition, error) {
+	var imp importDefinition
+	switch v := v.(type) {
+	case string:
+		imp.Path = v
+	case map[string]any:
+		md, _ := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+			ErrorUnused:      true,
+			WeaklyTypedInput: true,
+			Result:           &imp,
+		})
+		if err := md.Decode(v); err != nil {
+			return imp, err
+		}
+	default:
+		return imp, fmt.Errorf("must be a path or {path: ..., as: ...}, got %v", v)
+	}
+	if imp.Path == "" {
+		return imp, errors.New("path is required")
+	}
+	if !isURL(imp.Path) && (imp.SHA256 != "" || imp.Headers != nil || imp.Timeout != 0 || imp.TTL != 0) {
+		return imp, errors.New("sha256, headers, timeout and ttl are only for remote imports")
+	}
+
+	return imp, nil
+}
+
+// definedSections are the sections whose entries are namespaced by an
+// import's as and must not be defined by two files, with the name used for
+// each entry in errors.
+var definedSections = map[string]string{
+	"tasks":     "task",
+	"pipelines": "pipeline",
+	"contexts":  "context",
+	"watchers":  "watcher",
+	"profiles":  "profile",
+}
+
+// define records the tasks, pipelines, contexts, watchers and profiles raw,
+// the config of file loaded under namespace ns, defines, failing for one that
+// another file already defines.
+func (cl *Loader) define(file, ns string, raw map[string]any) error {
+	for section, kind := range definedSections {
+		for name := range asMap(raw[section]) {
+			k := section + "\x00" + ns + name
+			if prev, ok := cl.defined[k]; ok && prev != file {
+				return fmt.Errorf("%s %s is defined in both %s and %s", kind, ns+name, prev, file)
+			}
+			cl.defined[k] = file
+		}
+	}
+
+	return nil
+}
+
+// namespace prefixes the tasks, pipelines, contexts, watchers and profiles raw
+// defines with ns, and the references raw makes to them. Stages named after
+// the task or pipeline they run keep their name, so depends_on still matches.
+func namespace(raw map[string]any, ns string) {
+	tasks, pipelines, contexts := asMap(raw["tasks"]), asMap(raw["pipelines"]), asMap(raw["contexts"])
+	ref := func(def map[string]any, key string, defined map[string]any) {
+		if name, ok := def[key].(string); ok && name != "" {
+			if _, ok := defined[name]; ok {
+				def[key] = ns + name
+			}
+		}
+	}
+
+	if tasks != nil {
+		renamed := make(map[string]any, len(tasks))
+		for name, def := range tasks {
+			if d := asMap(def); d != nil {
+				d = maps.Clone(d)
+				ref(d, "context", contexts)
+				def = d
+			}
+			renamed[ns+name] = def
+		}
+		raw["tasks"] = renamed
+	}
+
+	if watchers := asMap(raw["watchers"]); watchers != nil {
+		renamed := make(map[string]any, len(watchers))
+		for name, def := range watchers {
+			if d := asMap(def); d != nil {
+				d = maps.Clone(d)
+				ref(d, "task", tasks)
+				def = d
+			}
+			renamed[ns+name] = def
+		}
+		raw["watchers"] = renamed
+	}
+
+	if pipelines != nil {
+		renamed := make(map[string]any, len(pipelines))
+		for name, def := range pipelines {
+			stages, ok := def.([]any)
+			if !ok {
+				stages, _ = asMap(def)["stages"].([]any)
+			}
+			namespaced := make([]any, len(stages))
+			for i, stage := range stages {
+				s := asMap(stage)
+				if s == nil {
+					namespaced[i] = stage
+					continue
+				}
+				s = maps.Clone(s)
+				if name, _ := s["name"].(string); name == "" {
+					if n := stageName(s); n != "" {
+						s["name"] = n
+					}
+				}
+				ref(s, "task", tasks)
+				ref(s, "pipeline", pipelines)
+				namespaced[i] = s
+			}
+			if ok {
+				def = namespaced
+			} else if d := asMap(def); d != nil && stages != nil {
+				d = maps.Clone(d)
+				d["stages"] = namespaced
+				def = d
+			}
+			renamed[ns+name] = def
+		}
+		raw["pipelines"] = renamed
+	}
+
+	if profiles := asMap(raw["profiles"]); profiles != nil {
+		renamed := make(map[string]any, len(profiles))
+		for name, def := range profiles {
+			if d := asMap(def); d != nil {
+				d = maps.Clone(d)
+				if overlays := asMap(d["tasks"]); overlays != nil {
+					namespaced := make(map[string]any, len(overlays))
+					for task, overlay := range overlays {
+						if o := asMap(overlay); o != nil {
+							o = maps.Clone(o)
+							ref(o, "context", contexts)
+							overlay = o
+						}
+						if _, ok := tasks[task]; ok {
+							task = ns + task
+						}
+						namespaced[task] = overlay
+					}
+					d["tasks"] = namespaced
+				}
+				if overlays := asMap(d["contexts"]); overlays != nil {
+					namespaced := make(map[string]any, len(overlays))
+					for context, overlay := range overlays {
+						if _, ok := contexts[context]; ok {
+							context = ns + context
+						}
+						namespaced[context] = overlay
+					}
+					d["contexts"] = namespaced
+				}
+				def = d
+			}
+			renamed[ns+name] = def
+		}
+		raw["profiles"] = renamed
+	}
+
+	if contexts != nil {
+		renamed := make(map[string]any, len(contexts))
+		for name, def := range contexts {
+			renamed[ns+name] = def
+		}
+		raw["contexts"] = renamed
+	}
+}
+
+// setImportVariables sets vars, the variables of an import of file, on every
+// task raw defines that doesn't declare them itself. ns is the namespace file
+// was loaded under.
+func (cl *Loader) setImportVariables(file, ns string, raw map[string]any, vars map[string]any) {
+	if len(vars) == 0 {
+		return
+	}
+
+	tasks := asMap(raw["tasks"])
+	for name, def := range tasks {
+		d := asMap(def)
+		if d == nil {
+			continue
+		}
+		d = maps.Clone(d)
+		own := asMap(d["variables"])
+		merged, added := maps.Clone(own), make(map[string]any)
+		if merged == nil {
+			merged = make(map[string]any, len(vars))
+		}
+		for k, v := range vars {
+			if _, ok := own[k]; !ok {
+				merged[k], added[k] = v, v
+			}
+		}
+		d["variables"] = merged
+		tasks[name] = d
+		if cl.dst.origins != nil {
+			cl.dst.origins.addMap(file, added, "tasks", ns+name, "variables")
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestLoader_LoadNamespacedImports(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"tasks.yaml": `
+import:
+  - path: backend/tasks.yaml
+    as: backend
+    variables:
+      Service: api
+  - {path: frontend.yaml, as: frontend}
+  - {path: shared.yaml, as: web}
+  - {path: missing.yaml, optional: true}
+  - {path: missing/, optional: true}
+tasks:
+  build:
+    command: [echo root]
+pipelines:
+  all:
+    - task: backend:build
+    - task: frontend:build
+`,
+		"backend/tasks.yaml": `
+import: [common.yaml]
+contexts:
+  docker:
+    dir: /srv
+tasks:
+  build:
+    context: docker
+    command: [echo backend]
+  migrate:
+    command: [echo migrate]
+    variables:
+      Service: db
+pipelines:
+  release:
+    - task: build
+    - task: lint
+      depends_on: [build]
+    - task: migrate
+      depends_on: [lint]
+watchers:
+  sources:
+    watch: ["*.go"]
+    task: build
+`,
+		"backend/common.yaml": `
+tasks:
+  lint:
+    command: [echo lint]
+`,
+		"frontend.yaml": `
+import:
+  - {path: shared.yaml, as: shared}
+tasks:
+  build:
+    command: [echo frontend]
+`,
+		"shared.yaml": `
+tasks:
+  fmt:
+    command: [echo fmt]
+`,
+	})
+
+	cfg := NewConfig()
+	cl := NewConfigLoader(cfg)
+	if _, err := cl.Load(filepath.Join(dir, "tasks.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"build", "backend:build", "backend:migrate", "backend:lint", "frontend:build", "frontend:shared:fmt", "web:fmt"} {
+		if cfg.Tasks[name] == nil {
+			t.Errorf("task %s is not defined", name)
+		}
+	}
+	if len(cfg.Tasks) != 7 {
+		t.Errorf("loaded %d tasks, want 7", len(cfg.Tasks))
+	}
+
+	if got := cfg.Tasks["backend:build"].Context; got != "backend:docker" {
+		t.Errorf("backend:build context = %s", got)
+	}
+	if cfg.Contexts["backend:docker"] == nil {
+		t.Error("context backend:docker is not defined")
+	}
+	if got := cfg.Tasks["backend:build"].Variables.Get("Service"); got != "api" {
+		t.Errorf("backend:build Service = %v", got)
+	}
+	if got := cfg.Tasks["backend:migrate"].Variables.Get("Service"); got != "db" {
+		t.Errorf("a task's own variable must win over the import's, Service = %v", got)
+	}
+	if cfg.Tasks["frontend:build"].Variables.Has("Service") {
+		t.Error("import variables must apply to the tasks of that import only")
+	}
+
+	migrate, err := cfg.Pipelines["backend:release"].Node("migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrate.Task.Name != "backend:migrate" || strings.Join(migrate.DependsOn, ",") != "lint" {
+		t.Errorf("unexpected stage %s: task %s, depends on %v", migrate.Name, migrate.Task.Name, migrate.DependsOn)
+	}
+	if _, err := cfg.Pipelines["all"].Node("backend:build"); err != nil {
+		t.Error(err)
+	}
+	if w := cfg.Watchers["backend:sources"]; w == nil {
+		t.Error("watcher backend:sources is not defined")
+	}
+
+	origins := cfg.Origins("tasks", "backend:build", "variables", "Service")
+	if len(origins) != 1 || origins[0].File != filepath.Join(dir, "tasks.yaml") {
+		t.Errorf("unexpected origins %v", origins)
+	}
+	origins = cfg.Origins("tasks", "backend:migrate", "variables", "Service")
+	if len(origins) != 1 || origins[0].File != filepath.Join(dir, "backend", "tasks.yaml") {
+		t.Errorf("unexpected origins %v", origins)
+	}
+}
+
+func TestLoader_LoadImportErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name: "collision between imports",
+			files: map[string]string{
+				"tasks.yaml": "import: [a.yaml, b.yaml]\n",
+				"a.yaml":     "tasks: {build: {command: [echo a]}}\n",
+				"b.yaml":     "tasks: {build: {command: [echo b]}}\n",
+			},
+			want: "task build is defined in both",
+		},
+		{
+			name: "collision with the importer",
+			files: map[string]string{
+				"tasks.yaml": "import: [a.yaml]\npipelines: {release: [{task: build}]}\ntasks: {build: {command: [echo root]}}\n",
+				"a.yaml":     "pipelines: {release: [{task: build}]}\n",
+			},
+			want: "pipeline release is defined in both",
+		},
+		{
+			name: "missing import",
+			files: map[string]string{
+				"tasks.yaml": "import: [{path: missing.yaml, as: missing}]\n",
+			},
+			want: "missing.yaml",
+		},
+		{
+			name: "unknown import key",
+			files: map[string]string{
+				"tasks.yaml": "import: [{path: a.yaml, namespace: a}]\n",
+				"a.yaml":     "tasks: {}\n",
+			},
+			want: "namespace",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeConfigFiles(t, tt.files)
+			cl := NewConfigLoader(NewConfig())
+			_, err := cl.Load(filepath.Join(dir, "tasks.yaml"))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("unexpected error %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoader_LoadOptionalURLImport(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	dir := writeConfigFiles(t, map[string]string{
+		"tasks.yaml":  "import:\n  - {path: " + srv.URL + "/tasks.yaml, optional: true}\ntasks: {build: {command: [echo]}}\n",
+		"strict.yaml": "import: [" + srv.URL + "/tasks.yaml]\n",
+	})
+
+	cl := NewConfigLoader(NewConfig())
+	if _, err := cl.Load(filepath.Join(dir, "tasks.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	cl = NewConfigLoader(NewConfig())
+	if _, err := cl.Load(filepath.Join(dir, "strict.yaml")); !errors.Is(err, ErrConfigNotFound) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestLoader_LoadImportPaths(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"tasks.yaml": `
+import: [services/api/tasks.yaml]
+tasks:
+  root:
+    command: [pwd]
+  bin:
+    dir: bin
+    command: [pwd]
+`,
+		"services/api/tasks.yaml": `
+contexts:
+  api:
+    env_file: .env
+tasks:
+  build:
+    context: api
+    env_file: .env
+    command: [go build]
+  test:
+    dir: src
+    command: [go test]
+  generate:
+    dir: "{{ .Root }}/gen"
+    command: [go generate]
+pipelines:
+  release:
+    - task: build
+    - task: test
+      dir: web
+      env_file: web.env
+watchers:
+  sources:
+    watch: ["**/*.go", "/tmp/*.go"]
+    task: test
+`,
+		"services/api/.env":        "TARGET=api\n",
+		"services/api/web/web.env": "TARGET=web\n",
+	})
+	api := filepath.Join(dir, "services", "api")
+
+	cfg := NewConfig()
+	cl := NewConfigLoader(cfg)
+	if _, err := cl.Load(filepath.Join(dir, "tasks.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct{ task, dir string }{
+		{"root", ""},
+		{"bin", filepath.Join(dir, "bin")},
+		{"build", api},
+		{"test", filepath.Join(api, "src")},
+		{"generate", "{{ .Root }}/gen"},
+	}
+	for _, tt := range tests {
+		if got := cfg.Tasks[tt.task].Dir; got != tt.dir {
+			t.Errorf("task %s dir = %q, want %q", tt.task, got, tt.dir)
+		}
+	}
+
+	build := cfg.Tasks["build"]
+	if got := build.Env.Get("TARGET"); got != "api" {
+		t.Errorf("build TARGET = %v", got)
+	}
+	if got := build.Variables.Get("ConfigFile"); got != filepath.Join(api, "tasks.yaml") {
+		t.Errorf("build ConfigFile = %v", got)
+	}
+	if got := build.Variables.Get("ConfigDir"); got != api {
+		t.Errorf("build ConfigDir = %v", got)
+	}
+	if got := cfg.Tasks["root"].Variables.Get("ConfigDir"); got != dir {
+		t.Errorf("root ConfigDir = %v", got)
+	}
+
+	if c := cfg.Contexts["api"]; c.Dir != api || c.Env.Get("TARGET") != "api" {
+		t.Errorf("context api: dir %s, TARGET %v", c.Dir, c.Env.Get("TARGET"))
+	}
+
+	stage, err := cfg.Pipelines["release"].Node("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stage.Dir != filepath.Join(api, "web") || stage.Env.Get("TARGET") != "web" {
+		t.Errorf("stage test: dir %s, TARGET %v", stage.Dir, stage.Env.Get("TARGET"))
+	}
+}
+
+func Test_resolvePatterns(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := resolvePatterns(filepath.Join(cwd, "testdata"), []string{"**/*.go", "/tmp/*.go", "../*.go"})
+	want := []string{filepath.Join("testdata", "**", "*.go"), "/tmp/*.go", "*.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("resolvePatterns() = %v, want %v", got, want)
+	}
+
+	got = resolvePatterns(filepath.Dir(cwd), []string{"*.go"})
+	if want := []string{filepath.Join(filepath.Dir(cwd), "*.go")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("a pattern outside the current directory stays absolute, got %v", got)
+	}
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/taskctl/taskctl/process"
+)
+
+// limitsDefinition is the limits: section of a task or context. Sizes are
+// strings so they can carry a unit, e.g. "4GiB".
+type limitsDefinition struct {
+	Memory       string
+	AddressSpace string        `mapstructure:"address_space"`
+	CPUTime      time.Duration `mapstructure:"cpu_time"`
+	OpenFiles    uint64        `mapstructure:"open_files"`
+	Nice         *int
+	IONice       string `mapstructure:"ionice"`
+	CPUs         float64
+}
+
+func buildLimits(def *limitsDefinition) (*process.Limits, error) {
+	l := &process.Limits{
+		CPUTime:   def.CPUTime,
+		OpenFiles: def.OpenFiles,
+		Nice:      def.Nice,
+		CPUs:      def.CPUs,
+	}
+
+	var err error
+	if def.Memory != "" {
+		if l.Memory, err = parseBytes(def.Memory); err != nil {
+			return nil, fmt.Errorf("limits.memory: %w", err)
+		}
+	}
+	if def.AddressSpace != "" {
+		if l.AddressSpace, err = parseBytes(def.AddressSpace); err != nil {
+			return nil, fmt.Errorf("limits.address_space: %w", err)
+		}
+	}
+
+	if l.Nice != nil && (*l.Nice < -20 || *l.Nice > 19) {
+		return nil, fmt.Errorf("limits.nice: %d is out of range -20..19", *l.Nice)
+	}
+	if l.CPUs < 0 {
+		return nil, fmt.Errorf("limits.cpus: %v must be positive", l.CPUs)
+	}
+
+	if def.IONice != "" {
+		if l.IOClass, l.IOLevel, err = parseIONice(def.IONice); err != nil {
+			return nil, fmt.Errorf("limits.ionice: %w", err)
+		}
+	}
+
+	return l, nil
+}
+
+// parseIONice parses an I/O priority as "class" or "class:level", where class
+// is realtime, best-effort or idle, and level 0 (highest) to 7 (default 4).
+func parseIONice(s string) (string, int, error) {
+	class, level, hasLevel := strings.Cut(s, ":")
+	if class != "realtime" && class != "best-effort" && class != "idle" {
+		return "", 0, fmt.Errorf("unknown class %q, want realtime, best-effort or idle", class)
+	}
+
+	if !hasLevel {
+		if class == "idle" {
+			return class, 0, nil
+		}
+		return class, 4, nil
+	}
+
+	n, err := strconv.Atoi(level)
+	if err != nil || n < 0 || n > 7 {
+		return "", 0, fmt.Errorf("level %q is out of range 0..7", level)
+	}
+
+	return class, n, nil
+}
+
+// ParseLogMemoryLimit parses the size of a task's output kept in memory (see
+// Config.LogMemoryLimit), in the units of parseBytes.
+func ParseLogMemoryLimit(s string) (int, error) {
+	n, err := parseBytes(s)
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 || n > math.MaxInt {
+		return 0, fmt.Errorf("invalid size %q: out of range", s)
+	}
+
+	return int(n), nil
+}
+
+// parseBytes parses a byte count with an optional binary unit suffix: "512",
+// "64KiB", "512M", "4GiB" or "1T". K, M, G and T (with or without "B" or "iB")
+// are powers of 1024.
+func parseBytes(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	num := strings.TrimRightFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	unit := strings.ToUpper(strings.TrimSpace(s[len(num):]))
+	unit = strings.TrimSuffix(strings.TrimSuffix(unit, "B"), "I")
+
+	n, err := strconv.ParseUint(num, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	if unit == "" {
+		return n, nil
+	}
+
+	shift := strings.Index("KMGT", unit) + 1
+	if shift == 0 || len(unit) != 1 {
+		return 0, fmt.Errorf("invalid size %q: unknown unit", s)
+	}
+
+	return n << (10 * shift), nil
+}
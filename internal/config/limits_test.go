@@ -0,0 +1,169 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/taskctl/taskctl/process"
+)
+
+func Test_parseBytes(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    uint64
+		wantErr bool
+	}{
+		{in: "512", want: 512},
+		{in: "512B", want: 512},
+		{in: "64KiB", want: 64 << 10},
+		{in: "512M", want: 512 << 20},
+		{in: "4GiB", want: 4 << 30},
+		{in: "2gb", want: 2 << 30},
+		{in: "1T", want: 1 << 40},
+		{in: "1.5G", wantErr: true},
+		{in: "4XB", wantErr: true},
+		{in: "GiB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseBytes(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseBytes(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseBytes(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func Test_buildLimits(t *testing.T) {
+	nice, tooNice := 10, 20
+	got, err := buildLimits(&limitsDefinition{
+		Memory:       "4GiB",
+		AddressSpace: "8G",
+		CPUTime:      time.Minute,
+		OpenFiles:    1024,
+		Nice:         &nice,
+		IONice:       "best-effort:7",
+		CPUs:         1.5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := process.Limits{
+		Memory:       4 << 30,
+		AddressSpace: 8 << 30,
+		CPUTime:      time.Minute,
+		OpenFiles:    1024,
+		Nice:         &nice,
+		IOClass:      "best-effort",
+		IOLevel:      7,
+		CPUs:         1.5,
+	}
+	if *got != want {
+		t.Errorf("buildLimits() = %+v, want %+v", *got, want)
+	}
+
+	for _, def := range []*limitsDefinition{
+		{Memory: "lots"},
+		{Nice: &tooNice},
+		{CPUs: -1},
+		{IONice: "fast"},
+		{IONice: "realtime:8"},
+	} {
+		if _, err := buildLimits(def); err == nil {
+			t.Errorf("buildLimits(%+v) expected an error", *def)
+		}
+	}
+}
+
+func Test_parseIONice(t *testing.T) {
+	for in, want := range map[string]struct {
+		class string
+		level int
+	}{
+		"idle":          {"idle", 0},
+		"best-effort":   {"best-effort", 4},
+		"realtime:0":    {"realtime", 0},
+		"best-effort:2": {"best-effort", 2},
+	} {
+		class, level, err := parseIONice(in)
+		if err != nil || class != want.class || level != want.level {
+			t.Errorf("parseIONice(%q) = %q, %d, %v; want %q, %d", in, class, level, err, want.class, want.level)
+		}
+	}
+}
+
+func TestLoader_Load_limits(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "taskctl.yaml")
+	err := os.WriteFile(file, []byte(`
+contexts:
+  heavy:
+    limits:
+      memory: 2GiB
+      cpus: 2
+tasks:
+  test:
+    context: heavy
+    command: go test -race ./...
+    limits:
+      cpu_time: 10m
+      open_files: 4096
+      nice: 10
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cl := NewConfigLoader(NewConfig())
+	cfg, err := cl.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := cfg.Contexts["heavy"].Limits; got == nil || got.Memory != 2<<30 || got.CPUs != 2 {
+		t.Errorf("context limits = %+v", got)
+	}
+	if got := cfg.Tasks["test"].Limits; got == nil || got.CPUTime != 10*time.Minute || got.OpenFiles != 4096 || got.Nice == nil || *got.Nice != 10 {
+		t.Errorf("task limits = %+v", got)
+	}
+}
+
+func TestParseLogMemoryLimit(t *testing.T) {
+	if got, err := ParseLogMemoryLimit("4MiB"); err != nil || got != 4<<20 {
+		t.Errorf("ParseLogMemoryLimit(4MiB) = %d, %v", got, err)
+	}
+	for _, in := range []string{"0", "lots", "-1"} {
+		if _, err := ParseLogMemoryLimit(in); err == nil {
+			t.Errorf("ParseLogMemoryLimit(%q) expected an error", in)
+		}
+	}
+}
+
+func TestLoader_Load_logMemoryLimit(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "taskctl.yaml")
+	if err := os.WriteFile(file, []byte("log_memory_limit: 1MiB\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cl := NewConfigLoader(NewConfig())
+	cfg, err := cl.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LogMemoryLimit != 1<<20 {
+		t.Errorf("LogMemoryLimit = %d, want %d", cfg.LogMemoryLimit, 1<<20)
+	}
+
+	if err := os.WriteFile(file, []byte("log_memory_limit: 0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cl = NewConfigLoader(NewConfig())
+	if _, err := cl.Load(file); err == nil {
+		t.Error("expected an error for a zero log_memory_limit")
+	}
+}
@@ -2,18 +2,18 @@ package config
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
+	"io/fs"
 	"log/slog"
-	"mime"
-	"net/http"
+	"maps"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 
 	"dario.cat/mergo"
@@ -22,50 +22,107 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/taskctl/taskctl/internal/fsutil"
-	"github.com/taskctl/taskctl/internal/iox"
 )
 
 // ErrConfigNotFound occurs when requested config file does not exists
 var ErrConfigNotFound = errors.New("config file not found")
 
-// isURL checks if given string is a valid URL
+// isURL checks if given string is the URL of a remote config: an http(s)
+// URL or a git one (see parseGitURL)
 func isURL(s string) bool {
 	u, err := url.Parse(s)
 	if err != nil {
 		return false
 	}
 
-	return strings.HasPrefix(u.Scheme, "http")
+	return strings.HasPrefix(u.Scheme, "http") || strings.HasPrefix(u.Scheme, "git+")
 }
 
 // Loader reads and parses config files
 type Loader struct {
 	dst     *Config
 	imports map[string]bool
+	loading map[string]bool
+	defined map[string]string
 	dir     string
 	homeDir string
+
+	remote  RemoteOptions
+	remotes []RemoteImport
+	lock    Lock
+
+	profile string
 }
 
 // NewConfigLoader is Loader constructor
 func NewConfigLoader(dst *Config) Loader {
-	return Loader{
+	cl := Loader{
 		dst:     dst,
 		imports: make(map[string]bool),
+		loading: make(map[string]bool),
+		defined: make(map[string]string),
 		homeDir: fsutil.MustGetUserHomeDir(),
 		dir:     fsutil.MustGetwd(),
 	}
+	cl.SetRemoteOptions(RemoteOptions{})
+
+	return cl
 }
 
 type loaderContext struct {
 	Dir string
+	// File is the config file loaded, Files the file each task, pipeline,
+	// context and watcher is defined in, keyed like Loader.defined.
+	File  string
+	Files map[string]string
+}
+
+// configFile returns the file defining the entry name of section.
+func (lc *loaderContext) configFile(section, name string) string {
+	if file, ok := lc.Files[section+"\x00"+name]; ok {
+		return file
+	}
+
+	return lc.File
+}
+
+// configDir returns the directory the relative paths of the entry name of
+// section are resolved against: the directory of its file or, for one
+// loaded from a URL, Dir.
+func (lc *loaderContext) configDir(section, name string) string {
+	file := lc.configFile(section, name)
+	if file == "" || isURL(file) {
+		return lc.Dir
+	}
+
+	return filepath.Dir(file)
+}
+
+// defaultDir returns the working directory of the entry name of section when
+// it sets none: the directory of the imported file defining it. The entries
+// of the config file itself, and of URLs, run in the current directory.
+func (lc *loaderContext) defaultDir(section, name string) string {
+	file := lc.configFile(section, name)
+	if file == "" || file == lc.File || isURL(file) {
+		return ""
+	}
+
+	return filepath.Dir(file)
+}
+
+// resolvePath resolves p against dir when it is relative. A path starting
+// with a template, like {{ .Root }}/bin, is left as is to be rendered.
+func resolvePath(dir, p string) string {
+	if p == "" || dir == "" || filepath.IsAbs(p) || strings.HasPrefix(p, "{{") {
+		return p
+	}
+
+	return filepath.Join(dir, p)
 }
 
 // Load loads and parses requested config file
 func (cl *Loader) Load(file string) (*Config, error) {
 	cl.reset()
-	lc := &loaderContext{
-		Dir: cl.dir,
-	}
 
 	_, err := cl.LoadGlobalConfig()
 	if err != nil {
@@ -83,16 +140,40 @@ func (cl *Loader) Load(file string) (*Config, error) {
 		file = path.Join(cl.dir, file)
 	}
 
-	raw, err := cl.load(file)
+	// the lock file pins the remote imports of the project config only
+	cl.remotes = nil
+	if !isURL(file) && !cl.remote.Refresh {
+		if cl.lock, err = ReadLock(LockPath(file)); err != nil {
+			return nil, err
+		}
+	}
+
+	raw, err := cl.load(file, "", importDefinition{})
 	if err != nil {
 		return nil, err
 	}
 
+	profiles, err := takeProfiles(raw)
+	if err != nil {
+		return nil, err
+	}
+	var profile *profileDefinition
+	if cl.profile != "" {
+		if profile, err = cl.applyProfile(raw, profiles, cl.profile); err != nil {
+			return nil, err
+		}
+	}
+
 	def, err := cl.decode(raw)
 	if err != nil {
 		return nil, err
 	}
 
+	lc := &loaderContext{
+		Dir:   cl.dir,
+		File:  file,
+		Files: cl.defined,
+	}
 	localCfg, err := buildFromDefinition(def, lc)
 	if err != nil {
 		return nil, err
@@ -102,6 +183,16 @@ func (cl *Loader) Load(file string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	cl.dst.File = file
+	cl.dst.Profiles = slices.Sorted(maps.Keys(profiles))
+	if profile != nil {
+		env, err := parseValues("env", profile.Env)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %w", cl.profile, err)
+		}
+		cl.dst.Env = cl.dst.Env.Merge(env)
+		cl.dst.Profile = cl.profile
+	}
 	cl.dst.Variables.Set("Root", cl.dir)
 	cl.dst.Variables.Set("Dir", cl.dir)
 
@@ -120,17 +211,24 @@ func (cl *Loader) LoadGlobalConfig() (*Config, error) {
 		return cl.dst, nil
 	}
 
-	raw, err := cl.load(file)
+	raw, err := cl.load(file, "", importDefinition{})
 	if err != nil {
 		return nil, err
 	}
+	lc := &loaderContext{
+		File:  file,
+		Files: maps.Clone(cl.defined),
+	}
+	// the project config may redefine the global config's tasks, pipelines,
+	// contexts and watchers
+	clear(cl.defined)
 
 	def, err := cl.decode(raw)
 	if err != nil {
 		return nil, err
 	}
 
-	cfg, err := buildFromDefinition(def, &loaderContext{})
+	cfg, err := buildFromDefinition(def, lc)
 	if err != nil {
 		return nil, err
 	}
@@ -145,54 +243,101 @@ func (cl *Loader) LoadGlobalConfig() (*Config, error) {
 
 func (cl *Loader) reset() {
 	cl.imports = make(map[string]bool)
+	cl.loading = make(map[string]bool)
+	cl.defined = make(map[string]string)
+	cl.remotes = nil
+	cl.lock = Lock{}
+	cl.dst.origins = provenance{}
 }
 
-func (cl *Loader) load(file string) (config map[string]any, err error) {
-	cl.imports[file] = true
+// load reads file, imported by imp, and the files it imports, merged into one
+// raw config, with the names it defines prefixed by ns, the namespace file is
+// imported under. A missing optional file loads as an empty config.
+func (cl *Loader) load(file, ns string, imp importDefinition) (config map[string]any, err error) {
+	cl.imports[ns+"\x00"+file] = true
+	cl.loading[file] = true
+	defer delete(cl.loading, file)
 
 	if isURL(file) {
-		config, err = cl.readURL(file)
+		config, err = cl.readRemote(file, imp)
 	} else {
 		if !fsutil.FileExists(file) {
-			return config, fmt.Errorf("%s: %w", file, ErrConfigNotFound)
+			err = fmt.Errorf("%s: %w", file, ErrConfigNotFound)
+		} else {
+			config, err = cl.readFile(file)
 		}
-		config, err = cl.readFile(file)
+	}
+	if imp.Optional && errors.Is(err, ErrConfigNotFound) {
+		slog.Debug(fmt.Sprintf("optional import %s not found", file))
+		return make(map[string]any), nil
 	}
 	if err != nil {
 		return nil, err
 	}
+	dir := cl.dir
+	if !isURL(file) {
+		dir = filepath.Dir(file)
+	}
+	cl.dst.origins.record(file, config, dir, ns)
+	if err := cl.define(file, ns, config); err != nil {
+		return nil, err
+	}
 
 	var raw map[string]any
 	importDir := path.Dir(file)
 	if imports, ok := config["import"]; ok && imports != nil {
-		for _, v := range imports.([]any) {
-			if isURL(v.(string)) {
-				if cl.imports[v.(string)] {
-					continue
-				}
-				raw, err = cl.load(v.(string))
+		entries, ok := imports.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: import must be a list, got %v", file, imports)
+		}
+		for _, v := range entries {
+			imp, err := parseImport(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s: import %v: %w", file, v, err)
+			}
+			imp.expandHeaders = !isURL(file)
+
+			importNS := ns
+			if imp.As != "" {
+				importNS += imp.As + ":"
+			}
+			importFile := imp.Path
+			if !isURL(importFile) {
+				importFile = path.Join(importDir, importFile)
+			}
+			// an import cycle and a file already imported under the same
+			// namespace are skipped
+			if cl.imports[importNS+"\x00"+importFile] || cl.loading[importFile] {
+				continue
+			}
+
+			if isURL(importFile) {
+				raw, err = cl.load(importFile, importNS, imp)
 			} else {
-				importFile := path.Join(importDir, v.(string))
-				if cl.imports[importFile] {
+				var fi os.FileInfo
+				fi, err = os.Stat(importFile)
+				if imp.Optional && errors.Is(err, fs.ErrNotExist) {
+					slog.Debug(fmt.Sprintf("optional import %s not found", importFile))
 					continue
 				}
-				fi, err := os.Stat(importFile)
 				if err != nil {
 					return nil, fmt.Errorf("%s: %w", importFile, err)
 				}
 				if !fi.IsDir() {
-					raw, err = cl.load(importFile)
+					raw, err = cl.load(importFile, importNS, imp)
 				} else {
-					raw, err = cl.loadDir(importFile)
-				}
-				if err != nil {
-					slog.Error(err.Error())
+					raw, err = cl.loadDir(importFile, importNS)
 				}
 			}
 			if err != nil {
 				return nil, fmt.Errorf("load import error: %w", err)
 			}
 
+			if imp.As != "" {
+				namespace(raw, imp.As+":")
+			}
+			cl.setImportVariables(file, ns, raw, imp.Variables)
+
 			err = mergo.Merge(&config, raw, mergo.WithOverride, mergo.WithAppendSlice, mergo.WithTypeCheck)
 			if err != nil {
 				return nil, err
@@ -203,7 +348,7 @@ func (cl *Loader) load(file string) (config map[string]any, err error) {
 	return config, nil
 }
 
-func (cl *Loader) loadDir(dir string) (map[string]any, error) {
+func (cl *Loader) loadDir(dir, ns string) (map[string]any, error) {
 	pattern := filepath.Join(dir, "*.yaml")
 	q, err := filepath.Glob(pattern)
 	if err != nil {
@@ -212,11 +357,11 @@ func (cl *Loader) loadDir(dir string) (map[string]any, error) {
 
 	cm := make(map[string]any)
 	for _, importFile := range q {
-		if cl.imports[importFile] {
+		if cl.imports[ns+"\x00"+importFile] || cl.loading[importFile] {
 			continue
 		}
 
-		cml, err := cl.load(importFile)
+		cml, err := cl.load(importFile, ns, importDefinition{})
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", importFile, err)
 		}
@@ -230,50 +375,6 @@ func (cl *Loader) loadDir(dir string) (map[string]any, error) {
 	return cm, nil
 }
 
-func (cl *Loader) readURL(u string) (map[string]any, error) {
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, u, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := http.DefaultClient.Do(req) //nolint:bodyclose // body is closed via iox.Close on the next line
-	if err != nil {
-		return nil, err
-	}
-	defer iox.Close(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%d: config request failed - %s", resp.StatusCode, u)
-	}
-
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", u, err)
-	}
-
-	var ext string
-	ct := resp.Header.Get("Content-Type")
-	if ct != "" {
-		mediaType, _, _ := mime.ParseMediaType(ct)
-		if mediaType == "application/json" {
-			ext = ".json"
-		}
-	}
-
-	if ext == "" {
-		up, err := url.Parse(u)
-		if err == nil {
-			ext = filepath.Ext(up.Path)
-		}
-	}
-
-	if ext == "" {
-		ext = ".yaml"
-	}
-
-	return cl.unmarshalData(data, ext)
-}
-
 func (cl *Loader) readFile(filename string) (map[string]any, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
@@ -316,6 +417,7 @@ func (cl *Loader) decode(cm map[string]any) (*configDefinition, error) {
 	md, _ := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
 		DecodeHook: mapstructure.ComposeDecodeHookFunc(
 			mapstructure.StringToTimeDurationHookFunc(),
+			pipelineDecodeHook,
 		),
 		ErrorUnused:      true,
 		WeaklyTypedInput: true,
@@ -331,6 +433,16 @@ func (cl *Loader) decode(cm map[string]any) (*configDefinition, error) {
 	return c, nil
 }
 
+// pipelineDecodeHook decodes a pipeline given as the list of its stages like
+// one given as an object with its stages under stages.
+func pipelineDecodeHook(from, to reflect.Type, data any) (any, error) {
+	if to == reflect.TypeFor[pipelineDefinition]() && from.Kind() == reflect.Slice {
+		return map[string]any{"stages": data}, nil
+	}
+
+	return data, nil
+}
+
 func (cl *Loader) resolveDefaultConfigFile() (file string, err error) {
 	dir := cl.dir
 	for dir != filepath.Dir(dir) {
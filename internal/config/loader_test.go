@@ -6,7 +6,9 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 const sampleCfg = "{\"tasks\": {\"task1\": {\"command\": [\"true\"]}}}"
@@ -83,7 +85,7 @@ func TestLoader_loadDir(t *testing.T) {
 	}
 
 	cl := NewConfigLoader(NewConfig())
-	m, err := cl.loadDir(filepath.Join(cwd, "testdata"))
+	m, err := cl.loadDir(filepath.Join(cwd, "testdata"), "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -94,7 +96,7 @@ func TestLoader_loadDir(t *testing.T) {
 	}
 }
 
-func TestLoader_readURL(t *testing.T) {
+func TestLoader_readRemote(t *testing.T) {
 	var r int
 	srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 		writer.Header().Set("Content-Type", "")
@@ -107,9 +109,11 @@ func TestLoader_readURL(t *testing.T) {
 		_, _ = fmt.Fprintln(writer, sampleCfg)
 		r++
 	}))
+	defer srv.Close()
 
 	cl := NewConfigLoader(NewConfig())
-	m, err := cl.readURL(srv.URL)
+	cl.SetRemoteOptions(RemoteOptions{CacheDir: t.TempDir()})
+	m, err := cl.readRemote(srv.URL, importDefinition{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -119,14 +123,133 @@ func TestLoader_readURL(t *testing.T) {
 		t.Error()
 	}
 
-	_, err = cl.readURL(srv.URL)
-	if err != nil {
-		t.Fatal()
+	if _, err := cl.readRemote(srv.URL, importDefinition{}); err != nil || r != 1 {
+		t.Fatalf("a fresh cached copy must be used, err %v, %d requests", err, r)
 	}
 
-	_, err = cl.readURL(srv.URL)
-	if err == nil {
-		t.Fatal()
+	cl.remote.Refresh = true
+	if _, err := cl.readRemote(srv.URL, importDefinition{}); err != nil || r != 2 {
+		t.Fatalf("refresh must fetch, err %v, %d requests", err, r)
+	}
+	if _, err := cl.readRemote(srv.URL, importDefinition{}); err == nil {
+		t.Fatal("a failed refresh must fail")
+	}
+
+	cl.remote.Refresh = false
+	if _, err := cl.readRemote(srv.URL, importDefinition{TTL: time.Nanosecond}); err != nil || r != 4 {
+		t.Fatalf("a stale copy must be fetched again, err %v, %d requests", err, r)
+	}
+
+	sum := cl.Remotes()[0].SHA256
+	cl.remote.Offline = true
+	if _, err := cl.readRemote(srv.URL, importDefinition{TTL: time.Nanosecond, SHA256: "sha256:" + sum}); err != nil || r != 4 {
+		t.Fatalf("offline must use the cached copy, err %v, %d requests", err, r)
+	}
+	if _, err := cl.readRemote(srv.URL+"/other.yaml", importDefinition{}); err == nil {
+		t.Error("offline must fail for an import that isn't cached")
+	}
+	if _, err := cl.readRemote(srv.URL, importDefinition{SHA256: strings.Repeat("0", 64)}); err == nil {
+		t.Error("content not matching the pinned sha256 must fail")
+	}
+	cl.lock = Lock{Imports: map[string]LockedImport{srv.URL: {SHA256: strings.Repeat("0", 64)}}}
+	if _, err := cl.readRemote(srv.URL, importDefinition{}); err == nil || !strings.Contains(err.Error(), LockFileName) {
+		t.Errorf("content not matching the lock file must fail, got %v", err)
+	}
+}
+
+func TestLoader_readRemoteStale(t *testing.T) {
+	down := false
+	srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if down {
+			writer.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = fmt.Fprintln(writer, sampleCfg)
+	}))
+	defer srv.Close()
+
+	cl := NewConfigLoader(NewConfig())
+	cl.SetRemoteOptions(RemoteOptions{CacheDir: t.TempDir()})
+	if _, err := cl.readRemote(srv.URL, importDefinition{}); err != nil {
+		t.Fatal(err)
+	}
+
+	down = true
+	if _, err := cl.readRemote(srv.URL, importDefinition{TTL: time.Nanosecond}); err == nil || !strings.Contains(err.Error(), "run with --offline") {
+		t.Errorf("a failed fetch must not fall back to a stale copy, got %v", err)
+	}
+	if _, err := cl.readRemote(srv.URL, importDefinition{}); err != nil {
+		t.Errorf("a fresh copy must still be used, got %v", err)
+	}
+}
+
+func TestLoader_readRemoteHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Header.Get("Authorization") != "Bearer secret" {
+			writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprintln(writer, sampleCfg)
+	}))
+	defer srv.Close()
+
+	cl := NewConfigLoader(NewConfig())
+	cl.SetRemoteOptions(RemoteOptions{CacheDir: t.TempDir()})
+	imp := importDefinition{Headers: map[string]string{"Authorization": "Bearer ${TASKCTL_TEST_TOKEN}"}, expandHeaders: true}
+	if _, err := cl.readRemote(srv.URL, imp); err == nil || !strings.Contains(err.Error(), "TASKCTL_TEST_TOKEN is not set") {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	t.Setenv("TASKCTL_TEST_TOKEN", "secret")
+	if _, err := cl.readRemote(srv.URL, imp); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoader_LoadRemoteHeaders(t *testing.T) {
+	t.Setenv("TASKCTL_TEST_TOKEN", "secret")
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/same.yaml":
+			http.Redirect(writer, request, "/tasks.yaml", http.StatusFound)
+		case "/nested.yaml":
+			_, _ = fmt.Fprintf(writer, "import:\n  - {path: %q, headers: {X-Token: $TASKCTL_TEST_TOKEN}}\n", srv.URL+"/tasks.yaml")
+		case "/tasks.yaml":
+			if request.Header.Get("X-Token") != "secret" {
+				writer.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = fmt.Fprintln(writer, sampleCfg)
+		}
+	}))
+	defer srv.Close()
+	other := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		http.Redirect(writer, request, srv.URL+"/tasks.yaml", http.StatusFound)
+	}))
+	defer other.Close()
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr string
+	}{
+		{name: "same host redirect", url: srv.URL + "/same.yaml"},
+		{name: "cross host redirect", url: other.URL + "/tasks.yaml", wantErr: "401"},
+		{name: "import of a remote config", url: srv.URL + "/nested.yaml", wantErr: "$TASKCTL_TEST_TOKEN is only expanded in the imports of a local config"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(writeConfigFiles(t, map[string]string{
+				"tasks.yaml": fmt.Sprintf("import:\n  - {path: %q, headers: {X-Token: $TASKCTL_TEST_TOKEN}}\n", tt.url),
+			}), "tasks.yaml")
+			cl := NewConfigLoader(NewConfig())
+			cl.SetRemoteOptions(RemoteOptions{CacheDir: t.TempDir()})
+			_, err := cl.Load(file)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
 	}
 }
 
@@ -167,3 +290,75 @@ func TestLoader_unmarshalData(t *testing.T) {
 		t.Error()
 	}
 }
+
+func TestLoader_Timeouts(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tasks.yaml")
+	data := `
+tasks:
+  test:
+    command: [go test ./...]
+    timeout: 8m
+    command_timeout: 2m
+pipelines:
+  list:
+    - task: test
+      timeout: 10m
+  object:
+    timeout: 30m
+    stages:
+      - task: test
+`
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cl := NewConfigLoader(NewConfig())
+	cfg, err := cl.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tsk := cfg.Tasks["test"]
+	if tsk.Timeout == nil || *tsk.Timeout != 8*time.Minute {
+		t.Errorf("task timeout = %v, want 8m", tsk.Timeout)
+	}
+	if tsk.CommandTimeout == nil || *tsk.CommandTimeout != 2*time.Minute {
+		t.Errorf("command timeout = %v, want 2m", tsk.CommandTimeout)
+	}
+
+	if stage, err := cfg.Pipelines["list"].Node("test"); err != nil || stage.Timeout != 10*time.Minute {
+		t.Errorf("stage timeout = %v (%v), want 10m", stage, err)
+	}
+	if got := cfg.Pipelines["list"].Timeout; got != 0 {
+		t.Errorf("list pipeline timeout = %s, want none", got)
+	}
+	if got := cfg.Pipelines["object"].Timeout; got != 30*time.Minute {
+		t.Errorf("object pipeline timeout = %s, want 30m", got)
+	}
+	if _, err := cfg.Pipelines["object"].Node("test"); err != nil {
+		t.Errorf("object pipeline stages: %v", err)
+	}
+}
+
+func TestLoader_LoadGlobalVariables(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		filepath.Join("home", ".taskctl", "config.yaml"): "variables: {Registry: global.example.com, Owner: global}\n",
+		"tasks.yaml": "variables: {Registry: project.example.com}\n",
+	})
+
+	cl := NewConfigLoader(NewConfig())
+	cl.homeDir = filepath.Join(dir, "home")
+	cfg, err := cl.Load(filepath.Join(dir, "tasks.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Variables.Get("Registry"); got != "project.example.com" {
+		t.Errorf("the project config must override a global variable, got %v", got)
+	}
+	if got := cfg.Variables.Get("Owner"); got != "global" {
+		t.Errorf("a global variable the project doesn't define must be kept, got %v", got)
+	}
+	if !cfg.Variables.Has("TempDir") {
+		t.Error("the default variables must be kept")
+	}
+}
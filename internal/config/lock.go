@@ -0,0 +1,70 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LockFileName is the file, next to the config file, pinning the content of
+// its remote imports.
+const LockFileName = "taskctl.lock"
+
+// Lock pins remote imports by URL to the content they had when taskctl
+// imports update wrote it.
+type Lock struct {
+	Imports map[string]LockedImport `yaml:"imports"`
+}
+
+// LockedImport is the pinned content of a remote import and, for a git
+// import, the commit its ref resolved to.
+type LockedImport struct {
+	SHA256 string `yaml:"sha256"`
+	Commit string `yaml:"commit,omitempty"`
+}
+
+// LockPath returns the lock file of configFile.
+func LockPath(configFile string) string {
+	return filepath.Join(filepath.Dir(configFile), LockFileName)
+}
+
+// ReadLock reads the lock file name; a missing one is an empty lock.
+func ReadLock(name string) (Lock, error) {
+	lock := Lock{Imports: make(map[string]LockedImport)}
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return lock, nil
+	}
+	if err != nil {
+		return lock, err
+	}
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return lock, fmt.Errorf("%s: %w", name, err)
+	}
+	if lock.Imports == nil {
+		lock.Imports = make(map[string]LockedImport)
+	}
+
+	return lock, nil
+}
+
+// Write writes the lock to the file name.
+func (l Lock) Write(name string) error {
+	var buf bytes.Buffer
+	buf.WriteString("# Written by taskctl imports update. Do not edit.\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(l); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	return os.WriteFile(name, buf.Bytes(), 0o644)
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLock_Write(t *testing.T) {
+	file := filepath.Join(t.TempDir(), LockFileName)
+	lock, err := ReadLock(file)
+	if err != nil || len(lock.Imports) != 0 {
+		t.Fatalf("a missing lock file must read as empty, got %v, %v", lock, err)
+	}
+
+	lock.Imports["https://example.com/tasks.yaml"] = LockedImport{SHA256: "abc"}
+	if err := lock.Write(file); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadLock(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, lock) {
+		t.Errorf("ReadLock() = %v, want %v", got, lock)
+	}
+}
+
+func TestLoader_LoadLockedImport(t *testing.T) {
+	content := sampleCfg
+	srv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintln(writer, content)
+	}))
+	defer srv.Close()
+
+	dir := writeConfigFiles(t, map[string]string{
+		"tasks.yaml": "import:\n  - {path: " + srv.URL + "/tasks.json, ttl: 1ns}\n",
+	})
+	file := filepath.Join(dir, "tasks.yaml")
+	load := func() (*Loader, error) {
+		cl := NewConfigLoader(NewConfig())
+		cl.SetRemoteOptions(RemoteOptions{CacheDir: filepath.Join(dir, "cache")})
+		_, err := cl.Load(file)
+		return &cl, err
+	}
+
+	cl, err := load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	remotes := cl.Remotes()
+	if len(remotes) != 1 || remotes[0].URL != srv.URL+"/tasks.json" {
+		t.Fatalf("unexpected remotes %v", remotes)
+	}
+	lock := Lock{Imports: map[string]LockedImport{remotes[0].URL: {SHA256: remotes[0].SHA256}}}
+	if err := lock.Write(LockPath(file)); err != nil {
+		t.Fatal(err)
+	}
+
+	content = strings.Replace(sampleCfg, "true", "false", 1)
+	if _, err := load(); err != nil {
+		t.Errorf("the cached copy matching the lock must be used, got %v", err)
+	}
+	if err := os.RemoveAll(filepath.Join(dir, "cache")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := load(); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("content not matching the lock must fail, got %v", err)
+	}
+}
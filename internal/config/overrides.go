@@ -0,0 +1,202 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/taskctl/taskctl/internal/envutil"
+	"github.com/taskctl/taskctl/scheduler"
+	"github.com/taskctl/taskctl/variables"
+)
+
+// Override is a variable or env value given on the command line, replacing
+// the one the config declares.
+type Override struct {
+	// Flag is the flag that gave the value: --set, --env or --var-file.
+	Flag string
+	// File is the file a --var-file value was read from.
+	File string
+	// Scope is the task, stage or pipeline/stage the value is for; empty
+	// for a global value.
+	Scope string
+	Key   string
+	Value any
+
+	task   string
+	stages []*scheduler.Stage
+}
+
+// Env reports whether o is an env value rather than a variable.
+func (o Override) Env() bool {
+	return o.Flag == "--env"
+}
+
+// AppliesToTask reports whether o was set on the task itself.
+func (o Override) AppliesToTask(name string) bool {
+	return o.task != "" && o.task == name
+}
+
+// AppliesToStage reports whether o was set on stage.
+func (o Override) AppliesToStage(stage *scheduler.Stage) bool {
+	return slices.Contains(o.stages, stage)
+}
+
+// ParseOverride reads a value given to flag, --set or --env, as KEY=value
+// or SCOPE.KEY=value. A --set value may be a JSON list or object (see
+// ParseVariable).
+func ParseOverride(flag, s string) (Override, error) {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || k == "" {
+		return Override{}, fmt.Errorf("%s %s: expected KEY=value or SCOPE.KEY=value", flag, s)
+	}
+
+	o := Override{Flag: flag, Key: k, Value: v}
+	if i := strings.LastIndex(k, "."); i >= 0 {
+		o.Scope, o.Key = k[:i], k[i+1:]
+		if o.Scope == "" || o.Key == "" {
+			return Override{}, fmt.Errorf("%s %s: expected KEY=value or SCOPE.KEY=value", flag, s)
+		}
+	}
+	if flag == "--set" {
+		o.Value = ParseVariable(v)
+	}
+
+	return o, nil
+}
+
+// SplitSet splits a --set value holding several, KEY=value,KEY=value, as the
+// flag did when it was a comma-separated list: only when the value of the
+// first isn't a JSON list or object and every piece is itself KEY=value or
+// SCOPE.KEY=value. Any other value is one, commas included.
+func SplitSet(s string) []string {
+	k, v, _ := strings.Cut(s, "=")
+	if !strings.Contains(v, ",") || !isOverrideKey(k) {
+		return []string{s}
+	}
+	if _, ok := ParseVariable(v).(string); !ok {
+		return []string{s}
+	}
+
+	pieces := strings.Split(s, ",")
+	for _, piece := range pieces {
+		if k, _, ok := strings.Cut(piece, "="); !ok || !isOverrideKey(k) {
+			return []string{s}
+		}
+	}
+
+	return pieces
+}
+
+// isOverrideKey reports whether k may be the KEY or SCOPE.KEY of a value given
+// on the command line: names of variables, tasks and stages, with their
+// namespace and pipeline.
+func isOverrideKey(k string) bool {
+	return k != "" && !strings.ContainsFunc(k, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("_-.:/", r)
+	})
+}
+
+// Override applies o: a global value is a variable of every task or, for
+// --env, the env every context's env overrides; a scoped one replaces the
+// value the task, or every stage, o.Scope names declares. A scope naming a
+// task applies to it wherever it runs; a stage is named as pipeline/stage,
+// or by its name alone for the stages so named in every pipeline.
+func (cfg *Config) Override(o Override) error {
+	container := func(c *variables.Container) variables.Container {
+		if *c == nil {
+			*c = variables.NewVariables()
+		}
+		return *c
+	}
+
+	if o.Scope == "" {
+		if o.Env() {
+			container(&cfg.Env).Set(o.Key, o.Value)
+		} else {
+			container(&cfg.Variables).Set(o.Key, o.Value)
+		}
+		cfg.Overrides = append(cfg.Overrides, o)
+		return nil
+	}
+
+	if t := cfg.Tasks[o.Scope]; t != nil {
+		if o.Env() {
+			container(&t.Env).Set(o.Key, o.Value)
+		} else {
+			container(&t.Variables).Set(o.Key, o.Value)
+		}
+		o.task = o.Scope
+		cfg.Overrides = append(cfg.Overrides, o)
+		return nil
+	}
+
+	pipeline, stageName, qualified := "", o.Scope, false
+	if i := strings.LastIndex(o.Scope, "/"); i >= 0 {
+		pipeline, stageName, qualified = o.Scope[:i], o.Scope[i+1:], true
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Pipelines)) {
+		if qualified && name != pipeline {
+			continue
+		}
+		stage := cfg.Pipelines[name].Nodes()[stageName]
+		if stage == nil || slices.Contains(o.stages, stage) {
+			continue
+		}
+		if o.Env() {
+			container(&stage.Env).Set(o.Key, o.Value)
+		} else {
+			container(&stage.Variables).Set(o.Key, o.Value)
+		}
+		o.stages = append(o.stages, stage)
+	}
+	if len(o.stages) == 0 {
+		return fmt.Errorf("%s %s.%s: no task or stage %s", o.Flag, o.Scope, o.Key, o.Scope)
+	}
+	cfg.Overrides = append(cfg.Overrides, o)
+
+	return nil
+}
+
+// LoadVarFile sets the variables of a --var-file as global variables: a
+// YAML, JSON or TOML map, by the file's extension, or a dotenv file. Its
+// values are read like the config's variables, so they may be lists, maps
+// or computed.
+func (cl *Loader) LoadVarFile(name string) error {
+	var values map[string]any
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json", ".toml":
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if values, err = cl.unmarshalData(data, filepath.Ext(name)); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	default:
+		env, err := envutil.ReadEnvFile(name)
+		if err != nil {
+			return err
+		}
+		values = make(map[string]any, len(env))
+		for k, v := range env {
+			values[k] = v
+		}
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(values)) {
+		v, err := parseValue("variable", values[k])
+		if err != nil {
+			return fmt.Errorf("%s: variable %s: %w", name, k, err)
+		}
+		if err := cl.dst.Override(Override{Flag: "--var-file", File: name, Key: k, Value: v}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
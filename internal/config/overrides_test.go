@@ -0,0 +1,203 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/taskctl/taskctl/variables"
+)
+
+func TestParseOverride(t *testing.T) {
+	tests := []struct {
+		flag, in string
+		want     Override
+	}{
+		{"--set", "Version=1.0", Override{Flag: "--set", Key: "Version", Value: "1.0"}},
+		{"--set", "build.Packages=[\"api\"]", Override{Flag: "--set", Scope: "build", Key: "Packages", Value: []any{"api"}}},
+		{"--set", "release/build.URL=http://x?a=b", Override{Flag: "--set", Scope: "release/build", Key: "URL", Value: "http://x?a=b"}},
+		{"--env", "lint.v1.GOFLAGS=[]", Override{Flag: "--env", Scope: "lint.v1", Key: "GOFLAGS", Value: "[]"}},
+		{"--env", "EMPTY=", Override{Flag: "--env", Key: "EMPTY", Value: ""}},
+	}
+	for _, tt := range tests {
+		got, err := ParseOverride(tt.flag, tt.in)
+		if err != nil {
+			t.Errorf("ParseOverride(%q) error = %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseOverride(%q) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"Version", "=1", ".Version=1", "build.=1"} {
+		if _, err := ParseOverride("--set", in); err == nil {
+			t.Errorf("ParseOverride(%q) expected an error", in)
+		}
+	}
+}
+
+func TestSplitSet(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"A=1,B=2", []string{"A=1", "B=2"}},
+		{"build.A=1,release/build.B=", []string{"build.A=1", "release/build.B="}},
+		{"A=1", []string{"A=1"}},
+		{"Msg=hello, world", []string{"Msg=hello, world"}},
+		{"Msg=a,b c=d", []string{"Msg=a,b c=d"}},
+		{"Msg=x, y=z", []string{"Msg=x, y=z"}},
+		{`Ports={"api":1,"web":2}`, []string{`Ports={"api":1,"web":2}`}},
+		{`Env={"a":"x=1","b":"y=2"}`, []string{`Env={"a":"x=1","b":"y=2"}`}},
+	}
+	for _, tt := range tests {
+		if got := SplitSet(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitSet(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func loadOverridesConfig(t *testing.T) (*Config, Loader) {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "tasks.yaml")
+	data := `
+variables:
+  Channel: stable
+tasks:
+  build:
+    command: [go build]
+    variables:
+      Channel: nightly
+pipelines:
+  release:
+    - task: build
+  nightly:
+    - task: build
+    - name: publish
+      task: build
+`
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := NewConfig()
+	cl := NewConfigLoader(cfg)
+	if _, err := cl.Load(file); err != nil {
+		t.Fatal(err)
+	}
+
+	return cfg, cl
+}
+
+func TestConfig_Override(t *testing.T) {
+	cfg, _ := loadOverridesConfig(t)
+
+	for _, s := range []string{"Channel=beta", "build.Channel=mine", "publish.Channel=rc"} {
+		o, err := ParseOverride("--set", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cfg.Override(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, s := range []string{"GOOS=linux", "nightly/build.CGO_ENABLED=0"} {
+		o, err := ParseOverride("--env", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cfg.Override(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := cfg.Variables.Get("Channel"); got != "beta" {
+		t.Errorf("global Channel = %v", got)
+	}
+	if got := cfg.Env.Get("GOOS"); got != "linux" {
+		t.Errorf("global GOOS = %v", got)
+	}
+	if got := cfg.Tasks["build"].Variables.Get("Channel"); got != "mine" {
+		t.Errorf("task Channel = %v", got)
+	}
+
+	publish, _ := cfg.Pipelines["nightly"].Node("publish")
+	if got := publish.Variables.Get("Channel"); got != "rc" {
+		t.Errorf("stage Channel = %v", got)
+	}
+	nightlyBuild, _ := cfg.Pipelines["nightly"].Node("build")
+	releaseBuild, _ := cfg.Pipelines["release"].Node("build")
+	if got := nightlyBuild.Env.Get("CGO_ENABLED"); got != "0" {
+		t.Errorf("nightly/build CGO_ENABLED = %v", got)
+	}
+	if releaseBuild.Env.Has("CGO_ENABLED") {
+		t.Error("a pipeline/stage scope must not apply to another pipeline's stage")
+	}
+
+	if len(cfg.Overrides) != 5 {
+		t.Fatalf("recorded %d overrides, want 5", len(cfg.Overrides))
+	}
+	if o := cfg.Overrides[1]; !o.AppliesToTask("build") || o.AppliesToStage(releaseBuild) {
+		t.Errorf("build.Channel applies to the task only: %#v", o)
+	}
+	if o := cfg.Overrides[2]; !o.AppliesToStage(publish) || o.AppliesToTask("build") {
+		t.Errorf("publish.Channel applies to the stage only: %#v", o)
+	}
+	if o := cfg.Overrides[4]; !o.Env() || !o.AppliesToStage(nightlyBuild) {
+		t.Errorf("nightly/build.CGO_ENABLED applies to the stage's env: %#v", o)
+	}
+
+	if err := cfg.Override(Override{Flag: "--set", Scope: "deploy", Key: "X", Value: "1"}); err == nil {
+		t.Error("a scope naming no task or stage must fail")
+	}
+}
+
+func TestLoader_LoadVarFile(t *testing.T) {
+	cfg, cl := loadOverridesConfig(t)
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"vars.toml": "Channel = \"edge\"\nPorts = { api = 8080 }\n",
+		"vars.json": `{"Packages": ["api", "web"], "Version": {"sh": "git describe"}}`,
+		"vars.env":  "Channel=from-dotenv\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"vars.toml", "vars.json"} {
+		if err := cl.LoadVarFile(filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := cfg.Variables.Get("Channel"); got != "edge" {
+		t.Errorf("Channel = %v", got)
+	}
+	if got := cfg.Variables.Get("Ports"); !reflect.DeepEqual(got, map[string]any{"api": int64(8080)}) {
+		t.Errorf("Ports = %#v", got)
+	}
+	if got := cfg.Variables.Get("Packages"); !reflect.DeepEqual(got, []any{"api", "web"}) {
+		t.Errorf("Packages = %#v", got)
+	}
+	if got := cfg.Variables.Get("Version"); got != (variables.Command{Script: "git describe"}) {
+		t.Errorf("Version = %#v", got)
+	}
+
+	if err := cl.LoadVarFile(filepath.Join(dir, "vars.env")); err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Variables.Get("Channel"); got != "from-dotenv" {
+		t.Errorf("a later file must win, Channel = %v", got)
+	}
+	if o := cfg.Overrides[len(cfg.Overrides)-1]; o.Flag != "--var-file" || o.File != filepath.Join(dir, "vars.env") {
+		t.Errorf("unexpected override %#v", o)
+	}
+
+	if err := cl.LoadVarFile(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("a missing file must fail")
+	}
+}
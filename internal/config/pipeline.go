@@ -2,7 +2,6 @@ package config
 
 import (
 	"fmt"
-	"path/filepath"
 
 	"github.com/taskctl/taskctl/internal/envutil"
 
@@ -22,7 +21,9 @@ type stageInfo struct {
 	DependsOn    []string
 }
 
-func buildPipeline(g *scheduler.ExecutionGraph, stages []*stageDefinition, cfg *Config) (*scheduler.ExecutionGraph, error) {
+// buildPipeline adds stages to g. Their relative dir and env_file paths are
+// resolved against configDir, the directory of the file defining them.
+func buildPipeline(g *scheduler.ExecutionGraph, stages []*stageDefinition, cfg *Config, configDir string) (*scheduler.ExecutionGraph, error) {
 	for _, def := range stages {
 		var stageTask *task.Task
 		var stagePipeline *scheduler.ExecutionGraph
@@ -39,19 +40,25 @@ func buildPipeline(g *scheduler.ExecutionGraph, stages []*stageDefinition, cfg *
 			}
 		}
 
-		dir := def.Dir
-		if def.Dir == "" && stageTask != nil {
-			dir = stageTask.Dir
+		dir := resolvePath(configDir, def.Dir)
+		envFileDir := dir
+		if def.Dir == "" {
+			envFileDir = configDir
+			if stageTask != nil {
+				dir = stageTask.Dir
+			}
 		}
 
-		envs := variables.FromMap(def.Env)
+		envs, err := parseValues("env", def.Env)
+		if err != nil {
+			return nil, fmt.Errorf("stage build failed: %w", err)
+		}
+		vars, err := parseValues("variable", def.Variables)
+		if err != nil {
+			return nil, fmt.Errorf("stage build failed: %w", err)
+		}
 		if def.EnvFile != "" {
-			filename := def.EnvFile
-			if !filepath.IsAbs(filename) && def.Dir != "" {
-				filename = filepath.Join(def.Dir, filename)
-			}
-
-			fileEnvs, err := envutil.ReadEnvFile(filename)
+			fileEnvs, err := envutil.ReadEnvFile(resolvePath(envFileDir, def.EnvFile))
 			if err != nil {
 				return nil, err
 			}
@@ -66,9 +73,10 @@ func buildPipeline(g *scheduler.ExecutionGraph, stages []*stageDefinition, cfg *
 			Pipeline:     stagePipeline,
 			DependsOn:    def.DependsOn,
 			Dir:          dir,
-			AllowFailure: def.AllowFailure,
+			SuccessCodes: def.SuccessCodes,
+			Timeout:      def.Timeout,
 			Env:          envs,
-			Variables:    variables.FromMap(def.Variables),
+			Variables:    vars,
 		}
 
 		if stage.Name == "" {
@@ -85,6 +93,16 @@ func buildPipeline(g *scheduler.ExecutionGraph, stages []*stageDefinition, cfg *
 			}
 		}
 
+		if err := checkExitCodes("success_codes", def.SuccessCodes); err != nil {
+			return nil, fmt.Errorf("stage %s: %w", stage.Name, err)
+		}
+
+		allow, exitCodes, err := parseAllowFailure(def.AllowFailure)
+		if err != nil {
+			return nil, fmt.Errorf("stage %s: %w", stage.Name, err)
+		}
+		stage.AllowFailure, stage.AllowFailureExitCodes = allow, exitCodes
+
 		stage.Variables.Set("Stage", stageInfo{
 			Name:         stage.Name,
 			Condition:    stage.Condition,
@@ -97,7 +115,7 @@ func buildPipeline(g *scheduler.ExecutionGraph, stages []*stageDefinition, cfg *
 			return nil, fmt.Errorf("stage with same name %s already exists", stage.Name)
 		}
 
-		err := g.AddStage(stage)
+		err = g.AddStage(stage)
 		if err != nil {
 			return nil, err
 		}
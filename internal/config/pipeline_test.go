@@ -53,7 +53,7 @@ func TestBuildPipeline_Cyclic(t *testing.T) {
 	}
 
 	g, _ := scheduler.NewExecutionGraph()
-	_, err = buildPipeline(g, stages, cfg)
+	_, err = buildPipeline(g, stages, cfg, "")
 	if err == nil || err.Error() != "cycle detected" {
 		t.Errorf("cycles detection failed")
 	}
@@ -92,7 +92,7 @@ func TestBuildPipeline_Error(t *testing.T) {
 	}
 
 	g, _ := scheduler.NewExecutionGraph()
-	_, err = buildPipeline(g, stages1, cfg)
+	_, err = buildPipeline(g, stages1, cfg, "")
 	if err == nil || !strings.Contains(err.Error(), "no such task") {
 		t.Error()
 	}
@@ -107,7 +107,7 @@ func TestBuildPipeline_Error(t *testing.T) {
 	}
 
 	g, _ = scheduler.NewExecutionGraph()
-	_, err = buildPipeline(g, stages2, cfg)
+	_, err = buildPipeline(g, stages2, cfg, "")
 	if err == nil || !strings.Contains(err.Error(), "no such pipeline") {
 		t.Error()
 	}
@@ -128,7 +128,7 @@ func TestBuildPipeline_Error(t *testing.T) {
 	}
 
 	g, _ = scheduler.NewExecutionGraph()
-	_, err = buildPipeline(g, stages3, cfg)
+	_, err = buildPipeline(g, stages3, cfg, "")
 	if err == nil || !strings.Contains(err.Error(), "stage with same name") {
 		t.Error()
 	}
@@ -160,7 +160,7 @@ func TestBuildPipeline_env_file(t *testing.T) {
 	}
 
 	g, _ := scheduler.NewExecutionGraph()
-	pipeline, err := buildPipeline(g, stages, cfg)
+	pipeline, err := buildPipeline(g, stages, cfg, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// profileDefinition is an entry of profiles:, overlaid on the config when
+// selected. Tasks and contexts are given like in the config and merged key by
+// key into the ones it defines, so a profile only states what it changes;
+// Env is the env of every task, under the env of contexts, tasks and stages.
+type profileDefinition struct {
+	Tasks     map[string]any
+	Contexts  map[string]any
+	Variables map[string]any
+	Env       map[string]any
+}
+
+// SetProfile selects the profile the next Load overlays; empty selects none.
+func (cl *Loader) SetProfile(name string) {
+	cl.profile = name
+}
+
+// takeProfiles removes profiles: from raw, the merged raw config, and
+// returns its entries.
+func takeProfiles(raw map[string]any) (map[string]*profileDefinition, error) {
+	var profiles map[string]*profileDefinition
+	v, ok := raw["profiles"]
+	delete(raw, "profiles")
+	if !ok || v == nil {
+		return profiles, nil
+	}
+
+	md, _ := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		ErrorUnused: true,
+		Result:      &profiles,
+	})
+	if err := md.Decode(v); err != nil {
+		return nil, fmt.Errorf("profiles: %w", err)
+	}
+
+	return profiles, nil
+}
+
+// applyProfile overlays the profile name of profiles on raw, failing when the
+// config declares no such profile or the profile changes a task or context the
+// config doesn't define.
+func (cl *Loader) applyProfile(raw map[string]any, profiles map[string]*profileDefinition, name string) (*profileDefinition, error) {
+	p, ok := profiles[name]
+	if !ok {
+		if len(profiles) == 0 {
+			return nil, fmt.Errorf("profile %s is not defined, the config declares no profiles", name)
+		}
+		return nil, fmt.Errorf("profile %s is not defined, want one of %s", name, strings.Join(slices.Sorted(maps.Keys(profiles)), ", "))
+	}
+	if p == nil {
+		p = &profileDefinition{}
+	}
+
+	tasks := asMap(raw["tasks"])
+	for _, task := range slices.Sorted(maps.Keys(p.Tasks)) {
+		if _, ok := tasks[task]; !ok {
+			return nil, fmt.Errorf("profile %s: task %s is not defined", name, task)
+		}
+	}
+	contexts := asMap(raw["contexts"])
+	for _, context := range slices.Sorted(maps.Keys(p.Contexts)) {
+		if _, ok := contexts[context]; !ok {
+			return nil, fmt.Errorf("profile %s: context %s is not defined", name, context)
+		}
+	}
+
+	overlay := map[string]any{}
+	for section, m := range map[string]map[string]any{"tasks": p.Tasks, "contexts": p.Contexts, "variables": p.Variables} {
+		if len(m) > 0 {
+			overlay[section] = m
+		}
+	}
+	cl.dst.origins.record("profile "+name, overlay, cl.dir, "")
+	cl.dst.origins.addMap("profile "+name, map[string]any(p.Env), "env")
+	overlayMap(raw, overlay)
+
+	return p, nil
+}
+
+// overlayMap sets the entries of src in dst, merging the maps both have key
+// by key; any other value of src, lists included, replaces dst's.
+func overlayMap(dst, src map[string]any) {
+	for k, v := range src {
+		if sm, ok := v.(map[string]any); ok {
+			if dm, ok := dst[k].(map[string]any); ok {
+				overlayMap(dm, sm)
+				continue
+			}
+			if dst[k] == nil {
+				dm := map[string]any{}
+				overlayMap(dm, sm)
+				dst[k] = dm
+				continue
+			}
+		}
+		dst[k] = v
+	}
+}
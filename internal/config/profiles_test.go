@@ -0,0 +1,159 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const profilesCfg = `
+contexts:
+  local:
+    env: {TARGET: local}
+tasks:
+  build:
+    context: local
+    command: [make build]
+    env: {GOOS: linux, CGO_ENABLED: "0"}
+  test:
+    command: [go test ./...]
+variables:
+  Version: dev
+profiles:
+  ci:
+    contexts:
+      local:
+        env: {TARGET: ci}
+    tasks:
+      build:
+        command: [make build-ci]
+        env: {GOOS: windows}
+        parallel: 4
+    variables:
+      Version: "1.0"
+    env:
+      CI: "true"
+  release: {}
+`
+
+func TestLoader_LoadProfile(t *testing.T) {
+	file := filepath.Join(writeConfigFiles(t, map[string]string{"tasks.yaml": profilesCfg}), "tasks.yaml")
+
+	cl := NewConfigLoader(NewConfig())
+	cfg, err := cl.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg.Profiles, []string{"ci", "release"}) || cfg.Profile != "" {
+		t.Errorf("Profiles = %v, Profile = %q", cfg.Profiles, cfg.Profile)
+	}
+	if got := cfg.Tasks["build"].Commands; !reflect.DeepEqual(got, []string{"make build"}) {
+		t.Errorf("without a profile, build runs %v", got)
+	}
+
+	cl = NewConfigLoader(NewConfig())
+	cl.SetProfile("ci")
+	cfg, err = cl.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	build := cfg.Tasks["build"]
+	if cfg.Profile != "ci" || !reflect.DeepEqual(build.Commands, []string{"make build-ci"}) || build.ParallelLimit != 4 {
+		t.Errorf("profile ci: Profile = %q, build runs %v with parallel %d", cfg.Profile, build.Commands, build.ParallelLimit)
+	}
+	if got := build.Env.Map(); got["GOOS"] != "windows" || got["CGO_ENABLED"] != "0" {
+		t.Errorf("profile ci must merge build's env, got %v", got)
+	}
+	if got := cfg.Contexts["local"].Env.Get("TARGET"); got != "ci" {
+		t.Errorf("profile ci must overlay the context local, got TARGET=%v", got)
+	}
+	if got := cfg.Variables.Get("Version"); got != "1.0" {
+		t.Errorf("Version = %v", got)
+	}
+	if got := cfg.Env.Get("CI"); got != "true" {
+		t.Errorf("env CI = %v", got)
+	}
+	if got := cfg.Origins("env", "CI"); len(got) != 1 || got[0].File != "profile ci" {
+		t.Errorf("Origins(env, CI) = %v", got)
+	}
+	if got := cfg.Tasks["test"].Commands; !reflect.DeepEqual(got, []string{"go test ./..."}) {
+		t.Errorf("profile ci must leave test as is, got %v", got)
+	}
+}
+
+func TestLoader_LoadProfileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		profile string
+		wantErr string
+	}{
+		{name: "unknown profile", config: profilesCfg, profile: "staging", wantErr: "profile staging is not defined, want one of ci, release"},
+		{name: "no profiles", config: "tasks: {build: {command: [make]}}", profile: "ci", wantErr: "the config declares no profiles"},
+		{name: "undefined task", config: "tasks: {build: {command: [make]}}\nprofiles: {ci: {tasks: {biuld: {command: [make ci]}}}}", profile: "ci", wantErr: "profile ci: task biuld is not defined"},
+		{name: "undefined context", config: "contexts: {docker: {}}\nprofiles: {ci: {contexts: {dokcer: {env: {CI: \"1\"}}}}}", profile: "ci", wantErr: "profile ci: context dokcer is not defined"},
+		{name: "unknown key", config: "profiles: {ci: {pipelines: {}}}", wantErr: "profiles"},
+		{name: "invalid env", config: "profiles: {ci: {env: {CI: [1]}}}", profile: "ci", wantErr: "profile ci: env CI"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(writeConfigFiles(t, map[string]string{"tasks.yaml": tt.config}), "tasks.yaml")
+			cl := NewConfigLoader(NewConfig())
+			cl.SetProfile(tt.profile)
+			if _, err := cl.Load(file); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoader_LoadImportedProfile(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"tasks.yaml": "import:\n  - {path: backend.yaml, as: backend}\ntasks:\n  build:\n    command: [make]\n",
+		"backend.yaml": `
+contexts:
+  local:
+    env: {TARGET: local}
+tasks:
+  build:
+    context: local
+    command: [go build]
+profiles:
+  ci:
+    tasks:
+      build: {command: [go build -race], context: local}
+    contexts:
+      local: {env: {TARGET: ci}}
+`,
+	})
+
+	cl := NewConfigLoader(NewConfig())
+	cl.SetProfile("backend:ci")
+	cfg, err := cl.Load(filepath.Join(dir, "tasks.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg.Profiles, []string{"backend:ci"}) {
+		t.Errorf("Profiles = %v", cfg.Profiles)
+	}
+	build := cfg.Tasks["backend:build"]
+	if !reflect.DeepEqual(build.Commands, []string{"go build -race"}) || build.Context != "backend:local" {
+		t.Errorf("backend:build runs %v in %s", build.Commands, build.Context)
+	}
+	if got := cfg.Tasks["build"].Commands; !reflect.DeepEqual(got, []string{"make"}) {
+		t.Errorf("the profile must leave the root's build as is, got %v", got)
+	}
+	if got := cfg.Contexts["backend:local"].Env.Get("TARGET"); got != "ci" {
+		t.Errorf("TARGET = %v", got)
+	}
+
+	dir = writeConfigFiles(t, map[string]string{
+		"tasks.yaml": "import: [ci.yaml]\nprofiles: {ci: {}}\n",
+		"ci.yaml":    "profiles: {ci: {}}\n",
+	})
+	cl = NewConfigLoader(NewConfig())
+	if _, err := cl.Load(filepath.Join(dir, "tasks.yaml")); err == nil || !strings.Contains(err.Error(), "profile ci is defined in both") {
+		t.Errorf("a profile defined in two files must fail, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/taskctl/taskctl/internal/envutil"
+	"github.com/taskctl/taskctl/variables"
+)
+
+// Origin is a value one config file (or the env file it references) gave a
+// variable or env entry.
+type Origin struct {
+	File  string
+	Value string
+}
+
+// provenance records, for every variable and env entry the loaded config files
+// declare, the values each file gave it in load order: a file before the files
+// it imports, which override it.
+type provenance map[string][]Origin
+
+func originKey(path []string) string {
+	return strings.Join(path, "\x00")
+}
+
+// Origins returns the values the loaded config files gave the setting at path,
+// in the order they were loaded, e.g. ("tasks", "build", "env", "GOOS") or
+// ("variables", "Version"). Stage settings are under ("pipelines", pipeline,
+// stage, ...). An env_file's entries are attributed to the env file and come
+// before the env entries of the same definition, which override them. The
+// values of the active profile come last, attributed to "profile NAME", its
+// env under ("env", ...).
+func (cfg *Config) Origins(path ...string) []Origin {
+	return cfg.origins[originKey(path)]
+}
+
+func (p provenance) add(file, value string, path ...string) {
+	k := originKey(path)
+	p[k] = append(p[k], Origin{File: file, Value: value})
+}
+
+func (p provenance) addMap(file string, m any, path ...string) {
+	values, ok := m.(map[string]any)
+	if !ok {
+		return
+	}
+
+	kind := "env"
+	if path[len(path)-1] == "variables" {
+		kind = "variable"
+	}
+	for k, v := range values {
+		if value, err := parseValue(kind, v); err == nil {
+			v = value
+		}
+		p.add(file, variables.Format(v), append(path[:len(path):len(path)], k)...)
+	}
+}
+
+// addEnvFile records the entries of an env file, resolved against dir when
+// relative. A file that can't be read fails the config load elsewhere.
+func (p provenance) addEnvFile(filename any, dir string, path ...string) {
+	name, ok := filename.(string)
+	if !ok || name == "" {
+		return
+	}
+	if !filepath.IsAbs(name) && dir != "" {
+		name = filepath.Join(dir, name)
+	}
+
+	envs, err := envutil.ReadEnvFile(name)
+	if err != nil {
+		return
+	}
+
+	for k, v := range envs {
+		p.add(name, v, append(path[:len(path):len(path)], k)...)
+	}
+}
+
+// record adds the variables and env the raw config of file declares. dir is
+// the directory its relative paths are resolved against, and ns the namespace
+// file is imported under, prefixing the names it defines.
+func (p provenance) record(file string, raw map[string]any, dir, ns string) {
+	if p == nil {
+		return
+	}
+
+	p.addMap(file, raw["variables"], "variables")
+
+	for name, def := range asMap(raw["contexts"]) {
+		def := asMap(def)
+		ctxDir, _ := def["dir"].(string)
+		ctxDir = resolvePath(dir, ctxDir)
+		if ctxDir == "" {
+			ctxDir = dir
+		}
+		p.addEnvFile(def["env_file"], ctxDir, "contexts", ns+name, "env")
+		p.addMap(file, def["env"], "contexts", ns+name, "env")
+		p.addMap(file, def["variables"], "contexts", ns+name, "variables")
+	}
+
+	for name, def := range asMap(raw["tasks"]) {
+		def := asMap(def)
+		p.addEnvFile(def["env_file"], dir, "tasks", ns+name, "env")
+		p.addMap(file, def["env"], "tasks", ns+name, "env")
+		p.addMap(file, def["variables"], "tasks", ns+name, "variables")
+	}
+
+	for name, pipeline := range asMap(raw["pipelines"]) {
+		stages, ok := pipeline.([]any)
+		if !ok {
+			stages, _ = asMap(pipeline)["stages"].([]any)
+		}
+		for _, def := range stages {
+			def := asMap(def)
+			stage := stageName(def)
+			if stage == "" {
+				continue
+			}
+			stageDir, _ := def["dir"].(string)
+			stageDir = resolvePath(dir, stageDir)
+			if stageDir == "" {
+				stageDir = dir
+			}
+			p.addEnvFile(def["env_file"], stageDir, "pipelines", ns+name, stage, "env")
+			p.addMap(file, def["env"], "pipelines", ns+name, stage, "env")
+			p.addMap(file, def["variables"], "pipelines", ns+name, stage, "variables")
+		}
+	}
+}
+
+// stageName names a raw stage definition the way buildPipeline does.
+func stageName(def map[string]any) string {
+	for _, k := range []string{"name", "pipeline", "task"} {
+		if v, ok := def[k].(string); ok && v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+func asMap(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfig_Origins(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"tasks.yaml": `
+import: [shared.yaml]
+variables:
+  Version: "1.0"
+  Packages: [api, web]
+contexts:
+  ci:
+    env:
+      CI: "true"
+tasks:
+  build:
+    command: "true"
+    env_file: build.env
+    env:
+      GOOS: linux
+      COMMIT: {sh: git rev-parse HEAD}
+pipelines:
+  release:
+    - task: build
+      variables:
+        Channel: rc
+`,
+		"shared.yaml": "variables:\n  Version: \"2.0\"\n",
+		"build.env":   "GOOS=darwin\nCGO_ENABLED=0\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cl := NewConfigLoader(NewConfig())
+	cl.dir = dir
+	cl.homeDir = ""
+	cfg, err := cl.Load("tasks.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfgFile, sharedFile, envFile := filepath.Join(dir, "tasks.yaml"), filepath.Join(dir, "shared.yaml"), filepath.Join(dir, "build.env")
+	tests := []struct {
+		path []string
+		want []Origin
+	}{
+		{[]string{"variables", "Version"}, []Origin{{cfgFile, "1.0"}, {sharedFile, "2.0"}}},
+		{[]string{"contexts", "ci", "env", "CI"}, []Origin{{cfgFile, "true"}}},
+		{[]string{"tasks", "build", "env", "GOOS"}, []Origin{{envFile, "darwin"}, {cfgFile, "linux"}}},
+		{[]string{"tasks", "build", "env", "CGO_ENABLED"}, []Origin{{envFile, "0"}}},
+		{[]string{"tasks", "build", "env", "COMMIT"}, []Origin{{cfgFile, "sh: git rev-parse HEAD"}}},
+		{[]string{"pipelines", "release", "build", "variables", "Channel"}, []Origin{{cfgFile, "rc"}}},
+		{[]string{"variables", "Packages"}, []Origin{{cfgFile, `["api","web"]`}}},
+		{[]string{"variables", "Missing"}, nil},
+	}
+	for _, tt := range tests {
+		got := cfg.Origins(tt.path...)
+		if len(got) != len(tt.want) {
+			t.Errorf("Origins(%q) = %v, want %v", tt.path, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Origins(%q) = %v, want %v", tt.path, got, tt.want)
+				break
+			}
+		}
+	}
+
+	if v := cfg.Variables.Get("Version"); v != "2.0" {
+		t.Errorf("expected the imported Version to win, got %v", v)
+	}
+}
@@ -0,0 +1,314 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/taskctl/taskctl/internal/iox"
+)
+
+const (
+	// DefaultImportTimeout caps a remote import's request when the import
+	// sets no timeout.
+	DefaultImportTimeout = 30 * time.Second
+	// DefaultImportTTL is how long a cached remote import is used before it
+	// is fetched again when the import sets no ttl.
+	DefaultImportTTL = time.Hour
+)
+
+// RemoteOptions control how a Loader fetches remote imports.
+type RemoteOptions struct {
+	// Offline reads remote imports from the cache only, however old, and
+	// fails for one that isn't cached; along with Refresh, the cache is read
+	// regardless of the lock file.
+	Offline bool
+	// Refresh fetches every remote import, bypassing the cache and the lock
+	// file and leaving the cache as is, as taskctl imports update and verify
+	// do.
+	Refresh bool
+	// CacheDir is where remote imports are cached; the default is
+	// ~/.taskctl/cache/imports.
+	CacheDir string
+}
+
+// RemoteImport is a remote config the loader read, with the SHA-256 of its
+// content and, for a git import, the commit it was read at.
+type RemoteImport struct {
+	URL    string
+	SHA256 string
+	Commit string
+}
+
+// SetRemoteOptions sets how remote imports are fetched.
+func (cl *Loader) SetRemoteOptions(o RemoteOptions) {
+	if o.CacheDir == "" && cl.homeDir != "" {
+		o.CacheDir = filepath.Join(cl.homeDir, ".taskctl", "cache", "imports")
+	}
+	cl.remote = o
+}
+
+// Remotes returns the remote imports the last Load read, in load order.
+func (cl *Loader) Remotes() []RemoteImport {
+	return cl.remotes
+}
+
+// cachedImport is the metadata kept next to a cached remote import.
+type cachedImport struct {
+	URL     string    `json:"url"`
+	Ext     string    `json:"ext"`
+	Fetched time.Time `json:"fetched"`
+}
+
+// readRemote reads the remote config u. A cached copy is used while younger
+// than the import's ttl, or whatever its age when its content is the one
+// pinned, by the import's sha256 or the lock file; otherwise u is fetched,
+// and a failed fetch fails. The content must match both pins.
+func (cl *Loader) readRemote(u string, imp importDefinition) (map[string]any, error) {
+	if isGitURL(u) {
+		return cl.readGit(u, imp)
+	}
+
+	locked := cl.locked(u)
+	verify := func(data []byte) (string, error) {
+		return cl.verifyRemote(u, imp, data)
+	}
+
+	data, meta, cacheErr := cl.readCache(u)
+	if cacheErr == nil && (!cl.remote.Refresh || cl.remote.Offline) {
+		ttl := DefaultImportTTL
+		if imp.TTL != 0 {
+			ttl = imp.TTL
+		}
+		pinned := imp.SHA256 != "" || locked.SHA256 != ""
+		if sum, err := verify(data); err == nil && (cl.remote.Offline || pinned || time.Since(meta.Fetched) < ttl) {
+			return cl.remoteImport(RemoteImport{URL: u, SHA256: sum}, data, meta.Ext)
+		} else if cl.remote.Offline {
+			return nil, err
+		}
+	}
+	if cl.remote.Offline {
+		return nil, fmt.Errorf("%s: not in the import cache, run without --offline to fetch it", u)
+	}
+
+	// a stale copy is not used when the fetch fails: that would hide the
+	// outage, --offline uses it knowingly
+	fetched, ext, err := cl.fetch(u, imp)
+	if err != nil {
+		if cacheErr == nil && !cl.remote.Refresh && !errors.Is(err, ErrConfigNotFound) {
+			return nil, fmt.Errorf("%w; run with --offline to use the copy cached %s", err, meta.Fetched.Format(time.RFC3339))
+		}
+		return nil, err
+	}
+
+	sum, err := verify(fetched)
+	if err != nil {
+		return nil, err
+	}
+	// a refresh only looks at the remote content, a later load caches it
+	if !cl.remote.Refresh {
+		if err := cl.writeCache(u, fetched, ext); err != nil {
+			slog.Debug(fmt.Sprintf("%s: caching failed: %s", u, err))
+		}
+	}
+
+	return cl.remoteImport(RemoteImport{URL: u, SHA256: sum}, fetched, ext)
+}
+
+// locked returns the lock file's entry for u, none when refreshing.
+func (cl *Loader) locked(u string) LockedImport {
+	if cl.remote.Refresh {
+		return LockedImport{}
+	}
+
+	return cl.lock.Imports[u]
+}
+
+// verifyRemote returns the SHA-256 of data, the content of the remote import
+// u, failing when it isn't the one pinned by the import or the lock file.
+func (cl *Loader) verifyRemote(u string, imp importDefinition, data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	got := hex.EncodeToString(sum[:])
+	if imp.SHA256 != "" && !strings.EqualFold(strings.TrimPrefix(imp.SHA256, "sha256:"), got) {
+		return got, fmt.Errorf("%s: sha256 %s does not match the pinned %s", u, got, imp.SHA256)
+	}
+	if locked := cl.locked(u).SHA256; locked != "" && locked != got {
+		return got, fmt.Errorf("%s: sha256 %s does not match %s locked in %s, run taskctl imports update to accept it", u, got, locked, LockFileName)
+	}
+
+	return got, nil
+}
+
+// remoteImport records the remote import r and parses its content.
+func (cl *Loader) remoteImport(r RemoteImport, data []byte, ext string) (map[string]any, error) {
+	cl.remotes = append(cl.remotes, r)
+	m, err := cl.unmarshalData(data, ext)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", r.URL, err)
+	}
+
+	return m, nil
+}
+
+// fetch requests u with the import's headers and timeout, returning the
+// content and the extension it is parsed by.
+func (cl *Loader) fetch(u string, imp importDefinition) ([]byte, string, error) {
+	timeout := DefaultImportTimeout
+	if imp.Timeout != 0 {
+		timeout = imp.Timeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	headers, err := importHeaders(imp)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", u, err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		// the headers are credentials for the import's host only
+		if req.URL.Host != via[0].URL.Host {
+			for k := range headers {
+				req.Header.Del(k)
+			}
+		}
+		return nil
+	}}
+	resp, err := client.Do(req) //nolint:bodyclose // body is closed via iox.Close on the next line
+	if err != nil {
+		return nil, "", err
+	}
+	defer iox.Close(resp.Body)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", fmt.Errorf("%s: %w", u, ErrConfigNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("%d: config request failed - %s", resp.StatusCode, u)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", u, err)
+	}
+
+	var ext string
+	ct := resp.Header.Get("Content-Type")
+	if ct != "" {
+		mediaType, _, _ := mime.ParseMediaType(ct)
+		if mediaType == "application/json" {
+			ext = ".json"
+		}
+	}
+
+	if ext == "" {
+		up, err := url.Parse(u)
+		if err == nil {
+			ext = filepath.Ext(up.Path)
+		}
+	}
+
+	if ext == "" {
+		ext = ".yaml"
+	}
+
+	return data, ext, nil
+}
+
+// importHeaders expands the $VAR and ${VAR} references of the import's
+// headers from the environment, so credentials stay out of the config. A
+// reference to an unset variable is an error rather than an empty credential,
+// as is one in the headers of an import declared by a remote config.
+func importHeaders(imp importDefinition) (map[string]string, error) {
+	expanded := make(map[string]string, len(imp.Headers))
+	for k, v := range imp.Headers {
+		var err error
+		expanded[k] = os.Expand(v, func(name string) string {
+			if !imp.expandHeaders {
+				if err == nil {
+					err = fmt.Errorf("header %s: $%s is only expanded in the imports of a local config", k, name)
+				}
+				return ""
+			}
+			value, ok := os.LookupEnv(name)
+			if !ok && err == nil {
+				err = fmt.Errorf("header %s: $%s is not set", k, name)
+			}
+			return value
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return expanded, nil
+}
+
+func (cl *Loader) cacheFile(u string) string {
+	sum := sha256.Sum256([]byte(u))
+	return filepath.Join(cl.remote.CacheDir, hex.EncodeToString(sum[:]))
+}
+
+func (cl *Loader) readCache(u string) ([]byte, cachedImport, error) {
+	var meta cachedImport
+	if cl.remote.CacheDir == "" {
+		return nil, meta, errors.New("no import cache")
+	}
+
+	file := cl.cacheFile(u)
+	b, err := os.ReadFile(file + ".json")
+	if err != nil {
+		return nil, meta, err
+	}
+	if err := json.Unmarshal(b, &meta); err != nil {
+		return nil, meta, err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, meta, err
+	}
+
+	return data, meta, nil
+}
+
+func (cl *Loader) writeCache(u string, data []byte, ext string) error {
+	if cl.remote.CacheDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(cl.remote.CacheDir, 0o755); err != nil {
+		return err
+	}
+
+	meta, err := json.Marshal(cachedImport{URL: u, Ext: ext, Fetched: time.Now()})
+	if err != nil {
+		return err
+	}
+	file := cl.cacheFile(u)
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.WriteFile(file+".json", meta, 0o644)
+}
@@ -1,7 +1,8 @@
 package config
 
 import (
-	"path/filepath"
+	"fmt"
+	"strconv"
 
 	"github.com/taskctl/taskctl/internal/envutil"
 	"github.com/taskctl/taskctl/variables"
@@ -11,30 +12,87 @@ import (
 
 func buildTask(def *taskDefinition, lc *loaderContext) (*task.Task, error) {
 	t := &task.Task{
-		Name:         def.Name,
-		Description:  def.Description,
-		Condition:    def.Condition,
-		Commands:     def.Command,
-		Env:          variables.FromMap(def.Env),
-		Variables:    variables.FromMap(def.Variables),
-		Variations:   def.Variations,
-		Dir:          def.Dir,
-		Timeout:      def.Timeout,
-		AllowFailure: def.AllowFailure,
-		After:        def.After,
-		Before:       def.Before,
-		ExportAs:     def.ExportAs,
-		Context:      def.Context,
-		Interactive:  def.Interactive,
+		Name:           def.Name,
+		Description:    def.Description,
+		Condition:      def.Condition,
+		Commands:       def.Command,
+		Variations:     def.Variations,
+		Dir:            resolvePath(lc.configDir("tasks", def.Name), def.Dir),
+		Timeout:        def.Timeout,
+		CommandTimeout: def.CommandTimeout,
+		SuccessCodes:   def.SuccessCodes,
+		After:          def.After,
+		Before:         def.Before,
+		OnFailure:      def.OnFailure,
+		Finally:        def.Finally,
+		ExportAs:       def.ExportAs,
+		Context:        def.Context,
+		Interactive:    def.Interactive,
+		TTY:            def.TTY,
 	}
 
-	if def.EnvFile != "" {
-		filename := def.EnvFile
-		if !filepath.IsAbs(filename) && lc.Dir != "" {
-			filename = filepath.Join(lc.Dir, filename)
+	if t.Dir == "" {
+		t.Dir = lc.defaultDir("tasks", def.Name)
+	}
+
+	var err error
+	if t.Env, err = parseValues("env", def.Env); err != nil {
+		return nil, fmt.Errorf("task %s: %w", def.Name, err)
+	}
+	if t.Variables, err = parseValues("variable", def.Variables); err != nil {
+		return nil, fmt.Errorf("task %s: %w", def.Name, err)
+	}
+	if file := lc.configFile("tasks", def.Name); file != "" {
+		t.Variables.Set("ConfigFile", file)
+		t.Variables.Set("ConfigDir", lc.configDir("tasks", def.Name))
+	}
+
+	if err := checkExitCodes("success_codes", def.SuccessCodes); err != nil {
+		return nil, fmt.Errorf("task %s: %w", def.Name, err)
+	}
+
+	allow, exitCodes, err := parseAllowFailure(def.AllowFailure)
+	if err != nil {
+		return nil, fmt.Errorf("task %s: %w", def.Name, err)
+	}
+	t.AllowFailure, t.AllowFailureExitCodes = allow, exitCodes
+
+	parallel, limit, err := parseParallel(def.Parallel)
+	if err != nil {
+		return nil, fmt.Errorf("task %s: %w", def.Name, err)
+	}
+	if def.TTY && def.Interactive {
+		return nil, fmt.Errorf("task %s: an interactive task already runs in the terminal and can't set tty", def.Name)
+	}
+	if parallel && def.Interactive {
+		return nil, fmt.Errorf("task %s: an interactive task can't run in parallel", def.Name)
+	}
+	t.Parallel, t.ParallelLimit = parallel, limit
+
+	if def.Limits != nil {
+		limits, err := buildLimits(def.Limits)
+		if err != nil {
+			return nil, fmt.Errorf("task %s: %w", def.Name, err)
 		}
+		t.Limits = limits
+	}
+
+	if def.EnvInherit != nil {
+		t.EnvInherit, err = parseEnvInherit(def.EnvInherit)
+		if err != nil {
+			return nil, fmt.Errorf("task %s: %w", def.Name, err)
+		}
+	}
+
+	if def.Builtins != nil {
+		t.Builtins, err = parseBuiltins(def.Builtins)
+		if err != nil {
+			return nil, fmt.Errorf("task %s: %w", def.Name, err)
+		}
+	}
 
-		envs, err := envutil.ReadEnvFile(filename)
+	if def.EnvFile != "" {
+		envs, err := envutil.ReadEnvFile(resolvePath(lc.configDir("tasks", def.Name), def.EnvFile))
 		if err != nil {
 			return nil, err
 		}
@@ -44,3 +102,21 @@ func buildTask(def *taskDefinition, lc *loaderContext) (*task.Task, error) {
 
 	return t, nil
 }
+
+// parseParallel reads the parallel key: true or false, or the number of
+// variations or commands run at once.
+func parseParallel(v any) (bool, int, error) {
+	switch v := v.(type) {
+	case nil:
+		return false, 0, nil
+	case bool:
+		return v, 0, nil
+	}
+
+	n, err := strconv.Atoi(fmt.Sprint(v))
+	if err != nil || n < 1 {
+		return false, 0, fmt.Errorf("parallel must be true, false or a positive number, got %v", v)
+	}
+
+	return true, n, nil
+}
@@ -36,3 +36,51 @@ func Test_buildTask(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseParallel(t *testing.T) {
+	cases := []struct {
+		v        any
+		parallel bool
+		limit    int
+		wantErr  bool
+	}{
+		{v: nil},
+		{v: false},
+		{v: true, parallel: true},
+		{v: 4, parallel: true, limit: 4},
+		{v: int64(2), parallel: true, limit: 2},
+		{v: float64(3), parallel: true, limit: 3},
+		{v: 0, wantErr: true},
+		{v: "many", wantErr: true},
+	}
+
+	for _, c := range cases {
+		parallel, limit, err := parseParallel(c.v)
+		if (err != nil) != c.wantErr {
+			t.Errorf("parseParallel(%v) error = %v, wantErr %v", c.v, err, c.wantErr)
+			continue
+		}
+		if parallel != c.parallel || limit != c.limit {
+			t.Errorf("parseParallel(%v) = %v, %d, want %v, %d", c.v, parallel, limit, c.parallel, c.limit)
+		}
+	}
+}
+
+func Test_buildTaskParallelInteractive(t *testing.T) {
+	_, err := buildTask(&taskDefinition{Name: "shell", Interactive: true, Parallel: true}, &loaderContext{})
+	if err == nil {
+		t.Error("expected an interactive parallel task to be rejected")
+	}
+}
+
+func Test_buildTaskTTY(t *testing.T) {
+	tsk, err := buildTask(&taskDefinition{Name: "build", TTY: true}, &loaderContext{})
+	if err != nil || !tsk.TTY {
+		t.Errorf("expected a tty task, got %v", err)
+	}
+
+	_, err = buildTask(&taskDefinition{Name: "shell", Interactive: true, TTY: true}, &loaderContext{})
+	if err == nil {
+		t.Error("expected an interactive tty task to be rejected")
+	}
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+
+	"github.com/taskctl/taskctl/variables"
+)
+
+// parseValues reads a variables or env map. A value given as {sh: script} is
+// computed by the runner when needed (see variables.Command). Other variables
+// keep their type, so lists and maps can be ranged over in templates, while
+// env values must be scalars and are used as strings.
+func parseValues(kind string, m map[string]any) (variables.Container, error) {
+	vars := variables.NewVariables()
+	for _, name := range slices.Sorted(maps.Keys(m)) {
+		v, err := parseValue(kind, m[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", kind, name, err)
+		}
+		vars.Set(name, v)
+	}
+
+	return vars, nil
+}
+
+func parseValue(kind string, v any) (any, error) {
+	if m, ok := v.(map[string]any); ok {
+		if script, ok := m["sh"]; ok && len(m) == 1 {
+			s, ok := script.(string)
+			if !ok || strings.TrimSpace(s) == "" {
+				return nil, fmt.Errorf("sh must be a command, got %v", script)
+			}
+			return variables.Command{Script: s}, nil
+		}
+	}
+
+	if kind == "variable" && v != nil {
+		return normalizeValue(v), nil
+	}
+
+	var s string
+	if err := mapstructure.WeakDecode(v, &s); err != nil {
+		return nil, fmt.Errorf("must be a string or {sh: command}, got %v", v)
+	}
+
+	return s, nil
+}
+
+// ParseVariable reads a variable given on the command line: a JSON list or
+// object is decoded, so --set 'Packages=["api","web"]' can be ranged over,
+// and anything else is the string itself.
+func ParseVariable(s string) any {
+	if t := strings.TrimSpace(s); strings.HasPrefix(t, "[") || strings.HasPrefix(t, "{") {
+		var v any
+		if err := json.Unmarshal([]byte(t), &v); err == nil {
+			return normalizeValue(v)
+		}
+	}
+
+	return s
+}
+
+// normalizeValue makes the values decoded from YAML, JSON and TOML alike:
+// maps are keyed by strings, and whole numbers, which JSON decodes as
+// floats, are ints.
+func normalizeValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[k] = normalizeValue(item)
+		}
+		return m
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = normalizeValue(item)
+		}
+		return m
+	case []any:
+		l := make([]any, len(v))
+		for i, item := range v {
+			l[i] = normalizeValue(item)
+		}
+		return l
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int(v)
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/taskctl/taskctl/variables"
+)
+
+func Test_parseValues(t *testing.T) {
+	values := map[string]any{
+		"Name":    "taskctl",
+		"Port":    8080,
+		"Ratio":   1.5,
+		"Enabled": true,
+		"Empty":   nil,
+		"Version": map[string]any{"sh": "git describe --tags"},
+	}
+
+	env, err := parseValues("env", values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]any{
+		"Name":    "taskctl",
+		"Port":    "8080",
+		"Ratio":   "1.5",
+		"Enabled": "1",
+		"Empty":   "",
+		"Version": variables.Command{Script: "git describe --tags"},
+	} {
+		if got := env.Get(k); got != v {
+			t.Errorf("env %s = %#v, want %#v", k, got, v)
+		}
+	}
+
+	values["Packages"] = []any{"api", "web"}
+	values["Ports"] = map[string]any{"api": float64(8080), "web": map[any]any{1: "x"}}
+	vars, err := parseValues("variable", values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]any{
+		"Name":     "taskctl",
+		"Port":     8080,
+		"Ratio":    1.5,
+		"Enabled":  true,
+		"Empty":    "",
+		"Version":  variables.Command{Script: "git describe --tags"},
+		"Packages": []any{"api", "web"},
+		"Ports":    map[string]any{"api": 8080, "web": map[string]any{"1": "x"}},
+	} {
+		if got := vars.Get(k); !reflect.DeepEqual(got, v) {
+			t.Errorf("variable %s = %#v, want %#v", k, got, v)
+		}
+	}
+
+	for _, v := range []any{
+		map[string]any{"sh": ""},
+		map[string]any{"sh": 1},
+		map[string]any{"value": "x"},
+		[]any{"a"},
+	} {
+		if _, err := parseValues("env", map[string]any{"X": v}); err == nil {
+			t.Errorf("parseValues(env, %v) expected an error", v)
+		}
+	}
+	if _, err := parseValues("variable", map[string]any{"X": map[string]any{"sh": []any{"date"}}}); err == nil {
+		t.Error("a variable's sh must be a command")
+	}
+}
+
+func TestParseVariable(t *testing.T) {
+	tests := []struct {
+		in   string
+		want any
+	}{
+		{"1.0", "1.0"},
+		{`["api","web"]`, []any{"api", "web"}},
+		{`{"api": 8080, "tags": ["a"]}`, map[string]any{"api": 8080, "tags": []any{"a"}}},
+		{"[not json", "[not json"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := ParseVariable(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseVariable(%q) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLoader_ComputedValues(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tasks.yaml")
+	data := `
+variables:
+  Version: {sh: git describe --tags}
+contexts:
+  local:
+    env:
+      HOST: {sh: hostname}
+tasks:
+  build:
+    command: [go build]
+    variables:
+      Arch: amd64
+    env:
+      COMMIT:
+        sh: git rev-parse HEAD
+`
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cl := NewConfigLoader(NewConfig())
+	cfg, err := cl.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := cfg.Variables.Get("Version"); got != (variables.Command{Script: "git describe --tags"}) {
+		t.Errorf("Version = %#v", got)
+	}
+	if got := cfg.Contexts["local"].Env.Get("HOST"); got != (variables.Command{Script: "hostname"}) {
+		t.Errorf("HOST = %#v", got)
+	}
+	if got := cfg.Tasks["build"].Env.Get("COMMIT"); got != (variables.Command{Script: "git rev-parse HEAD"}) {
+		t.Errorf("COMMIT = %#v", got)
+	}
+	if got := cfg.Tasks["build"].Variables.Get("Arch"); got != "amd64" {
+		t.Errorf("Arch = %#v", got)
+	}
+
+	data += `
+  broken:
+    command: [true]
+    env:
+      X: {shell: date}
+`
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cl = NewConfigLoader(NewConfig())
+	_, err = cl.Load(file)
+	if want := "task broken: env X: must be a string or {sh: command}, got map[shell:date]"; err == nil || err.Error() != want {
+		t.Errorf("Load() error = %v, want %q", err, want)
+	}
+}
@@ -2,15 +2,43 @@ package config
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/taskctl/taskctl/internal/fsutil"
 
 	"github.com/taskctl/taskctl/internal/watch"
 )
 
-func buildWatcher(name string, def *watcherDefinition, cfg *Config) (*watch.Watcher, error) {
+// buildWatcher builds the watcher name. Its relative patterns are resolved
+// against configDir, the directory of the file defining it, and kept relative
+// to the current directory when under it.
+func buildWatcher(name string, def *watcherDefinition, cfg *Config, configDir string) (*watch.Watcher, error) {
 	t, ok := cfg.Tasks[def.Task]
 	if !ok {
 		return nil, fmt.Errorf("watcher build failed. task %s not found", def.Task)
 	}
 
-	return watch.NewWatcher(name, def.Events, def.Watch, def.Exclude, t)
+	return watch.NewWatcher(name, def.Events, resolvePatterns(configDir, def.Watch), resolvePatterns(configDir, def.Exclude), t)
+}
+
+func resolvePatterns(dir string, patterns []string) []string {
+	if dir == "" {
+		return patterns
+	}
+
+	cwd := fsutil.MustGetwd()
+	resolved := make([]string, len(patterns))
+	for i, p := range patterns {
+		resolved[i] = p
+		if filepath.IsAbs(p) {
+			continue
+		}
+		resolved[i] = resolvePath(dir, p)
+		if rel, err := filepath.Rel(cwd, resolved[i]); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			resolved[i] = rel
+		}
+	}
+
+	return resolved
 }
@@ -9,14 +9,14 @@ import (
 func Test_buildWatcher(t *testing.T) {
 	_, err := buildWatcher("tw", &watcherDefinition{
 		Task: "hello",
-	}, &Config{})
+	}, &Config{}, "")
 	if err == nil {
 		t.Error()
 	}
 
 	_, err = buildWatcher("tw", &watcherDefinition{
 		Task: "hello",
-	}, &Config{Tasks: map[string]*task.Task{"hello": {}}})
+	}, &Config{Tasks: map[string]*task.Task{"hello": {}}}, "")
 	if err != nil {
 		t.Fatal()
 	}
@@ -0,0 +1,217 @@
+// Package coreutils implements common POSIX utilities in Go, so commands like
+// rm -rf or mkdir -p work where there are no binaries to run, e.g. in
+// distroless and scratch containers.
+//
+// The utilities run inside the interpreter through ExecHandler, with paths
+// relative to the shell's directory. They support the options scripts use
+// most, given before the operands; anything beyond that is an error rather
+// than silently ignored.
+package coreutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/interp"
+)
+
+// command runs a utility with its arguments, without the name. It returns a
+// usageError for invalid arguments and any other error to abort; errors about
+// one of several operands are reported through c.errorf instead, so the
+// remaining operands are still processed.
+type command func(c *call, args []string) error
+
+var commands = map[string]command{
+	"basename": basename,
+	"cat":      cat,
+	"chmod":    chmod,
+	"cp":       cp,
+	"dirname":  dirname,
+	"head":     head,
+	"ln":       ln,
+	"ls":       ls,
+	"mkdir":    mkdir,
+	"mktemp":   mktemp,
+	"mv":       mv,
+	"rm":       rm,
+	"rmdir":    rmdir,
+	"sleep":    sleep,
+	"tail":     tail,
+	"touch":    touch,
+	"wc":       wc,
+}
+
+// Commands returns the names of the implemented utilities, sorted.
+func Commands() []string {
+	return slices.Sorted(maps.Keys(commands))
+}
+
+// ExecHandler is an interpreter exec middleware running the implemented
+// utilities in place of binaries and passing other commands to next. A
+// utility exits with status 2 on invalid arguments and 1 on other errors.
+func ExecHandler(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(ctx context.Context, args []string) error {
+		cmd, ok := commands[args[0]]
+		if !ok {
+			return next(ctx, args)
+		}
+
+		c := &call{ctx: ctx, hc: interp.HandlerCtx(ctx), name: args[0]}
+		err := cmd(c, args[1:])
+		var usage usageError
+		switch {
+		case errors.As(err, &usage):
+			c.errorf("%s", usage)
+			return interp.ExitStatus(2)
+		case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+			return err
+		case err != nil:
+			c.errorf("%v", c.relative(err))
+			return interp.ExitStatus(1)
+		case c.failed:
+			return interp.ExitStatus(1)
+		}
+
+		return nil
+	}
+}
+
+// call is one invocation of a utility.
+type call struct {
+	ctx    context.Context
+	hc     interp.HandlerContext
+	name   string
+	failed bool
+}
+
+// errorf reports an error on stderr, prefixed with the utility's name, and
+// makes the utility exit with status 1.
+func (c *call) errorf(format string, args ...any) {
+	_, _ = fmt.Fprintf(c.hc.Stderr, c.name+": "+format+"\n", args...)
+	c.failed = true
+}
+
+// fail reports err about one operand, with paths relative to the shell's
+// directory as the script gave them.
+func (c *call) fail(err error) {
+	c.errorf("%v", c.relative(err))
+}
+
+// path resolves name against the shell's directory.
+func (c *call) path(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+
+	return filepath.Join(c.hc.Dir, name)
+}
+
+// relative rewrites the paths of a file system error relative to the shell's
+// directory, undoing path.
+func (c *call) relative(err error) error {
+	rel := func(p string) string {
+		if r, err := filepath.Rel(c.hc.Dir, p); err == nil && filepath.IsLocal(r) {
+			return r
+		}
+		return p
+	}
+
+	var pathErr *fs.PathError
+	var linkErr *os.LinkError
+	switch {
+	case errors.As(err, &pathErr):
+		return &fs.PathError{Op: pathErr.Op, Path: rel(pathErr.Path), Err: pathErr.Err}
+	case errors.As(err, &linkErr):
+		return &os.LinkError{Op: linkErr.Op, Old: rel(linkErr.Old), New: rel(linkErr.New), Err: linkErr.Err}
+	}
+
+	return err
+}
+
+// usageError is an invalid invocation of a utility.
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// flags are the options given to a utility, by letter. An option taking a
+// value maps to it; others map to "".
+type flags map[byte]string
+
+func (f flags) has(letters string) bool {
+	for i := range len(letters) {
+		if _, ok := f[letters[i]]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseFlags splits args into options and operands, getopt style: spec lists
+// the accepted letters, each followed by ':' when it takes a value, and long
+// maps long options to their letters. Short options may be combined as in
+// -rf; the first operand or "--" ends the options, and "-" is an operand.
+func parseFlags(args []string, spec string, long map[string]byte) (flags, []string, error) {
+	f := make(flags)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return f, args[i+1:], nil
+		case arg == "-" || !strings.HasPrefix(arg, "-"):
+			return f, args[i:], nil
+		case strings.HasPrefix(arg, "--"):
+			name, value, hasValue := strings.Cut(arg[2:], "=")
+			letter, ok := long[name]
+			if !ok {
+				return nil, nil, usageError(fmt.Sprintf("unknown option --%s", name))
+			}
+			takesValue := strings.Contains(spec, string(letter)+":")
+			switch {
+			case takesValue && !hasValue:
+				if i+1 == len(args) {
+					return nil, nil, usageError(fmt.Sprintf("option --%s requires a value", name))
+				}
+				i++
+				value = args[i]
+			case !takesValue && hasValue:
+				return nil, nil, usageError(fmt.Sprintf("option --%s takes no value", name))
+			}
+			f[letter] = value
+		default:
+			for j := 1; j < len(arg); j++ {
+				k := strings.IndexByte(spec, arg[j])
+				if k < 0 || arg[j] == ':' {
+					return nil, nil, usageError(fmt.Sprintf("unknown option -%c", arg[j]))
+				}
+				if k+1 == len(spec) || spec[k+1] != ':' {
+					f[arg[j]] = ""
+					continue
+				}
+
+				// The value is the rest of the argument or the next one.
+				value := arg[j+1:]
+				if value == "" {
+					if i+1 == len(args) {
+						return nil, nil, usageError(fmt.Sprintf("option -%c requires a value", arg[j]))
+					}
+					i++
+					value = args[i]
+				}
+				f[arg[j]] = value
+				break
+			}
+		}
+	}
+
+	return f, nil, nil
+}
@@ -0,0 +1,129 @@
+package coreutils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// run runs script in dir with the utilities and no binaries, returning its
+// stdout and stderr.
+func run(t *testing.T, dir, script string) (string, string, error) {
+	t.Helper()
+
+	return runContext(context.Background(), t, dir, script)
+}
+
+func runContext(ctx context.Context, t *testing.T, dir, script string) (string, string, error) {
+	t.Helper()
+
+	f, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	r, err := interp.New(
+		interp.Dir(dir),
+		interp.StdIO(nil, &stdout, &stderr),
+		interp.ExecHandlers(ExecHandler, func(interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+			return func(ctx context.Context, args []string) error {
+				return errors.New("no binaries: " + args[0])
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.Run(ctx, f)
+	return stdout.String(), stderr.String(), err
+}
+
+func TestExecHandler(t *testing.T) {
+	dir := t.TempDir()
+
+	_, _, err := run(t, dir, "grep foo")
+	if err == nil || !strings.Contains(err.Error(), "no binaries: grep") {
+		t.Errorf("other commands must go to the next handler, got %v", err)
+	}
+
+	_, stderr, err := run(t, dir, "rm")
+	if code, ok := errors.AsType[interp.ExitStatus](err); !ok || code != 2 || stderr != "rm: missing operand\n" {
+		t.Errorf("a usage error must exit with status 2, got %v, %q", err, stderr)
+	}
+
+	_, stderr, err = run(t, dir, "cat missing")
+	if code, ok := errors.AsType[interp.ExitStatus](err); !ok || code != 1 || stderr != "cat: open missing: no such file or directory\n" {
+		t.Errorf("a failed operand must exit with status 1 and show its relative path, got %v, %q", err, stderr)
+	}
+
+	if !slices.Contains(Commands(), "rm") || !slices.IsSorted(Commands()) {
+		t.Errorf("unexpected commands %v", Commands())
+	}
+}
+
+func Test_parseFlags(t *testing.T) {
+	tests := []struct {
+		args     []string
+		flags    flags
+		operands []string
+		wantErr  bool
+	}{
+		{args: []string{"-rf", "a", "-b"}, flags: flags{'r': "", 'f': ""}, operands: []string{"a", "-b"}},
+		{args: []string{"--recursive", "--", "-a"}, flags: flags{'r': ""}, operands: []string{"-a"}},
+		{args: []string{"-n5", "-"}, flags: flags{'n': "5"}, operands: []string{"-"}},
+		{args: []string{"-rn", "5", "x"}, flags: flags{'r': "", 'n': "5"}, operands: []string{"x"}},
+		{args: []string{"--lines=3"}, flags: flags{'n': "3"}},
+		{args: []string{"--lines", "3"}, flags: flags{'n': "3"}},
+		{args: []string{"-x"}, wantErr: true},
+		{args: []string{"-n"}, wantErr: true},
+		{args: []string{"--force"}, wantErr: true},
+		{args: []string{"--recursive=yes"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		f, operands, err := parseFlags(tt.args, "rfn:", map[string]byte{"recursive": 'r', "lines": 'n'})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseFlags(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			if !errors.As(err, new(usageError)) {
+				t.Errorf("parseFlags(%q) error %v is not a usage error", tt.args, err)
+			}
+			continue
+		}
+		if len(f) != len(tt.flags) || !slices.Equal(operands, tt.operands) {
+			t.Errorf("parseFlags(%q) = %v, %q; want %v, %q", tt.args, f, operands, tt.flags, tt.operands)
+		}
+		for k, v := range tt.flags {
+			if f[k] != v {
+				t.Errorf("parseFlags(%q) flag %c = %q, want %q", tt.args, k, f[k], v)
+			}
+		}
+	}
+}
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Whatever the umask.
+	if err := os.Chmod(name, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
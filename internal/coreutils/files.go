@@ -0,0 +1,609 @@
+package coreutils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// rm [-rf] FILE...
+func rm(c *call, args []string) error {
+	f, operands, err := parseFlags(args, "rRf", map[string]byte{"recursive": 'r', "force": 'f'})
+	if err != nil {
+		return err
+	}
+	recursive, force := f.has("rR"), f.has("f")
+	if len(operands) == 0 && !force {
+		return usageError("missing operand")
+	}
+
+	for _, name := range operands {
+		if base := filepath.Base(filepath.Clean(name)); base == "." || base == ".." {
+			c.errorf("refusing to remove %q", name)
+			continue
+		}
+		p := c.path(name)
+		if filepath.Dir(p) == p {
+			c.errorf("refusing to remove the root directory %s", name)
+			continue
+		}
+
+		info, err := os.Lstat(p)
+		switch {
+		case err != nil:
+			if !force || !errors.Is(err, fs.ErrNotExist) {
+				c.fail(err)
+			}
+		case info.IsDir() && !recursive:
+			c.errorf("cannot remove %s: is a directory", name)
+		case recursive:
+			if err := os.RemoveAll(p); err != nil {
+				c.fail(err)
+			}
+		default:
+			if err := os.Remove(p); err != nil {
+				c.fail(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// rmdir DIR...
+func rmdir(c *call, args []string) error {
+	_, operands, err := parseFlags(args, "", nil)
+	if err != nil {
+		return err
+	}
+	if len(operands) == 0 {
+		return usageError("missing operand")
+	}
+
+	for _, name := range operands {
+		p := c.path(name)
+		info, err := os.Lstat(p)
+		switch {
+		case err != nil:
+			c.fail(err)
+		case !info.IsDir():
+			c.errorf("failed to remove %s: not a directory", name)
+		default:
+			if err := os.Remove(p); err != nil {
+				c.fail(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// mkdir [-p] [-m MODE] DIR...
+func mkdir(c *call, args []string) error {
+	f, operands, err := parseFlags(args, "pm:", map[string]byte{"parents": 'p', "mode": 'm'})
+	if err != nil {
+		return err
+	}
+	if len(operands) == 0 {
+		return usageError("missing operand")
+	}
+
+	var mode func(fs.FileMode) fs.FileMode
+	if m, ok := f['m']; ok {
+		if mode, err = parseMode(m); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range operands {
+		p := c.path(name)
+		if f.has("p") {
+			err = os.MkdirAll(p, 0o777)
+		} else {
+			err = os.Mkdir(p, 0o777)
+		}
+		if err == nil && mode != nil {
+			err = os.Chmod(p, mode(0o777))
+		}
+		if err != nil {
+			c.fail(err)
+		}
+	}
+
+	return nil
+}
+
+// touch [-c] FILE...
+func touch(c *call, args []string) error {
+	f, operands, err := parseFlags(args, "c", map[string]byte{"no-create": 'c'})
+	if err != nil {
+		return err
+	}
+	if len(operands) == 0 {
+		return usageError("missing file operand")
+	}
+
+	now := time.Now()
+	for _, name := range operands {
+		p := c.path(name)
+		err := os.Chtimes(p, now, now)
+		if errors.Is(err, fs.ErrNotExist) {
+			if f.has("c") {
+				continue
+			}
+
+			var file *os.File
+			if file, err = os.OpenFile(p, os.O_WRONLY|os.O_CREATE, 0o666); err == nil {
+				err = file.Close()
+			}
+		}
+		if err != nil {
+			c.fail(err)
+		}
+	}
+
+	return nil
+}
+
+// cp [-rf] SOURCE... DEST
+//
+// With -r, directories are copied recursively and symbolic links are copied
+// as links; otherwise links are followed.
+func cp(c *call, args []string) error {
+	f, operands, err := parseFlags(args, "rRf", map[string]byte{"recursive": 'r', "force": 'f'})
+	if err != nil {
+		return err
+	}
+
+	return transfer(c, operands, func(src, dst string) error {
+		return copyPath(src, dst, f.has("rR"), f.has("f"))
+	})
+}
+
+// mv [-f] SOURCE... DEST
+func mv(c *call, args []string) error {
+	_, operands, err := parseFlags(args, "f", map[string]byte{"force": 'f'})
+	if err != nil {
+		return err
+	}
+
+	return transfer(c, operands, func(src, dst string) error {
+		err := os.Rename(src, dst)
+		if !errors.Is(err, syscall.EXDEV) {
+			return err
+		}
+
+		// Across file systems, copy and remove the source.
+		if err := copyPath(src, dst, true, true); err != nil {
+			return err
+		}
+		return os.RemoveAll(src)
+	})
+}
+
+// transfer applies op to every source and its destination, for cp and mv:
+// with several sources or an existing directory as DEST, the sources go into
+// DEST under their own names.
+func transfer(c *call, operands []string, op func(src, dst string) error) error {
+	if len(operands) < 2 {
+		return usageError("missing destination operand")
+	}
+	sources, dest := operands[:len(operands)-1], operands[len(operands)-1]
+
+	info, err := os.Stat(c.path(dest))
+	into := err == nil && info.IsDir()
+	if len(sources) > 1 && !into {
+		return fmt.Errorf("target %s is not a directory", dest)
+	}
+
+	for _, src := range sources {
+		dst := c.path(dest)
+		if into {
+			dst = filepath.Join(dst, filepath.Base(filepath.Clean(src)))
+		}
+
+		if inside(c.path(src), dst) {
+			c.errorf("cannot copy or move %s into itself", src)
+			continue
+		}
+		if err := op(c.path(src), dst); err != nil {
+			c.fail(err)
+		}
+	}
+
+	return nil
+}
+
+// inside reports whether path is dir or lies within it.
+func inside(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && filepath.IsLocal(rel)
+}
+
+// copyPath copies src to dst. A directory is only copied when recursive,
+// along with its contents, and recursive copies links as links. With force,
+// a destination file that can't be opened is removed and created anew.
+func copyPath(src, dst string, recursive, force bool) error {
+	stat := os.Stat
+	if recursive {
+		stat = os.Lstat
+	}
+	info, err := stat(src)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		if force {
+			_ = os.Remove(dst)
+		}
+		return os.Symlink(target, dst)
+	case info.IsDir():
+		if !recursive {
+			return fmt.Errorf("-r not specified; omitting directory %s", src)
+		}
+		if err := os.MkdirAll(dst, info.Mode().Perm()|0o700); err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := copyPath(filepath.Join(src, e.Name()), filepath.Join(dst, e.Name()), recursive, force); err != nil {
+				return err
+			}
+		}
+		return os.Chmod(dst, info.Mode().Perm())
+	}
+
+	if dstInfo, err := os.Stat(dst); err == nil && os.SameFile(info, dstInfo) {
+		return fmt.Errorf("%s and %s are the same file", src, dst)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil && force {
+		_ = os.Remove(dst)
+		out, err = os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// ln [-sf] TARGET [LINK], or ln [-sf] TARGET... DIR
+func ln(c *call, args []string) error {
+	f, operands, err := parseFlags(args, "sf", map[string]byte{"symbolic": 's', "force": 'f'})
+	if err != nil {
+		return err
+	}
+	if len(operands) == 0 {
+		return usageError("missing file operand")
+	}
+	if len(operands) == 1 {
+		operands = append(operands, ".")
+	}
+
+	targets, dest := operands[:len(operands)-1], operands[len(operands)-1]
+	info, err := os.Stat(c.path(dest))
+	into := err == nil && info.IsDir()
+	if len(targets) > 1 && !into {
+		return fmt.Errorf("target %s is not a directory", dest)
+	}
+
+	for _, target := range targets {
+		link := c.path(dest)
+		if into {
+			link = filepath.Join(link, filepath.Base(filepath.Clean(target)))
+		}
+		if f.has("f") {
+			_ = os.Remove(link)
+		}
+
+		// A symbolic link's target stays as given: it is relative to the
+		// link, not to the shell's directory.
+		if f.has("s") {
+			err = os.Symlink(target, link)
+		} else {
+			err = os.Link(c.path(target), link)
+		}
+		if err != nil {
+			c.fail(err)
+		}
+	}
+
+	return nil
+}
+
+// chmod [-R] MODE FILE...
+func chmod(c *call, args []string) error {
+	f, operands, err := parseFlags(args, "R", map[string]byte{"recursive": 'R'})
+	if err != nil {
+		return err
+	}
+	if len(operands) < 2 {
+		return usageError("missing operand")
+	}
+
+	mode, err := parseMode(operands[0])
+	if err != nil {
+		return err
+	}
+
+	apply := func(p string) error {
+		info, err := os.Stat(p)
+		if err != nil {
+			return err
+		}
+		return os.Chmod(p, mode(info.Mode().Perm()))
+	}
+
+	for _, name := range operands[1:] {
+		if !f.has("R") {
+			if err := apply(c.path(name)); err != nil {
+				c.fail(err)
+			}
+			continue
+		}
+
+		err := filepath.WalkDir(c.path(name), func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.Type()&fs.ModeSymlink != 0 {
+				return nil
+			}
+			return apply(p)
+		})
+		if err != nil {
+			c.fail(err)
+		}
+	}
+
+	return nil
+}
+
+// parseMode parses an octal mode like 755 or a symbolic one like u+x,go-w
+// into a function computing the new permissions from the current ones.
+func parseMode(s string) (func(fs.FileMode) fs.FileMode, error) {
+	if n, err := strconv.ParseUint(s, 8, 32); err == nil {
+		if n > 0o777 {
+			return nil, fmt.Errorf("unsupported mode %s: only permission bits can be set", s)
+		}
+		return func(fs.FileMode) fs.FileMode { return fs.FileMode(n) }, nil
+	}
+
+	invalid := fmt.Errorf("invalid mode %q", s)
+	var ops []func(fs.FileMode) fs.FileMode
+	for clause := range strings.SplitSeq(s, ",") {
+		var who fs.FileMode
+		i := 0
+		for ; i < len(clause) && strings.IndexByte("ugoa", clause[i]) >= 0; i++ {
+			who |= map[byte]fs.FileMode{'u': 0o700, 'g': 0o070, 'o': 0o007, 'a': 0o777}[clause[i]]
+		}
+		if who == 0 {
+			who = 0o777
+		}
+		if i == len(clause) {
+			return nil, invalid
+		}
+
+		for i < len(clause) {
+			op := clause[i]
+			if strings.IndexByte("+-=", op) < 0 {
+				return nil, invalid
+			}
+			var perm fs.FileMode
+			for i++; i < len(clause) && strings.IndexByte("rwx", clause[i]) >= 0; i++ {
+				perm |= map[byte]fs.FileMode{'r': 0o444, 'w': 0o222, 'x': 0o111}[clause[i]]
+			}
+			bits := perm & who
+
+			ops = append(ops, func(m fs.FileMode) fs.FileMode {
+				switch op {
+				case '+':
+					return m | bits
+				case '-':
+					return m &^ bits
+				default:
+					return m&^who | bits
+				}
+			})
+		}
+	}
+
+	return func(m fs.FileMode) fs.FileMode {
+		for _, op := range ops {
+			m = op(m)
+		}
+		return m
+	}, nil
+}
+
+// mktemp [-d] [-p DIR] [TEMPLATE]
+//
+// TEMPLATE ends in at least three X, replaced by random characters; without
+// it, the name is tmp.XXXXXXXXXX. The file goes in DIR, or $TMPDIR unless
+// the template has a directory.
+func mktemp(c *call, args []string) error {
+	f, operands, err := parseFlags(args, "dp:", map[string]byte{"directory": 'd', "tmpdir": 'p'})
+	if err != nil {
+		return err
+	}
+	if len(operands) > 1 {
+		return usageError("too many templates")
+	}
+
+	template := "tmp.XXXXXXXXXX"
+	if len(operands) == 1 {
+		template = operands[0]
+	}
+	pattern := strings.TrimRight(template, "X")
+	if len(template)-len(pattern) < 3 {
+		return fmt.Errorf("too few X's in template %q", template)
+	}
+	pattern += "*"
+
+	dir, ok := f['p']
+	switch {
+	case ok:
+	case filepath.Dir(template) != ".":
+		dir, pattern = filepath.Split(pattern)
+	default:
+		if dir = c.hc.Env.Get("TMPDIR").String(); dir == "" {
+			dir = os.TempDir()
+		}
+	}
+	dir = c.path(dir)
+
+	var name string
+	if f.has("d") {
+		name, err = os.MkdirTemp(dir, pattern)
+	} else {
+		var file *os.File
+		if file, err = os.CreateTemp(dir, pattern); err == nil {
+			name, err = file.Name(), file.Close()
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(c.hc.Stdout, name)
+	return err
+}
+
+// ls [-aAl1] [FILE...]
+func ls(c *call, args []string) error {
+	f, operands, err := parseFlags(args, "aAl1", map[string]byte{"all": 'a', "almost-all": 'A'})
+	if err != nil {
+		return err
+	}
+	if len(operands) == 0 {
+		operands = []string{"."}
+	}
+
+	var files []listing
+	var dirs []string
+	for _, name := range operands {
+		info, err := os.Stat(c.path(name))
+		if err != nil {
+			// A dangling link is still listed.
+			if info, err = os.Lstat(c.path(name)); err != nil {
+				c.fail(err)
+				continue
+			}
+		}
+		if info.IsDir() {
+			dirs = append(dirs, name)
+		} else {
+			files = append(files, listing{name, c.path(name), info})
+		}
+	}
+	slices.SortFunc(files, func(a, b listing) int { return strings.Compare(a.name, b.name) })
+	slices.Sort(dirs)
+
+	w := c.hc.Stdout
+	printList(w, files, f.has("l"))
+	for i, dir := range dirs {
+		if len(files) > 0 || i > 0 {
+			_, _ = fmt.Fprintln(w)
+		}
+		if len(operands) > 1 {
+			_, _ = fmt.Fprintf(w, "%s:\n", dir)
+		}
+
+		entries, err := os.ReadDir(c.path(dir))
+		if err != nil {
+			c.fail(err)
+			continue
+		}
+
+		var list []listing
+		if f.has("a") {
+			for _, name := range []string{".", ".."} {
+				if info, err := os.Stat(filepath.Join(c.path(dir), name)); err == nil {
+					list = append(list, listing{name, filepath.Join(c.path(dir), name), info})
+				}
+			}
+		}
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), ".") && !f.has("aA") {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil {
+				c.fail(err)
+				continue
+			}
+			list = append(list, listing{e.Name(), filepath.Join(c.path(dir), e.Name()), info})
+		}
+		printList(w, list, f.has("l"))
+	}
+
+	return nil
+}
+
+// listing is a file ls lists, by the name it shows.
+type listing struct {
+	name string
+	path string
+	info fs.FileInfo
+}
+
+// printList prints one file per line, with -l as its mode, size,
+// modification time, name and, for a link, its target.
+func printList(w io.Writer, list []listing, long bool) {
+	if !long {
+		for _, l := range list {
+			_, _ = fmt.Fprintln(w, l.name)
+		}
+		return
+	}
+
+	width := 0
+	for _, l := range list {
+		width = max(width, len(strconv.FormatInt(l.info.Size(), 10)))
+	}
+
+	sixMonthsAgo := time.Now().AddDate(0, -6, 0)
+	for _, l := range list {
+		layout := "Jan _2 15:04"
+		if l.info.ModTime().Before(sixMonthsAgo) {
+			layout = "Jan _2  2006"
+		}
+		name := l.name
+		if l.info.Mode()&fs.ModeSymlink != 0 {
+			if target, err := os.Readlink(l.path); err == nil {
+				name += " -> " + target
+			}
+		}
+		_, _ = fmt.Fprintf(w, "%s %*d %s %s\n", l.info.Mode(), width, l.info.Size(), l.info.ModTime().Format(layout), name)
+	}
+}
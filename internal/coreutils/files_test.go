@@ -0,0 +1,174 @@
+package coreutils
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "src", "a.txt"), "a")
+	writeFile(t, filepath.Join(dir, "src", "sub", "b.txt"), "b")
+
+	script := `
+mkdir -p out/nested build
+touch out/empty
+cp src/a.txt out/
+cp -r src copy
+mv copy moved
+ln -s a.txt src/link
+chmod 600 out/a.txt
+chmod 644 out/empty
+chmod u+x,go-r out/empty
+rm -rf build
+`
+	if _, stderr, err := run(t, dir, script); err != nil {
+		t.Fatalf("%v: %s", err, stderr)
+	}
+
+	for name, want := range map[string]string{
+		"out/a.txt":           "a",
+		"moved/a.txt":         "a",
+		"moved/sub/b.txt":     "b",
+		"out/empty":           "",
+		"src/link":            "a",
+		"src/sub/b.txt":       "b",
+		"out/nested/../a.txt": "a",
+	} {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(b) != want {
+			t.Errorf("%s = %q, %v; want %q", name, b, err, want)
+		}
+	}
+
+	for name, want := range map[string]fs.FileMode{"out/a.txt": 0o600, "out/empty": 0o700} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil || info.Mode().Perm() != want {
+			t.Errorf("%s mode = %v, %v; want %v", name, info.Mode().Perm(), err, want)
+		}
+	}
+
+	for _, name := range []string{"copy", "build"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s must not exist, got %v", name, err)
+		}
+	}
+}
+
+func TestFiles_Errors(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "d", "f"), "")
+
+	for script, want := range map[string]string{
+		"rm d":           "rm: cannot remove d: is a directory",
+		"rm missing":     "rm: lstat missing: no such file or directory",
+		"rm -rf .":       `rm: refusing to remove "."`,
+		"rm -rf /":       "rm: refusing to remove the root directory /",
+		"cp d e":         "cp: -r not specified; omitting directory",
+		"cp -r d d/e":    "cp: cannot copy or move d into itself",
+		"cp d/f x y":     "cp: target y is not a directory",
+		"mkdir d":        "mkdir: mkdir d: file exists",
+		"rmdir d/f":      "rmdir: failed to remove d/f: not a directory",
+		"chmod +z d/f":   `chmod: invalid mode "+z"`,
+		"chmod 1755 d/f": "chmod: unsupported mode 1755",
+		"mktemp XX":      `mktemp: too few X's in template "XX"`,
+	} {
+		_, stderr, err := run(t, dir, script)
+		if err == nil || !strings.HasPrefix(stderr, want) {
+			t.Errorf("%s: got %v, %q; want %q", script, err, stderr, want)
+		}
+	}
+
+	// -f ignores missing files.
+	if _, stderr, err := run(t, dir, "rm -f missing && rm -f"); err != nil {
+		t.Errorf("rm -f: %v: %s", err, stderr)
+	}
+}
+
+func Test_parseMode(t *testing.T) {
+	tests := []struct {
+		mode string
+		from fs.FileMode
+		want fs.FileMode
+	}{
+		{"755", 0o600, 0o755},
+		{"+x", 0o644, 0o755},
+		{"u+x", 0o644, 0o744},
+		{"go-w", 0o666, 0o644},
+		{"a=r", 0o755, 0o444},
+		{"u=rwx,g=rx,o=", 0o777, 0o750},
+		{"u+w-x", 0o500, 0o600},
+	}
+
+	for _, tt := range tests {
+		mode, err := parseMode(tt.mode)
+		if err != nil {
+			t.Errorf("parseMode(%q): %v", tt.mode, err)
+			continue
+		}
+		if got := mode(tt.from); got != tt.want {
+			t.Errorf("parseMode(%q)(%v) = %v, want %v", tt.mode, tt.from, got, tt.want)
+		}
+	}
+
+	for _, s := range []string{"", "u", "x+", "9", "u+r,", "q+r"} {
+		if _, err := parseMode(s); err == nil {
+			t.Errorf("parseMode(%q) must fail", s)
+		}
+	}
+}
+
+func TestLs(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "d", "b"), "")
+	writeFile(t, filepath.Join(dir, "d", "a"), "12345")
+	writeFile(t, filepath.Join(dir, "d", ".hidden"), "")
+	writeFile(t, filepath.Join(dir, "f"), "")
+
+	for script, want := range map[string]string{
+		"ls d":       "a\nb\n",
+		"ls -A d":    ".hidden\na\nb\n",
+		"ls -a d":    ".\n..\n.hidden\na\nb\n",
+		"ls d/a f":   "d/a\nf\n",
+		"ls f d":     "f\n\nd:\na\nb\n",
+		"cd d && ls": "a\nb\n",
+	} {
+		stdout, stderr, err := run(t, dir, script)
+		if err != nil || stdout != want {
+			t.Errorf("%s = %q, %v (%s); want %q", script, stdout, err, stderr, want)
+		}
+	}
+
+	stdout, _, err := run(t, dir, "ls -l d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "-rw-r--r-- 5 ") || !strings.HasSuffix(lines[0], " a") || !strings.HasPrefix(lines[1], "-rw-r--r-- 0 ") {
+		t.Errorf("unexpected long listing %q", stdout)
+	}
+}
+
+func TestMktemp(t *testing.T) {
+	dir := t.TempDir()
+
+	stdout, stderr, err := run(t, dir, "mktemp -d -p . build.XXXXXX")
+	if err != nil {
+		t.Fatalf("%v: %s", err, stderr)
+	}
+	name := strings.TrimSpace(stdout)
+	if info, err := os.Stat(name); err != nil || !info.IsDir() || !strings.HasPrefix(filepath.Base(name), "build.") || filepath.Dir(name) != dir {
+		t.Errorf("unexpected temporary directory %q: %v", name, err)
+	}
+
+	stdout, stderr, err = run(t, dir, "TMPDIR="+dir+" mktemp")
+	if err != nil {
+		t.Fatalf("%v: %s", err, stderr)
+	}
+	if name := strings.TrimSpace(stdout); filepath.Dir(name) != dir || !strings.HasPrefix(filepath.Base(name), "tmp.") {
+		t.Errorf("unexpected temporary file %q", name)
+	}
+}
@@ -0,0 +1,332 @@
+package coreutils
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cat [FILE...]
+//
+// "-" or no FILE reads stdin.
+func cat(c *call, args []string) error {
+	_, operands, err := parseFlags(args, "", nil)
+	if err != nil {
+		return err
+	}
+
+	return eachInput(c, operands, func(_ string, r io.Reader) error {
+		_, err := io.Copy(c.hc.Stdout, r)
+		return err
+	})
+}
+
+// head [-n LINES] [FILE...]
+func head(c *call, args []string) error {
+	f, operands, err := parseFlags(args, "n:", map[string]byte{"lines": 'n'})
+	if err != nil {
+		return err
+	}
+	n, _, err := lineCount(f, false)
+	if err != nil {
+		return err
+	}
+
+	return eachInputWithHeader(c, operands, func(r io.Reader) error {
+		br := bufio.NewReader(r)
+		for range n {
+			line, err := br.ReadBytes('\n')
+			if _, werr := c.hc.Stdout.Write(line); werr != nil {
+				return werr
+			}
+			if err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// tail [-n [+]LINES] [FILE...]
+//
+// With +LINES, tail prints from that line on.
+func tail(c *call, args []string) error {
+	f, operands, err := parseFlags(args, "n:", map[string]byte{"lines": 'n'})
+	if err != nil {
+		return err
+	}
+	n, from, err := lineCount(f, true)
+	if err != nil {
+		return err
+	}
+
+	return eachInputWithHeader(c, operands, func(r io.Reader) error {
+		br := bufio.NewReader(r)
+		var last [][]byte
+		for i := 1; ; i++ {
+			line, err := br.ReadBytes('\n')
+			if len(line) > 0 {
+				switch {
+				case from && i >= n:
+					if _, werr := c.hc.Stdout.Write(line); werr != nil {
+						return werr
+					}
+				case !from && n > 0:
+					if len(last) == n {
+						last = last[1:]
+					}
+					last = append(last, line)
+				}
+			}
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return err
+			}
+		}
+
+		_, err := c.hc.Stdout.Write(bytes.Join(last, nil))
+		return err
+	})
+}
+
+// lineCount reads the -n option of head and tail, 10 by default. With plus,
+// a leading "+" counts from the start; from reports it.
+func lineCount(f flags, plus bool) (n int, from bool, err error) {
+	s, ok := f['n']
+	if !ok {
+		return 10, false, nil
+	}
+	if plus && strings.HasPrefix(s, "+") {
+		s, from = s[1:], true
+	}
+
+	n, err = strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, false, usageError(fmt.Sprintf("invalid number of lines %q", f['n']))
+	}
+
+	return n, from, nil
+}
+
+// wc [-lwc] [FILE...]
+//
+// Without options, wc counts lines, words and bytes.
+func wc(c *call, args []string) error {
+	f, operands, err := parseFlags(args, "lwc", map[string]byte{"lines": 'l', "words": 'w', "bytes": 'c'})
+	if err != nil {
+		return err
+	}
+	if !f.has("lwc") {
+		f = flags{'l': "", 'w': "", 'c': ""}
+	}
+
+	type counts struct {
+		name                string
+		lines, words, bytes int
+	}
+	var all []counts
+	var total counts
+	err = eachInput(c, operands, func(name string, r io.Reader) error {
+		cnt := counts{name: name}
+		br := bufio.NewReader(r)
+		inWord := false
+		for {
+			b, err := br.ReadByte()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return err
+			}
+
+			cnt.bytes++
+			if b == '\n' {
+				cnt.lines++
+			}
+			space := strings.IndexByte(" \t\n\v\f\r", b) >= 0
+			if !space && !inWord {
+				cnt.words++
+			}
+			inWord = !space
+		}
+
+		all = append(all, cnt)
+		total.lines, total.words, total.bytes = total.lines+cnt.lines, total.words+cnt.words, total.bytes+cnt.bytes
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(all) > 1 {
+		total.name = "total"
+		all = append(all, total)
+	}
+
+	// The columns fit the largest count shown, unless it is the only one.
+	width := 0
+	if len(f) > 1 || len(all) > 1 {
+		for flag, count := range map[byte]int{'l': total.lines, 'w': total.words, 'c': total.bytes} {
+			if f.has(string(flag)) {
+				width = max(width, len(strconv.Itoa(count)))
+			}
+		}
+	}
+	for _, cnt := range all {
+		var fields []string
+		for _, v := range []struct {
+			flag  byte
+			count int
+		}{{'l', cnt.lines}, {'w', cnt.words}, {'c', cnt.bytes}} {
+			if f.has(string(v.flag)) {
+				fields = append(fields, fmt.Sprintf("%*d", width, v.count))
+			}
+		}
+		if cnt.name != "" {
+			fields = append(fields, cnt.name)
+		}
+		if _, err := fmt.Fprintln(c.hc.Stdout, strings.Join(fields, " ")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// eachInput calls fn with every named file or, for "-" or no names, stdin,
+// under the name "". A file that can't be opened is reported and skipped.
+func eachInput(c *call, names []string, fn func(name string, r io.Reader) error) error {
+	if len(names) == 0 {
+		names = []string{"-"}
+	}
+
+	for _, name := range names {
+		if name == "-" {
+			stdin := c.hc.Stdin
+			if stdin == nil {
+				stdin = strings.NewReader("")
+			}
+			if err := fn("", stdin); err != nil {
+				return err
+			}
+			continue
+		}
+
+		file, err := os.Open(c.path(name))
+		if err != nil {
+			c.fail(err)
+			continue
+		}
+		err = fn(name, file)
+		_ = file.Close()
+		if err != nil {
+			c.fail(err)
+		}
+	}
+
+	return nil
+}
+
+// eachInputWithHeader is eachInput for head and tail, which precede each
+// input with a "==> name <==" header when there are several.
+func eachInputWithHeader(c *call, names []string, fn func(r io.Reader) error) error {
+	first := true
+	return eachInput(c, names, func(name string, r io.Reader) error {
+		if len(names) > 1 {
+			if name == "" {
+				name = "standard input"
+			}
+			sep := "\n"
+			if first {
+				sep, first = "", false
+			}
+			if _, err := fmt.Fprintf(c.hc.Stdout, "%s==> %s <==\n", sep, name); err != nil {
+				return err
+			}
+		}
+		return fn(r)
+	})
+}
+
+// basename NAME [SUFFIX]
+func basename(c *call, args []string) error {
+	_, operands, err := parseFlags(args, "", nil)
+	if err != nil {
+		return err
+	}
+	if len(operands) == 0 || len(operands) > 2 {
+		return usageError("usage: basename NAME [SUFFIX]")
+	}
+
+	base := filepath.Base(operands[0])
+	if len(operands) == 2 && base != operands[1] {
+		base = strings.TrimSuffix(base, operands[1])
+	}
+
+	_, err = fmt.Fprintln(c.hc.Stdout, base)
+	return err
+}
+
+// dirname NAME...
+func dirname(c *call, args []string) error {
+	_, operands, err := parseFlags(args, "", nil)
+	if err != nil {
+		return err
+	}
+	if len(operands) == 0 {
+		return usageError("missing operand")
+	}
+
+	for _, name := range operands {
+		if _, err := fmt.Fprintln(c.hc.Stdout, filepath.Dir(name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sleep NUMBER[SUFFIX]...
+//
+// SUFFIX is s (the default), m, h or d; sleep waits for the sum of the
+// durations, or until the command is canceled.
+func sleep(c *call, args []string) error {
+	_, operands, err := parseFlags(args, "", nil)
+	if err != nil {
+		return err
+	}
+	if len(operands) == 0 {
+		return usageError("missing operand")
+	}
+
+	var d time.Duration
+	for _, s := range operands {
+		unit := time.Second
+		if i := len(s) - 1; i > 0 {
+			if u, ok := map[byte]time.Duration{'s': time.Second, 'm': time.Minute, 'h': time.Hour, 'd': 24 * time.Hour}[s[i]]; ok {
+				s, unit = s[:i], u
+			}
+		}
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil || n < 0 {
+			return usageError(fmt.Sprintf("invalid time interval %q", s))
+		}
+		d += time.Duration(n * float64(unit))
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-c.ctx.Done():
+		return c.ctx.Err()
+	}
+}
@@ -0,0 +1,57 @@
+package coreutils
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestText(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "lines"), "1\n2\n3\n4\n5\n")
+	writeFile(t, filepath.Join(dir, "words"), "one two\nthree")
+
+	for script, want := range map[string]string{
+		"cat lines words":                       "1\n2\n3\n4\n5\none two\nthree",
+		"echo in | cat - words":                 "in\none two\nthree",
+		"head -n 2 lines":                       "1\n2\n",
+		"head -n2 words":                        "one two\nthree",
+		"seq() { cat lines; }; seq | head -n 1": "1\n",
+		"tail -n 2 lines":                       "4\n5\n",
+		"tail -n +4 lines":                      "4\n5\n",
+		"tail -n 1 words":                       "three",
+		"head -n 1 lines words":                 "==> lines <==\n1\n\n==> words <==\none two\n",
+		"wc -l lines":                           "5 lines\n",
+		"wc words":                              " 1  3 13 words\n",
+		"cat words | wc -w":                     "3\n",
+		"wc -l lines words":                     "5 lines\n1 words\n6 total\n",
+		"basename /a/b/c.txt .txt":              "c\n",
+		"basename .txt .txt":                    ".txt\n",
+		"dirname /a/b/c.txt d":                  "/a/b\n.\n",
+		"sleep 0.01 0s && echo done":            "done\n",
+	} {
+		stdout, stderr, err := run(t, dir, script)
+		if err != nil || stdout != want {
+			t.Errorf("%s = %q, %v (%s); want %q", script, stdout, err, stderr, want)
+		}
+	}
+
+	for _, script := range []string{"head -n x lines", "tail -n -1 lines", "sleep", "sleep 1y", "basename"} {
+		if _, _, err := run(t, dir, script); err == nil {
+			t.Errorf("%s must fail", script)
+		}
+	}
+}
+
+func TestSleep_Canceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, _, err := runContext(ctx, t, t.TempDir(), "sleep 10")
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > time.Second {
+		t.Errorf("sleep must stop when canceled, got %v after %s", err, time.Since(start))
+	}
+}
@@ -101,3 +101,82 @@ func ReadEnvFile(filename string) (map[string]string, error) {
 
 	return envs, nil
 }
+
+// ReadExportFile reads a file commands append exported variables to, in the
+// format CI systems use for step outputs: "KEY=value" lines, and multi-line
+// values as a heredoc,
+//
+//	KEY<<DELIMITER
+//	line 1
+//	line 2
+//	DELIMITER
+//
+// Blank lines are skipped; a later entry for a key replaces an earlier one.
+func ReadExportFile(filename string) (map[string]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer iox.Close(f)
+
+	envs := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		eq, heredoc := strings.Index(line, "="), strings.Index(line, "<<")
+		if heredoc < 0 || (eq >= 0 && eq < heredoc) {
+			k, v, found := strings.Cut(line, "=")
+			if !found || !isName(k) {
+				return nil, fmt.Errorf("line %d: invalid entry %q, want KEY=value or KEY<<DELIMITER", n, line)
+			}
+			envs[k] = v
+			continue
+		}
+
+		k, delim := line[:heredoc], line[heredoc+2:]
+		if !isName(k) || delim == "" {
+			return nil, fmt.Errorf("line %d: invalid entry %q, want KEY=value or KEY<<DELIMITER", n, line)
+		}
+
+		start := n
+		var value []string
+		closed := false
+		for scanner.Scan() {
+			n++
+			if scanner.Text() == delim {
+				closed = true
+				break
+			}
+			value = append(value, scanner.Text())
+		}
+		if !closed {
+			return nil, fmt.Errorf("line %d: %s is missing its closing delimiter %q", start, k, delim)
+		}
+		envs[k] = strings.Join(value, "\n")
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return envs, nil
+}
+
+// isName reports whether s is a valid POSIX environment variable name.
+func isName(s string) bool {
+	if s == "" || !isNameStart(s[0]) {
+		return false
+	}
+
+	for i := 1; i < len(s); i++ {
+		if !isNameStart(s[i]) && (s[i] < '0' || s[i] > '9') {
+			return false
+		}
+	}
+
+	return true
+}
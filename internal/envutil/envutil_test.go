@@ -1,6 +1,7 @@
 package envutil
 
 import (
+	"maps"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -148,3 +149,38 @@ func TestReadEnvFile_notExist(t *testing.T) {
 		t.Error("expected error for missing file, got nil")
 	}
 }
+
+func TestReadExportFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"key value", "A=1\n\nB=x=y\n", map[string]string{"A": "1", "B": "x=y"}, false},
+		{"later wins", "A=1\nA=2\n", map[string]string{"A": "2"}, false},
+		{"empty value", "A=\n", map[string]string{"A": ""}, false},
+		{"heredoc", "NOTES<<EOF\nline 1\n\nline 3\nEOF\nB=2\n", map[string]string{"NOTES": "line 1\n\nline 3", "B": "2"}, false},
+		{"heredoc marker in value", "A=x<<y\n", map[string]string{"A": "x<<y"}, false},
+		{"unterminated heredoc", "A<<EOF\nline\n", nil, true},
+		{"no separator", "A\n", nil, true},
+		{"invalid name", "1A=x\n", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "env")
+			if err := os.WriteFile(file, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ReadExportFile(file)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadExportFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !maps.Equal(got, tt.want) {
+				t.Errorf("ReadExportFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+// Package history persists a record of every run so it can be listed,
+// replayed and analyzed after the terminal has scrolled away.
+package history
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultKeep is the number of runs retained when the config sets no limit.
+const DefaultKeep = 100
+
+// TailBytes caps how much of each stream a stored record keeps; history is a
+// record of what happened, not a log archive (see --log-dir for that).
+const TailBytes = 64 * 1024
+
+// ErrRunNotFound occurs when no stored run matches the requested id.
+var ErrRunNotFound = errors.New("run not found")
+
+// Run is the stored record of one taskctl invocation.
+type Run struct {
+	ID         string    `json:"id"`
+	Config     string    `json:"config,omitempty"`
+	ConfigHash string    `json:"config_hash,omitempty"`
+	Targets    []string  `json:"targets"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	Tasks      []Task    `json:"tasks"`
+}
+
+// Task is the stored record of one task or pipeline stage within a run.
+type Task struct {
+	Name       string    `json:"name"`
+	Task       string    `json:"task,omitempty"`
+	Status     string    `json:"status"`
+	ExitCode   int       `json:"exit_code"`
+	Start      time.Time `json:"start,omitzero"`
+	DurationMs int64     `json:"duration_ms"`
+	Stdout     string    `json:"stdout,omitempty"`
+	Stderr     string    `json:"stderr,omitempty"`
+	LogFiles   []string  `json:"log_files,omitempty"`
+}
+
+// Duration returns the run's wall-clock duration.
+func (r *Run) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+// Store keeps run records as one JSON file per run in Dir, pruning the oldest
+// beyond Keep runs (negative for no limit) or older than MaxAge (zero for no
+// limit).
+type Store struct {
+	Dir    string
+	Keep   int
+	MaxAge time.Duration
+}
+
+// NewStore creates a Store in dir with the default retention.
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir, Keep: DefaultKeep}
+}
+
+// DefaultDir returns the per-project store under the user's ~/.taskctl,
+// keyed by the config file so separate projects keep separate histories.
+func DefaultDir(homeDir, configFile string) string {
+	sum := sha256.Sum256([]byte(configFile))
+	return filepath.Join(homeDir, ".taskctl", "history", hex.EncodeToString(sum[:])[:12])
+}
+
+// NewRunID returns a sortable, practically unique id for a run started at t.
+func NewRunID(t time.Time) string {
+	b := make([]byte, 2)
+	_, _ = rand.Read(b)
+	return t.UTC().Format("20060102T150405") + "-" + hex.EncodeToString(b)
+}
+
+// HashFile returns the hex sha256 of the file's contents, or an empty string
+// when it cannot be read (e.g. a remote config).
+func HashFile(name string) string {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Tail returns at most the last TailBytes of s, cut at a line boundary when
+// truncated.
+func Tail(s string) string {
+	if len(s) <= TailBytes {
+		return s
+	}
+
+	s = s[len(s)-TailBytes:]
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+
+	return s
+}
+
+// Save writes r to the store and applies the retention settings.
+func (s *Store) Save(r *Run) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(s.Dir, r.ID+".json"), data, 0o644); err != nil {
+		return err
+	}
+
+	_, err = s.Prune()
+	return err
+}
+
+// List returns every stored run, newest first. Unreadable records are skipped
+// so one corrupt file does not hide the rest of the history.
+func (s *Store) List() ([]*Run, error) {
+	ids, err := s.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]*Run, 0, len(ids))
+	for _, id := range ids {
+		r, err := s.read(id)
+		if err != nil {
+			continue
+		}
+		runs = append(runs, r)
+	}
+
+	return runs, nil
+}
+
+// Load returns the run with the given id. "last" selects the most recent run,
+// and an unambiguous id prefix is accepted.
+func (s *Store) Load(id string) (*Run, error) {
+	ids, err := s.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	if id == "last" {
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("%s: %w", id, ErrRunNotFound)
+		}
+		return s.read(ids[0])
+	}
+
+	var matches []string
+	for _, v := range ids {
+		if v == id {
+			return s.read(v)
+		}
+		if strings.HasPrefix(v, id) {
+			matches = append(matches, v)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%s: %w", id, ErrRunNotFound)
+	case 1:
+		return s.read(matches[0])
+	default:
+		return nil, fmt.Errorf("run id %q is ambiguous: matches %s", id, strings.Join(matches, ", "))
+	}
+}
+
+// Prune deletes runs beyond the retention limits and reports how many were
+// removed.
+func (s *Store) Prune() (int, error) {
+	ids, err := s.ids()
+	if err != nil {
+		return 0, err
+	}
+
+	var removed int
+	for i, id := range ids {
+		expired := s.Keep >= 0 && i >= s.Keep
+		if !expired && s.MaxAge > 0 {
+			if start, err := time.Parse("20060102T150405", strings.SplitN(id, "-", 2)[0]); err == nil {
+				expired = time.Since(start) > s.MaxAge
+			}
+		}
+		if !expired {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(s.Dir, id+".json")); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+// ids returns the stored run ids, newest first. A missing store is empty.
+func (s *Store) ids() ([]string, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if id, ok := strings.CutSuffix(e.Name(), ".json"); ok && !e.IsDir() {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	slices.Reverse(ids)
+
+	return ids, nil
+}
+
+func (s *Store) read(id string) (*Run, error) {
+	data, err := os.ReadFile(filepath.Join(s.Dir, id+".json"))
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Run{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("%s: %w", id, err)
+	}
+
+	return r, nil
+}
@@ -0,0 +1,84 @@
+package history
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore_SaveListLoad(t *testing.T) {
+	s := NewStore(t.TempDir())
+
+	start := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	for i, status := range []string{"done", "failed"} {
+		r := &Run{ID: NewRunID(start.Add(time.Duration(i) * time.Minute)), Targets: []string{"build"}, Status: status}
+		if err := s.Save(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runs, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].Status != "failed" {
+		t.Fatalf("expected 2 runs, newest first, got %+v", runs)
+	}
+
+	last, err := s.Load("last")
+	if err != nil || last.ID != runs[0].ID {
+		t.Errorf("Load(last) = %v, %v; want %s", last, err, runs[0].ID)
+	}
+
+	byPrefix, err := s.Load(runs[1].ID[:18])
+	if err != nil || byPrefix.ID != runs[1].ID {
+		t.Errorf("Load(prefix) = %v, %v; want %s", byPrefix, err, runs[1].ID)
+	}
+
+	if _, err := s.Load("20250102T15"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("expected an ambiguous id error, got %v", err)
+	}
+
+	if _, err := s.Load("nope"); !errors.Is(err, ErrRunNotFound) {
+		t.Errorf("expected ErrRunNotFound, got %v", err)
+	}
+}
+
+func TestStore_Prune(t *testing.T) {
+	s := NewStore(t.TempDir())
+	s.Keep = 2
+
+	now := time.Now()
+	for i := range 4 {
+		if err := s.Save(&Run{ID: NewRunID(now.Add(time.Duration(i) * time.Second))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runs, _ := s.List()
+	if len(runs) != 2 {
+		t.Fatalf("Save must prune to Keep runs, got %d", len(runs))
+	}
+
+	s.Keep = -1
+	if err := s.Save(&Run{ID: NewRunID(now.Add(-48 * time.Hour))}); err != nil {
+		t.Fatal(err)
+	}
+	s.MaxAge = 24 * time.Hour
+	n, err := s.Prune()
+	if err != nil || n != 1 {
+		t.Errorf("Prune() = %d, %v; want the one expired run removed", n, err)
+	}
+}
+
+func TestTail(t *testing.T) {
+	if got := Tail("short\n"); got != "short\n" {
+		t.Errorf("Tail must keep short output as-is, got %q", got)
+	}
+
+	long := strings.Repeat("x", TailBytes) + "\nlast line\n"
+	if got := Tail(long); got != "last line\n" {
+		t.Errorf("Tail must cut at a line boundary, got %q", got)
+	}
+}
@@ -0,0 +1,148 @@
+package history
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// minTrendSamples is the fewest successful runs a trend is computed from; with
+// fewer, one slow outlier would read as a trend.
+const minTrendSamples = 4
+
+// Stats summarizes the recorded executions of one run, task or stage.
+type Stats struct {
+	Name     string
+	Runs     int
+	Failures int
+	P50      time.Duration
+	P95      time.Duration
+	// Trend is the relative change of the median duration of the newer half of
+	// the successful executions against the older half: 0.1 means 10% slower.
+	// It is zero when there are too few executions to tell.
+	Trend float64
+}
+
+// FailureRate returns the share of executions that failed.
+func (s *Stats) FailureRate() float64 {
+	if s.Runs == 0 {
+		return 0
+	}
+
+	return float64(s.Failures) / float64(s.Runs)
+}
+
+// sample is one finished execution, in chronological order within a series.
+type sample struct {
+	duration time.Duration
+	failed   bool
+}
+
+// RunStats summarizes the given runs as a whole, labeled name.
+func RunStats(name string, runs []*Run) Stats {
+	series := make([]sample, 0, len(runs))
+	for _, r := range slices.Backward(runs) {
+		series = append(series, sample{duration: r.Duration(), failed: r.Status == "failed" || r.Status == "timed_out"})
+	}
+
+	return summarize(name, series)
+}
+
+// TaskStats summarizes every task and stage recorded in runs (newest first, as
+// returned by List), sorted by name. Skipped and canceled executions say
+// nothing about a task's duration or reliability and are left out.
+func TaskStats(runs []*Run) []Stats {
+	series := map[string][]sample{}
+	for _, r := range slices.Backward(runs) {
+		for _, t := range r.Tasks {
+			if t.Status != "done" && t.Status != "failed" && t.Status != "timed_out" {
+				continue
+			}
+			series[t.Name] = append(series[t.Name], sample{
+				duration: time.Duration(t.DurationMs) * time.Millisecond,
+				failed:   t.Status == "failed" || t.Status == "timed_out",
+			})
+		}
+	}
+
+	stats := make([]Stats, 0, len(series))
+	for name, s := range series {
+		stats = append(stats, summarize(name, s))
+	}
+	slices.SortFunc(stats, func(a, b Stats) int { return strings.Compare(a.Name, b.Name) })
+
+	return stats
+}
+
+// Estimates returns the median duration of the successful executions of every
+// task and stage in runs, keyed both by the recorded name and by the task the
+// stage ran, so a caller can look a running task up either way.
+func Estimates(runs []*Run) map[string]time.Duration {
+	durations := map[string][]time.Duration{}
+	for _, r := range runs {
+		for _, t := range r.Tasks {
+			if t.Status != "done" {
+				continue
+			}
+			d := time.Duration(t.DurationMs) * time.Millisecond
+			durations[t.Name] = append(durations[t.Name], d)
+			if t.Task != "" && t.Task != t.Name {
+				durations[t.Task] = append(durations[t.Task], d)
+			}
+		}
+	}
+
+	estimates := make(map[string]time.Duration, len(durations))
+	for name, d := range durations {
+		estimates[name] = Percentile(d, 50)
+	}
+
+	return estimates
+}
+
+// Percentile returns the p-th percentile of durations by the nearest-rank
+// method, or zero for an empty slice.
+func Percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+
+	sorted := slices.Sorted(slices.Values(durations))
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
+
+// summarize computes the stats of a chronologically ordered series. Durations
+// come from successful executions only, since a failure usually cuts a run
+// short; a series without any success falls back to all of them.
+func summarize(name string, series []sample) Stats {
+	s := Stats{Name: name, Runs: len(series)}
+
+	var ok, all []time.Duration
+	for _, v := range series {
+		all = append(all, v.duration)
+		if v.failed {
+			s.Failures++
+			continue
+		}
+		ok = append(ok, v.duration)
+	}
+
+	if len(ok) == 0 {
+		ok = all
+	}
+
+	s.P50 = Percentile(ok, 50)
+	s.P95 = Percentile(ok, 95)
+
+	if len(ok) >= minTrendSamples {
+		older, newer := Percentile(ok[:len(ok)/2], 50), Percentile(ok[len(ok)/2:], 50)
+		if older > 0 {
+			s.Trend = float64(newer-older) / float64(older)
+		}
+	}
+
+	return s
+}
@@ -0,0 +1,94 @@
+package history
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	d := []time.Duration{5, 1, 4, 2, 3}
+
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{50, 3},
+		{95, 5},
+		{0, 1},
+		{100, 5},
+	}
+	for _, tt := range tests {
+		if got := Percentile(d, tt.p); got != tt.want {
+			t.Errorf("Percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+
+	if got := Percentile(nil, 50); got != 0 {
+		t.Errorf("Percentile(nil) = %v, want 0", got)
+	}
+}
+
+// runsOf builds runs newest first, as List returns them, from build durations
+// in chronological order.
+func runsOf(durations ...int64) []*Run {
+	start := time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+
+	var runs []*Run
+	for i, ms := range durations {
+		status := "done"
+		if ms < 0 {
+			status, ms = "failed", -ms
+		}
+		s := start.Add(time.Duration(i) * time.Hour)
+		runs = append([]*Run{{
+			Start:  s,
+			End:    s.Add(time.Duration(ms) * time.Millisecond),
+			Status: status,
+			Tasks: []Task{
+				{Name: "compile", Task: "build", Status: status, DurationMs: ms},
+				{Name: "deploy", Status: "skipped"},
+			},
+		}}, runs...)
+	}
+
+	return runs
+}
+
+func TestTaskStats(t *testing.T) {
+	stats := TaskStats(runsOf(100, 100, -5, 200, 200))
+	if len(stats) != 1 {
+		t.Fatalf("expected only the executed stage, got %+v", stats)
+	}
+
+	s := stats[0]
+	if s.Name != "compile" || s.Runs != 5 || s.Failures != 1 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+	if s.P50 != 100*time.Millisecond || s.P95 != 200*time.Millisecond {
+		t.Errorf("p50/p95 = %v/%v, want 100ms/200ms (failures excluded)", s.P50, s.P95)
+	}
+	if math.Abs(s.Trend-1) > 1e-9 {
+		t.Errorf("Trend = %v, want 1 (twice as slow)", s.Trend)
+	}
+	if got := s.FailureRate(); got != 0.2 {
+		t.Errorf("FailureRate = %v, want 0.2", got)
+	}
+}
+
+func TestRunStats(t *testing.T) {
+	s := RunStats("build", runsOf(100, 300))
+	if s.Runs != 2 || s.P50 != 100*time.Millisecond || s.Trend != 0 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+}
+
+func TestEstimates(t *testing.T) {
+	e := Estimates(runsOf(100, -5, 300, 200))
+	if e["compile"] != 200*time.Millisecond || e["build"] != 200*time.Millisecond {
+		t.Errorf("unexpected estimates %v", e)
+	}
+	if _, ok := e["deploy"]; ok {
+		t.Error("skipped stages must not be estimated")
+	}
+}
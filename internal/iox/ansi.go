@@ -0,0 +1,87 @@
+package iox
+
+import "io"
+
+// ansiState is where an ANSIStripper is within an escape sequence.
+type ansiState uint8
+
+const (
+	ansiText ansiState = iota
+	ansiEscape
+	ansiEscapeIntermediate
+	ansiCSI
+	ansiString
+	ansiStringEscape
+)
+
+// ANSIStripper is an io.Writer that drops the ANSI escape sequences (colors,
+// cursor movement, window titles and the like) of what is written to it,
+// passing the rest on. Sequences may span writes.
+type ANSIStripper struct {
+	w     io.Writer
+	state ansiState
+}
+
+// StripANSI returns an ANSIStripper writing to w.
+func StripANSI(w io.Writer) *ANSIStripper {
+	return &ANSIStripper{w: w}
+}
+
+// Write writes p to the underlying writer without its escape sequences. It
+// reports all of p as written unless the underlying writer fails.
+func (s *ANSIStripper) Write(p []byte) (int, error) {
+	out := make([]byte, 0, len(p))
+	for _, b := range p {
+		switch s.state {
+		case ansiText:
+			if b == 0x1b {
+				s.state = ansiEscape
+				continue
+			}
+			out = append(out, b)
+		case ansiEscape:
+			switch {
+			case b == '[':
+				s.state = ansiCSI
+			case b == ']' || b == 'P' || b == 'X' || b == '^' || b == '_':
+				// OSC, DCS, SOS, PM and APC run to a terminator.
+				s.state = ansiString
+			case b >= 0x20 && b <= 0x2f:
+				s.state = ansiEscapeIntermediate
+			default:
+				s.state = ansiText
+			}
+		case ansiEscapeIntermediate:
+			if b < 0x20 || b > 0x2f {
+				s.state = ansiText
+			}
+		case ansiCSI:
+			if b >= 0x40 && b <= 0x7e {
+				s.state = ansiText
+			}
+		case ansiString:
+			switch b {
+			case 0x07:
+				s.state = ansiText
+			case 0x1b:
+				s.state = ansiStringEscape
+			}
+		case ansiStringEscape:
+			// ESC \ ends the string; anything else after ESC starts a new
+			// sequence.
+			if b == '\\' {
+				s.state = ansiText
+			} else {
+				s.state = ansiEscape
+			}
+		}
+	}
+
+	if len(out) > 0 {
+		if _, err := s.w.Write(out); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,36 @@
+package iox
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStripANSI(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []string
+		want string
+	}{
+		{"plain", []string{"hello\r\n"}, "hello\r\n"},
+		{"colors", []string{"\x1b[1;31merror\x1b[0m: boom\n"}, "error: boom\n"},
+		{"split sequence", []string{"\x1b[", "32", "mok\x1b", "[0m"}, "ok"},
+		{"cursor", []string{"50%\x1b[2K\x1b[1G100%"}, "50%100%"},
+		{"title", []string{"\x1b]0;build\x07done"}, "done"},
+		{"string terminator", []string{"\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\"}, "link"},
+		{"charset", []string{"\x1b(Bx\x1b=y"}, "xy"},
+		{"utf-8", []string{"✓ passed"}, "✓ passed"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		s := StripANSI(&buf)
+		for _, in := range tt.in {
+			if n, err := s.Write([]byte(in)); err != nil || n != len(in) {
+				t.Fatalf("%s: Write = %d, %v", tt.name, n, err)
+			}
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, buf.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package iox
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// DefaultSpillLimit is how much of its content a SpillBuffer keeps in memory
+// unless told otherwise.
+const DefaultSpillLimit = 16 << 20
+
+// SpillBuffer accumulates what is written to it like a bytes.Buffer, but keeps
+// at most Limit bytes in memory: a write going past it moves the content to a
+// temporary file, which later writes append to. The zero value is an empty
+// buffer ready to use. Like bytes.Buffer, it is not safe for concurrent use.
+//
+// The temporary file is unlinked as soon as it is created where the system
+// allows it, so it goes away with the process; elsewhere Reset removes it.
+type SpillBuffer struct {
+	// Limit is how many bytes are kept in memory; 0 means DefaultSpillLimit
+	// and a negative Limit keeps everything in memory.
+	Limit int
+
+	mem  []byte
+	file *os.File
+	// name is the path of a file that could not be unlinked when created.
+	name string
+	size int
+}
+
+// Write appends p to the buffer, spilling it to a file once it outgrows
+// Limit. When the file can't be created, the buffer stays in memory.
+func (b *SpillBuffer) Write(p []byte) (int, error) {
+	if b.file == nil && b.Limit >= 0 && len(b.mem)+len(p) > b.limit() {
+		b.spill()
+	}
+
+	if b.file == nil {
+		b.mem = append(b.mem, p...)
+		b.size += len(p)
+		return len(p), nil
+	}
+
+	n, err := b.file.Write(p)
+	b.size += n
+	return n, err
+}
+
+// WriteString appends s to the buffer, like Write.
+func (b *SpillBuffer) WriteString(s string) (int, error) {
+	return b.Write([]byte(s))
+}
+
+func (b *SpillBuffer) limit() int {
+	if b.Limit == 0 {
+		return DefaultSpillLimit
+	}
+
+	return b.Limit
+}
+
+// spill moves the content to a new temporary file.
+func (b *SpillBuffer) spill() {
+	f, err := os.CreateTemp("", "taskctl-log-")
+	if err == nil {
+		_, err = f.Write(b.mem)
+	}
+	if err != nil {
+		slog.Warn("keeping output in memory: " + err.Error())
+		if f != nil {
+			Close(f)
+			_ = os.Remove(f.Name())
+		}
+		b.Limit = -1
+		return
+	}
+
+	if os.Remove(f.Name()) != nil {
+		b.name = f.Name()
+	}
+	b.file = f
+	b.mem = nil
+}
+
+// Spilled reports whether the content was moved to a file.
+func (b *SpillBuffer) Spilled() bool {
+	return b.file != nil
+}
+
+// Len returns the number of bytes written to the buffer.
+func (b *SpillBuffer) Len() int {
+	return b.size
+}
+
+// String returns the whole content, read back from the file if it spilled.
+func (b *SpillBuffer) String() string {
+	var s strings.Builder
+	s.Grow(b.size)
+	_, _ = b.WriteTo(&s)
+
+	return s.String()
+}
+
+// WriteTo writes the whole content to w without reading it all into memory.
+func (b *SpillBuffer) WriteTo(w io.Writer) (int64, error) {
+	return io.Copy(w, b.section(0))
+}
+
+// Tail returns the last n bytes of the content, or all of it when shorter.
+func (b *SpillBuffer) Tail(n int) []byte {
+	off := max(b.size-n, 0)
+	if b.file == nil {
+		return b.mem[off:]
+	}
+
+	tail := make([]byte, b.size-off)
+	n, _ = io.ReadFull(b.section(off), tail)
+
+	return tail[:n]
+}
+
+// section reads the content from offset off.
+func (b *SpillBuffer) section(off int) io.Reader {
+	if b.file == nil {
+		return bytes.NewReader(b.mem[off:])
+	}
+
+	return io.NewSectionReader(b.file, int64(off), int64(b.size-off))
+}
+
+// Reset empties the buffer, closing and removing its file.
+func (b *SpillBuffer) Reset() {
+	if b.file != nil {
+		Close(b.file)
+		if b.name != "" {
+			_ = os.Remove(b.name)
+		}
+	}
+	*b = SpillBuffer{Limit: b.Limit}
+}
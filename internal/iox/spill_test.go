@@ -0,0 +1,75 @@
+package iox
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSpillBuffer(t *testing.T) {
+	b := &SpillBuffer{Limit: 8}
+	_, _ = b.WriteString("line 1\n")
+	if b.Spilled() {
+		t.Fatal("the buffer spilled below its limit")
+	}
+	if got := string(b.Tail(3)); got != " 1\n" {
+		t.Errorf("Tail(3) = %q, want \" 1\\n\"", got)
+	}
+
+	_, _ = b.WriteString("line 2\n")
+	_, _ = b.WriteString("line 3\n")
+	if !b.Spilled() {
+		t.Fatal("the buffer didn't spill past its limit")
+	}
+
+	want := "line 1\nline 2\nline 3\n"
+	if b.Len() != len(want) {
+		t.Errorf("Len() = %d, want %d", b.Len(), len(want))
+	}
+	if got := b.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got := string(b.Tail(7)); got != "line 3\n" {
+		t.Errorf("Tail(7) = %q, want %q", got, "line 3\n")
+	}
+	if got := string(b.Tail(100)); got != want {
+		t.Errorf("Tail(100) = %q, want %q", got, want)
+	}
+
+	var out bytes.Buffer
+	if _, err := b.WriteTo(&out); err != nil || out.String() != want {
+		t.Errorf("WriteTo() wrote %q, %v; want %q", out.String(), err, want)
+	}
+
+	b.Reset()
+	if b.Spilled() || b.Len() != 0 || b.String() != "" {
+		t.Errorf("Reset() left %d bytes, spilled %v", b.Len(), b.Spilled())
+	}
+	_, _ = b.WriteString("again")
+	if got := b.String(); got != "again" {
+		t.Errorf("String() after Reset() = %q, want %q", got, "again")
+	}
+}
+
+func TestSpillBuffer_Unlimited(t *testing.T) {
+	b := &SpillBuffer{Limit: -1}
+	big := strings.Repeat("x", 1024)
+	_, _ = b.WriteString(big)
+	if b.Spilled() {
+		t.Error("a buffer with a negative limit spilled")
+	}
+	if b.String() != big {
+		t.Error("String() doesn't return what was written")
+	}
+}
+
+func TestSpillBuffer_Zero(t *testing.T) {
+	var b SpillBuffer
+	if b.Len() != 0 || b.String() != "" || len(b.Tail(10)) != 0 {
+		t.Error("the zero SpillBuffer is not empty")
+	}
+	_, _ = b.WriteString("small")
+	if b.Spilled() {
+		t.Error("the zero SpillBuffer spilled below DefaultSpillLimit")
+	}
+}
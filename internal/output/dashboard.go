@@ -3,8 +3,10 @@ package output
 import (
 	"bytes"
 	"cmp"
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"slices"
 	"strings"
 	"sync"
@@ -16,6 +18,7 @@ import (
 	"github.com/charmbracelet/x/ansi"
 
 	"github.com/taskctl/taskctl/internal/tui"
+	"github.com/taskctl/taskctl/process"
 	"github.com/taskctl/taskctl/task"
 )
 
@@ -43,8 +46,44 @@ var (
 	// share a Name; keying rows by a unique id keeps one clone's finish from
 	// clearing another's elapsed time and output line.
 	rowID atomic.Uint64
+
+	// estimates feeds the dashboard's progress and ETA display; see SetEstimates.
+	estimates   Estimates
+	estimatesMu sync.Mutex
 )
 
+// Estimates are the expected durations of an upcoming run, typically the
+// medians of earlier runs.
+type Estimates struct {
+	// Tasks maps stage and task names to their expected duration.
+	Tasks map[string]time.Duration
+	// Total is the expected duration of the whole run.
+	Total time.Duration
+}
+
+// SetEstimates makes the dashboard show a progress percentage and an ETA for
+// every running task, and for the run as a whole, it has an expected duration
+// for. It must be called before the first task starts.
+func SetEstimates(e Estimates) {
+	estimatesMu.Lock()
+	defer estimatesMu.Unlock()
+
+	estimates = e
+}
+
+// expectedDuration looks the task up by its stage name first, since one task
+// may run as several stages of different cost, then by its own name.
+func expectedDuration(t *task.Task) time.Duration {
+	estimatesMu.Lock()
+	defer estimatesMu.Unlock()
+
+	if d, ok := estimates.Tasks[t.Stage]; ok && t.Stage != "" {
+		return d
+	}
+
+	return estimates.Tasks[t.Name]
+}
+
 // baseDashboard is the live multi-task dashboard: a single bubbletea program that
 // shows a spinner with the currently-running tasks and prints a "Finished" line
 // as each completes. It is safe for concurrent add/remove from the scheduler's
@@ -79,14 +118,16 @@ func (s *dashboardStreamWriter) Write(p []byte) (int, error) {
 }
 
 type taskStartedMsg struct {
-	id      uint64
-	name    string
-	started time.Time
+	id       uint64
+	name     string
+	started  time.Time
+	expected time.Duration
 }
 type taskFinishedMsg struct {
 	id       uint64
 	name     string
 	errored  bool
+	timedOut bool
 	duration time.Duration
 }
 
@@ -95,17 +136,36 @@ type taskOutputMsg struct {
 	line string
 }
 
+type taskProgressMsg struct {
+	id      uint64
+	percent int
+}
+
+type taskLogMsg struct {
+	name    string
+	level   string
+	message string
+}
+
 type taskRow struct {
 	id       uint64
 	name     string
 	started  time.Time
+	expected time.Duration
 	lastLine string
+	// percent is the progress the task reported, -1 until it does.
+	percent int
 }
 
 type dashboardModel struct {
 	spin  spinner.Model
 	rows  []taskRow
 	width int
+
+	// started is when the first task started; total is the run's expected
+	// duration, zero when unknown.
+	started time.Time
+	total   time.Duration
 }
 
 func (m dashboardModel) Init() tea.Cmd {
@@ -119,7 +179,10 @@ func (m dashboardModel) rowIndex(id uint64) int {
 func (m dashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case taskStartedMsg:
-		m.rows = append(m.rows, taskRow{id: msg.id, name: msg.name, started: msg.started})
+		if m.started.IsZero() {
+			m.started = msg.started
+		}
+		m.rows = append(m.rows, taskRow{id: msg.id, name: msg.name, started: msg.started, expected: msg.expected, percent: -1})
 		slices.SortFunc(m.rows, func(a, b taskRow) int {
 			if c := strings.Compare(a.name, b.name); c != 0 {
 				return c
@@ -137,6 +200,9 @@ func (m dashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			mark = tui.StyleError.Render("✗")
 		}
 		line := fmt.Sprintf("%s Finished %s in %s", mark, tui.StyleBold.Render(msg.name), msg.duration)
+		if msg.timedOut {
+			line = fmt.Sprintf("%s %s timed out after %s", tui.StyleError.Render("⏱"), tui.StyleBold.Render(msg.name), msg.duration)
+		}
 
 		return m, tea.Println(line)
 	case taskOutputMsg:
@@ -146,6 +212,20 @@ func (m dashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.rows[i].lastLine = msg.line
 		}
 		return m, nil
+	case taskProgressMsg:
+		if i := m.rowIndex(msg.id); i != -1 {
+			m.rows[i].percent = msg.percent
+		}
+		return m, nil
+	case taskLogMsg:
+		mark := tui.StyleFaint.Render("•")
+		switch msg.level {
+		case "warn":
+			mark = tui.StyleWarning.Render("⚠")
+		case "error":
+			mark = tui.StyleError.Render("✗")
+		}
+		return m, tea.Println(fmt.Sprintf("%s %s: %s", mark, tui.StyleBold.Render(msg.name), msg.message))
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		return m, nil
@@ -176,9 +256,19 @@ func (m dashboardModel) View() tea.View {
 	}
 
 	spin := m.spin.View()
-	rows := make([]string, 0, len(visible)*2+1)
+	rows := make([]string, 0, len(visible)*2+2)
+	if m.total > 0 {
+		rows = append(rows, tui.StyleFaint.Render(ansi.Truncate("Run "+progress(time.Since(m.started), m.total), w, "…")))
+	}
 	for _, r := range visible {
-		row := fmt.Sprintf("%s %s (%s)", spin, r.name, time.Since(r.started).Round(time.Second))
+		elapsed := time.Since(r.started).Round(time.Second)
+		row := fmt.Sprintf("%s %s (%s)", spin, r.name, elapsed)
+		switch {
+		case r.percent >= 0:
+			row = fmt.Sprintf("%s %s (%s, %d%%)", spin, r.name, elapsed, r.percent)
+		case r.expected > 0:
+			row = fmt.Sprintf("%s %s (%s, %s)", spin, r.name, elapsed, progress(time.Since(r.started), r.expected))
+		}
 		rows = append(rows, ansi.Truncate(row, w, "…"))
 
 		if r.lastLine != "" {
@@ -193,14 +283,30 @@ func (m dashboardModel) View() tea.View {
 	return tea.NewView(strings.Join(rows, "\n"))
 }
 
+// progress renders how far along something expected to take expected is after
+// elapsed: a percentage and the time left, or how long it has overrun. The
+// percentage stops at 99 so an estimate never claims completion.
+func progress(elapsed, expected time.Duration) string {
+	if elapsed > expected {
+		return fmt.Sprintf("%s over estimate", (elapsed - expected).Round(time.Second))
+	}
+
+	pct := min(int(elapsed*100/expected), 99)
+	return fmt.Sprintf("%d%%, ~%s left", pct, (expected - elapsed).Round(time.Second))
+}
+
 func (b *baseDashboard) start() {
 	b.startOnce.Do(func() {
 		sp := spinner.New()
 		sp.Spinner = spinner.Dot
 		sp.Style = tui.StyleSpinner
 
+		estimatesMu.Lock()
+		total := estimates.Total
+		estimatesMu.Unlock()
+
 		b.mu.Lock()
-		b.prog = tea.NewProgram(dashboardModel{spin: sp}, tea.WithOutput(b.w), tea.WithInput(nil), tea.WithoutSignalHandler())
+		b.prog = tea.NewProgram(dashboardModel{spin: sp, total: total}, tea.WithOutput(b.w), tea.WithInput(nil), tea.WithoutSignalHandler())
 		p := b.prog
 		b.mu.Unlock()
 
@@ -322,11 +428,31 @@ func (d *dashboardOutputDecorator) scanLine(stream string, p []byte) string {
 
 func (d *dashboardOutputDecorator) WriteHeader() error {
 	d.b.start()
-	d.b.send(taskStartedMsg{id: d.id, name: d.t.Name, started: time.Now()})
+	d.b.send(taskStartedMsg{id: d.id, name: d.t.Name, started: time.Now(), expected: expectedDuration(d.t)})
 	return nil
 }
 
+// SetOutput and Annotate show nothing while the run goes on: outputs are
+// for later tasks, annotations are listed by the run summary.
+func (d *dashboardOutputDecorator) SetOutput(string, string)    {}
+func (d *dashboardOutputDecorator) Annotate(process.Annotation) {}
+
+// Log prints a message the task's commands log above the dashboard, unless
+// its level is below the log's.
+func (d *dashboardOutputDecorator) Log(level, message string) {
+	if !slog.Default().Enabled(context.Background(), logLevel(level)) {
+		return
+	}
+	d.b.send(taskLogMsg{name: d.t.Name, level: level, message: message})
+}
+
+// Progress shows the progress the task's commands report in its row, in
+// place of the estimate from its past runs.
+func (d *dashboardOutputDecorator) Progress(percent int) {
+	d.b.send(taskProgressMsg{id: d.id, percent: percent})
+}
+
 func (d *dashboardOutputDecorator) WriteFooter() error {
-	d.b.send(taskFinishedMsg{id: d.id, name: d.t.Name, errored: d.t.Errored, duration: d.t.Duration()})
+	d.b.send(taskFinishedMsg{id: d.id, name: d.t.Name, errored: d.t.Errored, timedOut: d.t.TimedOut, duration: d.t.Duration()})
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"strings"
 	"testing"
+	"time"
 
 	"charm.land/bubbles/v2/spinner"
 	tea "charm.land/bubbletea/v2"
@@ -182,3 +183,64 @@ func Test_dashboardModel_View_truncatesToWidth(t *testing.T) {
 		}
 	}
 }
+
+func Test_progress(t *testing.T) {
+	tests := []struct {
+		elapsed, expected time.Duration
+		want              string
+	}{
+		{2 * time.Second, 10 * time.Second, "20%, ~8s left"},
+		{10 * time.Second, 10 * time.Second, "99%, ~0s left"},
+		{13 * time.Second, 10 * time.Second, "3s over estimate"},
+	}
+
+	for _, tt := range tests {
+		if got := progress(tt.elapsed, tt.expected); got != tt.want {
+			t.Errorf("progress(%s, %s) = %q, want %q", tt.elapsed, tt.expected, got, tt.want)
+		}
+	}
+}
+
+func Test_dashboardModel_View_estimates(t *testing.T) {
+	m := newTestDashboardModel()
+	m.total = time.Hour
+	m = update(m, taskStartedMsg{id: 1, name: "build", started: time.Now(), expected: time.Hour})
+	m = update(m, taskStartedMsg{id: 2, name: "lint", started: time.Now()})
+
+	view := m.View().Content
+	if !strings.Contains(view, "Run 0%, ~1h0m0s left") {
+		t.Errorf("missing run progress\n%s", view)
+	}
+	if !strings.Contains(view, "build (0s, 0%, ~1h0m0s left)") {
+		t.Errorf("missing task progress\n%s", view)
+	}
+	if !strings.Contains(view, "lint (0s)") {
+		t.Errorf("task without an estimate must show only its elapsed time\n%s", view)
+	}
+}
+
+func Test_dashboardModel_View_reportedProgress(t *testing.T) {
+	m := newTestDashboardModel()
+	m = update(m, taskStartedMsg{id: 1, name: "build", started: time.Now(), expected: time.Hour})
+	m = update(m, taskProgressMsg{id: 1, percent: 40})
+
+	// What the task reports takes precedence over the estimate.
+	if view := m.View().Content; !strings.Contains(view, "build (0s, 40%)") {
+		t.Errorf("missing reported progress\n%s", view)
+	}
+}
+
+func Test_expectedDuration(t *testing.T) {
+	SetEstimates(Estimates{Tasks: map[string]time.Duration{"build": time.Second, "release": time.Minute}})
+	t.Cleanup(func() { SetEstimates(Estimates{}) })
+
+	if got := expectedDuration(&task.Task{Name: "build"}); got != time.Second {
+		t.Errorf("by task name = %s, want 1s", got)
+	}
+	if got := expectedDuration(&task.Task{Name: "build", Stage: "release"}); got != time.Minute {
+		t.Errorf("by stage name = %s, want 1m0s", got)
+	}
+	if got := expectedDuration(&task.Task{Name: "test"}); got != 0 {
+		t.Errorf("unknown task = %s, want 0", got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"github.com/taskctl/taskctl/internal/collections"
+	"github.com/taskctl/taskctl/process"
 	"github.com/taskctl/taskctl/task"
 )
 
@@ -31,14 +32,51 @@ type TaskOutputEvent struct {
 	Data   string `json:"data"`
 }
 
+// TaskSetOutputEvent is emitted when a task's command sets an output with
+// taskctl-set-output.
+type TaskSetOutputEvent struct {
+	Event string `json:"event"`
+	Task  string `json:"task"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// TaskLogEvent carries a message a task's command logged with taskctl-log.
+type TaskLogEvent struct {
+	Event   string `json:"event"`
+	Task    string `json:"task"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// TaskAnnotationEvent carries an annotation a task's command added with
+// taskctl-annotate.
+type TaskAnnotationEvent struct {
+	Event   string `json:"event"`
+	Task    string `json:"task"`
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// TaskProgressEvent carries the progress a task's command reported with
+// taskctl-progress.
+type TaskProgressEvent struct {
+	Event   string `json:"event"`
+	Task    string `json:"task"`
+	Percent int    `json:"percent"`
+}
+
 // TaskFinishedEvent is emitted when a task completes.
 type TaskFinishedEvent struct {
-	Event      string `json:"event"`
-	Task       string `json:"task"`
-	Status     string `json:"status"`
-	ExitCode   int    `json:"exit_code"`
-	DurationMs int64  `json:"duration_ms"`
-	Error      string `json:"error,omitempty"`
+	Event      string            `json:"event"`
+	Task       string            `json:"task"`
+	Status     string            `json:"status"`
+	ExitCode   int               `json:"exit_code"`
+	DurationMs int64             `json:"duration_ms"`
+	Error      string            `json:"error,omitempty"`
+	LogFiles   []string          `json:"log_files,omitempty"`
+	Outputs    map[string]string `json:"outputs,omitempty"`
 }
 
 // TaskResult summarizes a single task's outcome within a run_finished event.
@@ -106,11 +144,14 @@ func EmitRunFinished(w io.Writer, status string, durationMs int64, results []Tas
 }
 
 // TaskStatus maps a completed task's flags to the NDJSON status vocabulary
-// ("done"/"skipped"/"failed") shared by the task_finished and run_finished events.
+// ("done"/"skipped"/"failed"/"timed_out") shared by the task_finished and
+// run_finished events.
 func TaskStatus(t *task.Task) string {
 	switch {
 	case t.Skipped:
 		return "skipped"
+	case t.TimedOut:
+		return "timed_out"
 	case t.Errored:
 		return "failed"
 	default:
@@ -118,6 +159,12 @@ func TaskStatus(t *task.Task) string {
 	}
 }
 
+// IsFailure reports whether status is one of the failing statuses: "failed",
+// or "timed_out" for a task or stage stopped by a timeout.
+func IsFailure(status string) bool {
+	return status == "failed" || status == "timed_out"
+}
+
 // jsonOutputWriter is the DecoratedOutputWriter that turns a task's output
 // into NDJSON events. It line-buffers stdout and stderr independently (via
 // StreamWriter) so a partial write on one stream never corrupts the other.
@@ -199,6 +246,24 @@ func (d *jsonOutputWriter) emitOutput(stream string, data []byte) error {
 	})
 }
 
+// SetOutput, Log, Annotate and Progress emit the events of taskctl's builtin
+// commands; see executor.Reporter.
+func (d *jsonOutputWriter) SetOutput(key, value string) {
+	_ = writeEvent(d.w, TaskSetOutputEvent{Event: "task_set_output", Task: d.t.Name, Key: key, Value: value})
+}
+
+func (d *jsonOutputWriter) Log(level, message string) {
+	_ = writeEvent(d.w, TaskLogEvent{Event: "task_log", Task: d.t.Name, Level: level, Message: message})
+}
+
+func (d *jsonOutputWriter) Annotate(a process.Annotation) {
+	_ = writeEvent(d.w, TaskAnnotationEvent{Event: "task_annotation", Task: d.t.Name, File: a.File, Line: a.Line, Message: a.Message})
+}
+
+func (d *jsonOutputWriter) Progress(percent int) {
+	_ = writeEvent(d.w, TaskProgressEvent{Event: "task_progress", Task: d.t.Name, Percent: percent})
+}
+
 // WriteFooter flushes any buffered partial line on each stream, then emits
 // the task_finished event.
 func (d *jsonOutputWriter) WriteFooter() error {
@@ -225,8 +290,10 @@ func (d *jsonOutputWriter) WriteFooter() error {
 		Status:     status,
 		ExitCode:   int(d.t.ExitCode),
 		DurationMs: d.t.Duration().Milliseconds(),
+		LogFiles:   d.t.LogFiles,
+		Outputs:    d.t.Outputs,
 	}
-	if status == "failed" {
+	if IsFailure(status) {
 		ev.Error = d.t.ErrorMessage()
 	}
 
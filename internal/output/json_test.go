@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/taskctl/taskctl/process"
 	"github.com/taskctl/taskctl/task"
 )
 
@@ -164,6 +165,7 @@ func TestJSONOutputWriter_FooterFlushesFailedStatus(t *testing.T) {
 	tt.ExitCode = 1
 	tt.Error = testError{}
 	tt.Log.Stderr.WriteString("boom")
+	tt.LogFiles = []string{"logs/task1.log"}
 
 	d := newJSONOutputWriter(tt, &buf)
 	if err := d.WriteFooter(); err != nil {
@@ -181,6 +183,9 @@ func TestJSONOutputWriter_FooterFlushesFailedStatus(t *testing.T) {
 	if last["error"] != "boom" {
 		t.Errorf("expected error message boom, got %+v", last["error"])
 	}
+	if files, ok := last["log_files"].([]any); !ok || len(files) != 1 || files[0] != "logs/task1.log" {
+		t.Errorf("expected log_files [logs/task1.log], got %+v", last["log_files"])
+	}
 }
 
 type testError struct{}
@@ -288,3 +293,50 @@ func TestNewTaskOutput_JSON(t *testing.T) {
 		t.Errorf("expected task log to also capture stdout, got %q", tt.Log.Stdout.String())
 	}
 }
+
+func TestNewTaskOutput_JSON_Reporter(t *testing.T) {
+	var buf bytes.Buffer
+	tt := task.FromCommands("echo hi")
+	tt.Name = "task1"
+
+	o, err := NewTaskOutput(tt, FormatJSON, &buf, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := o.Start(); err != nil {
+		t.Fatal(err)
+	}
+	o.SetOutput("version", "1.2.3")
+	o.Log("warn", "cache miss")
+	o.Annotate(process.Annotation{File: "main.go", Line: 12, Message: "unused"})
+	o.Progress(40)
+	if err := o.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	if tt.Outputs["version"] != "1.2.3" || len(tt.Annotations) != 1 || tt.Progress != 40 {
+		t.Errorf("task did not record the reports: %+v %+v %d", tt.Outputs, tt.Annotations, tt.Progress)
+	}
+
+	byEvent := make(map[string]map[string]any)
+	for _, ev := range decodeLines(t, buf.Bytes()) {
+		byEvent[ev["event"].(string)] = ev
+	}
+
+	if ev := byEvent["task_set_output"]; ev["key"] != "version" || ev["value"] != "1.2.3" {
+		t.Errorf("unexpected task_set_output event: %+v", ev)
+	}
+	if ev := byEvent["task_log"]; ev["level"] != "warn" || ev["message"] != "cache miss" {
+		t.Errorf("unexpected task_log event: %+v", ev)
+	}
+	if ev := byEvent["task_annotation"]; ev["file"] != "main.go" || ev["line"] != float64(12) || ev["message"] != "unused" {
+		t.Errorf("unexpected task_annotation event: %+v", ev)
+	}
+	if ev := byEvent["task_progress"]; ev["percent"] != float64(40) {
+		t.Errorf("unexpected task_progress event: %+v", ev)
+	}
+	if outputs, _ := byEvent["task_finished"]["outputs"].(map[string]any); outputs["version"] != "1.2.3" {
+		t.Errorf("expected task_finished to carry the outputs, got %+v", byEvent["task_finished"])
+	}
+}
@@ -1,9 +1,15 @@
 package output
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
+	"sync"
 
+	"github.com/taskctl/taskctl/executor"
+	"github.com/taskctl/taskctl/internal/iox"
+	"github.com/taskctl/taskctl/process"
 	"github.com/taskctl/taskctl/task"
 )
 
@@ -39,6 +45,13 @@ type streamAwareWriter interface {
 type TaskOutput struct {
 	t         *task.Task
 	decorator DecoratedOutputWriter
+	// plain is set for decorators that show a TTY task's output without its
+	// escape sequences.
+	plain bool
+
+	// mu guards what the task's commands report, which a background job may
+	// do concurrently with the task's other commands.
+	mu sync.Mutex
 }
 
 // NewTaskOutput creates new TaskOutput instance for given task.
@@ -54,8 +67,10 @@ func NewTaskOutput(t *task.Task, format string, stdout, stderr io.Writer) (*Task
 		o.decorator = newPrefixedOutputWriter(t, stdout)
 	case FormatDefault:
 		o.decorator = newDashboardOutputWriter(t, stdout, closeCh)
+		o.plain = true
 	case FormatJSON:
 		o.decorator = newJSONOutputWriter(t, stdout)
+		o.plain = true
 	default:
 		return nil, fmt.Errorf("unknown decorator \"%s\" requested", format)
 	}
@@ -65,18 +80,31 @@ func NewTaskOutput(t *task.Task, format string, stdout, stderr io.Writer) (*Task
 
 // Stdout returns io.Writer that can be used for Job's STDOUT
 func (o *TaskOutput) Stdout() io.Writer {
-	if sa, ok := o.decorator.(streamAwareWriter); ok {
-		return io.MultiWriter(sa.StreamWriter("stdout"), &o.t.Log.Stdout)
-	}
-	return io.MultiWriter(o.decorator, &o.t.Log.Stdout)
+	return o.writer("stdout", &o.t.Log.Stdout)
 }
 
 // Stderr returns io.Writer that can be used for Job's STDERR
 func (o *TaskOutput) Stderr() io.Writer {
+	return o.writer("stderr", &o.t.Log.Stderr)
+}
+
+// writer returns the writer of stream, writing to the decorator and the
+// task's log. A TTY task's escape sequences are kept for the raw and prefixed
+// output, which go to a terminal, and dropped elsewhere.
+func (o *TaskOutput) writer(stream string, log io.Writer) io.Writer {
+	var w io.Writer = o.decorator
 	if sa, ok := o.decorator.(streamAwareWriter); ok {
-		return io.MultiWriter(sa.StreamWriter("stderr"), &o.t.Log.Stderr)
+		w = sa.StreamWriter(stream)
+	}
+
+	if o.t.TTY {
+		log = iox.StripANSI(log)
+		if o.plain {
+			w = iox.StripANSI(w)
+		}
 	}
-	return io.MultiWriter(o.decorator, &o.t.Log.Stderr)
+
+	return io.MultiWriter(w, log)
 }
 
 // Start should be called before task's output starts
@@ -89,6 +117,71 @@ func (o *TaskOutput) Finish() error {
 	return o.decorator.WriteFooter()
 }
 
+// SetOutput records an output the task's commands set, as the
+// executor.Reporter of the task's jobs.
+func (o *TaskOutput) SetOutput(key, value string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.t.Outputs == nil {
+		o.t.Outputs = make(map[string]string)
+	}
+	o.t.Outputs[key] = value
+	if r, ok := o.decorator.(executor.Reporter); ok {
+		r.SetOutput(key, value)
+	}
+}
+
+// Log passes a message the task's commands log to the decorator or, for one
+// that does not show them, to the log.
+func (o *TaskOutput) Log(level, message string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if r, ok := o.decorator.(executor.Reporter); ok {
+		r.Log(level, message)
+		return
+	}
+	slog.Log(context.Background(), logLevel(level), fmt.Sprintf("%s: %s", o.t.Name, message))
+}
+
+// Annotate records an annotation the task's commands add; the run summary
+// lists them.
+func (o *TaskOutput) Annotate(a process.Annotation) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.t.Annotations = append(o.t.Annotations, a)
+	if r, ok := o.decorator.(executor.Reporter); ok {
+		r.Annotate(a)
+	}
+}
+
+// Progress records the progress the task's commands report.
+func (o *TaskOutput) Progress(percent int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.t.Progress = percent
+	if r, ok := o.decorator.(executor.Reporter); ok {
+		r.Progress(percent)
+	}
+}
+
+// logLevel maps a taskctl-log level to its slog.Level.
+func logLevel(level string) slog.Level {
+	switch level {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
 // Close releases resources and closes underlying decorators. For the default
 // (dashboard) output it blocks until the dashboard program has fully shut down
 // (final lines flushed, terminal restored).
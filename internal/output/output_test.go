@@ -118,3 +118,38 @@ func TestNewTaskOutput(t *testing.T) {
 
 	Close()
 }
+
+func TestTaskOutput_TTY(t *testing.T) {
+	const colored = "\x1b[32mok\x1b[0m\n"
+
+	for _, tc := range []struct {
+		format string
+		want   string
+	}{
+		{FormatRaw, colored},
+		{FormatJSON, `"data":"ok"`},
+	} {
+		var b bytes.Buffer
+		tt := task.FromCommands("npm test")
+		tt.Name = "test"
+		tt.TTY = true
+
+		o, err := NewTaskOutput(tt, tc.format, &b, &b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := o.Stdout().Write([]byte(colored)); err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Finish(); err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(b.String(), tc.want) {
+			t.Errorf("%s: output %q must contain %q", tc.format, b.String(), tc.want)
+		}
+		if tt.Log.Stdout.String() != "ok\n" {
+			t.Errorf("%s: the task's log must have no escape sequences, got %q", tc.format, tt.Log.Stdout.String())
+		}
+	}
+}
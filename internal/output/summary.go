@@ -11,11 +11,16 @@ import (
 	"charm.land/lipgloss/v2"
 
 	"github.com/taskctl/taskctl/internal/tui"
+	"github.com/taskctl/taskctl/process"
 	"github.com/taskctl/taskctl/task"
 )
 
 const logTailLines = 10
 
+// logTailBytes is how much of the end of a task's log the summary reads for
+// its tail.
+const logTailBytes = 64 * 1024
+
 // statusMarks drives both the counts header and the per-stage lines, in
 // display order. An unknown status (future typo or addition) falls back to
 // the last row rather than disappearing.
@@ -25,6 +30,7 @@ var statusMarks = []struct {
 }{
 	{"done", "✔", "succeeded", &tui.StyleSuccess},
 	{"failed", "✗", "failed", &tui.StyleError},
+	{"timed_out", "⏱", "timed out", &tui.StyleError},
 	{"skipped", "⊘", "skipped", &tui.StyleFaint},
 	{"canceled", "⊗", "canceled", &tui.StyleFaint},
 }
@@ -40,6 +46,8 @@ type StageSummary struct {
 	OutputBytes int
 	ErrMessage  string
 	LogTail     []string
+	LogFiles    []string
+	Annotations []process.Annotation
 }
 
 // SummarizeTask reads t's final state into a StageSummary without draining its
@@ -52,6 +60,8 @@ func SummarizeTask(t *task.Task) StageSummary {
 		Duration:    t.Duration(),
 		ExitCode:    t.ExitCode,
 		OutputBytes: t.Log.Stdout.Len() + t.Log.Stderr.Len(),
+		LogFiles:    t.LogFiles,
+		Annotations: t.Annotations,
 	}
 
 	// A task that "succeeded" without ever starting actually failed before
@@ -74,11 +84,13 @@ func SummarizeTask(t *task.Task) StageSummary {
 	return s
 }
 
-// SummarizeTasks maps SummarizeTask over tasks, preserving their order.
+// SummarizeTasks maps SummarizeTask over tasks, preserving their order. A
+// parallel task is followed by the variations or commands it ran.
 func SummarizeTasks(tasks []*task.Task) []StageSummary {
 	out := make([]StageSummary, 0, len(tasks))
 	for _, t := range tasks {
 		out = append(out, SummarizeTask(t))
+		out = append(out, SummarizeTasks(t.Units)...)
 	}
 	return out
 }
@@ -110,6 +122,12 @@ func PrintRunSummary(w io.Writer, items []StageSummary, total time.Duration) {
 	for _, it := range items {
 		tui.Println(w, summaryLine(it, nameWidth))
 		printFailureDetail(w, it)
+		for _, a := range it.Annotations {
+			tui.Println(w, "    "+tui.StyleWarning.Render("⚑ "+a.Location())+"  "+a.Message)
+		}
+		for _, f := range it.LogFiles {
+			tui.Println(w, tui.StyleFaint.Render("    log: "+f))
+		}
 	}
 }
 
@@ -125,6 +143,13 @@ func statusIndex(status string) int {
 	return len(statusMarks) - 1
 }
 
+// StatusMark renders the colored symbol the summary uses for status ("✔" for
+// done, "✗" for failed, ...), for other views listing run outcomes.
+func StatusMark(status string) string {
+	m := statusMarks[statusIndex(status)]
+	return m.style.Render(m.sym)
+}
+
 func summaryHeader(items []StageSummary, total time.Duration) string {
 	counts := make([]int, len(statusMarks))
 	for _, it := range items {
@@ -153,7 +178,14 @@ func summaryLine(it StageSummary, nameWidth int) string {
 	}
 
 	line += "  " + formatDuration(it.Duration)
-	if it.Status == "failed" {
+	if it.Status == "timed_out" {
+		line += tui.StyleError.Render("  timed out")
+	}
+	// A success code or an allowed failure other than 0.
+	if it.Status == "done" && it.ExitCode > 0 {
+		line += tui.StyleFaint.Render(fmt.Sprintf("  exit %d", it.ExitCode))
+	}
+	if IsFailure(it.Status) {
 		if it.ExitCode > 0 {
 			line += tui.StyleError.Render(fmt.Sprintf("  exit %d", it.ExitCode))
 		}
@@ -176,7 +208,7 @@ func formatDuration(d time.Duration) string {
 }
 
 func printFailureDetail(w io.Writer, it StageSummary) {
-	if it.Status != "failed" {
+	if !IsFailure(it.Status) {
 		return
 	}
 	if it.ErrMessage != "" {
@@ -188,10 +220,13 @@ func printFailureDetail(w io.Writer, it StageSummary) {
 }
 
 // lastLines extracts the final n lines without copying the whole buffer: it
-// scans backwards for line boundaries and converts only the bounded tail,
-// keeping the cost independent of how large the captured log is.
-func lastLines(buf *bytes.Buffer, n int) []string {
-	b := bytes.TrimRight(buf.Bytes(), "\r\n")
+// reads at most logTailBytes of its end, scans backwards for line boundaries
+// and converts only the bounded tail, keeping the cost independent of how
+// large the captured log is.
+func lastLines(buf *task.LogBuffer, n int) []string {
+	tail := buf.Tail(logTailBytes)
+	truncated := len(tail) < buf.Len()
+	b := bytes.TrimRight(tail, "\r\n")
 	if len(b) == 0 {
 		return nil
 	}
@@ -207,6 +242,11 @@ func lastLines(buf *bytes.Buffer, n int) []string {
 	}
 	if start > 0 {
 		start++ // step past the newline preceding the tail
+	} else if truncated {
+		// The first line was cut by the read; drop it unless it's all there is.
+		if nl := bytes.IndexByte(b, '\n'); nl >= 0 {
+			start = nl + 1
+		}
 	}
 
 	lines := strings.Split(string(b[start:]), "\n")
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/taskctl/taskctl/process"
 	"github.com/taskctl/taskctl/task"
 )
 
@@ -28,6 +29,10 @@ func TestSummarizeTask(t *testing.T) {
 
 	failed := failedTask("test", "line1\nboom: assertion failed\n", 2)
 
+	timedOut := failedTask("e2e", "waiting\n", -1)
+	timedOut.TimedOut = true
+	timedOut.Error = errors.New("timed out: task timeout of 1s exceeded")
+
 	tests := []struct {
 		name       string
 		task       *task.Task
@@ -39,6 +44,7 @@ func TestSummarizeTask(t *testing.T) {
 	}{
 		{"done", done, "done", 0, len("compiled\n"), "", nil},
 		{"skipped", skipped, "skipped", 0, 0, "", nil},
+		{"timed out", timedOut, "timed_out", -1, len("waiting\n"), "timed out: task timeout of 1s exceeded", []string{"waiting"}},
 		{"failed", failed, "failed", 2, len("line1\nboom: assertion failed\n"), "exit status 2", []string{"line1", "boom: assertion failed"}},
 	}
 
@@ -74,6 +80,22 @@ func TestSummarizeTaskNeverStartedIsFailed(t *testing.T) {
 	}
 }
 
+func TestSummarizeTasksIncludesParallelUnits(t *testing.T) {
+	build := &task.Task{Name: "build", Start: time.Unix(0, 0), End: time.Unix(2, 0)}
+	build.Units = []*task.Task{
+		{Name: "build (GOOS=linux)", Start: time.Unix(0, 0), End: time.Unix(1, 0)},
+		failedTask("build (GOOS=darwin)", "boom\n", 1),
+	}
+
+	got := SummarizeTasks([]*task.Task{build})
+	if len(got) != 3 {
+		t.Fatalf("expected the task followed by its units, got %d entries", len(got))
+	}
+	if got[1].Name != "build (GOOS=linux)" || got[2].Name != "build (GOOS=darwin)" || got[2].Status != "failed" {
+		t.Errorf("unexpected unit entries %+v", got[1:])
+	}
+}
+
 func TestSummarizeTaskFailedFallsBackToStdout(t *testing.T) {
 	t2 := &task.Task{Name: "x", Errored: true, Error: errors.New("boom")}
 	t2.Log.Stdout.WriteString("only-stdout\n")
@@ -99,11 +121,13 @@ func TestLastLines(t *testing.T) {
 		{"single no newline", "x", 10, []string{"x"}},
 		{"crlf endings", "a\r\nb\r\n", 5, []string{"a", "b"}},
 		{"progress overwrites", "10%\r50%\r100%\nok\n", 5, []string{"100%", "ok"}},
+		{"cut by the tail", strings.Repeat("x", logTailBytes) + "\na\nb\n", 5, []string{"a", "b"}},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			buf := bytes.NewBufferString(tt.input)
+			buf := &task.LogBuffer{}
+			_, _ = buf.WriteString(tt.input)
 			got := lastLines(buf, tt.n)
 			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
 				t.Errorf("lastLines(%q, %d) = %v, want %v", tt.input, tt.n, got, tt.want)
@@ -134,9 +158,11 @@ func TestHumanizeBytes(t *testing.T) {
 
 func TestPrintRunSummary(t *testing.T) {
 	items := []StageSummary{
-		{Name: "build", Status: "done", Start: time.Unix(1, 0), Duration: time.Second},
+		{Name: "build", Status: "done", Start: time.Unix(1, 0), Duration: time.Second, LogFiles: []string{"logs/build.log"}},
 		{Name: "test", Status: "failed", Start: time.Unix(2, 0), Duration: 3 * time.Second, ExitCode: 2, OutputBytes: 2048, ErrMessage: "exit status 2", LogTail: []string{"assertion failed"}},
 		{Name: "deploy", Status: "skipped", Start: time.Unix(3, 0)},
+		{Name: "lint", Status: "done", Start: time.Unix(5, 0), Duration: time.Second, ExitCode: 3, Annotations: []process.Annotation{{File: "main.go", Line: 12, Message: "unused variable"}}},
+		{Name: "e2e", Status: "timed_out", Start: time.Unix(4, 0), Duration: time.Second, ErrMessage: "timed out: task timeout of 1s exceeded"},
 	}
 
 	var buf bytes.Buffer
@@ -144,9 +170,13 @@ func TestPrintRunSummary(t *testing.T) {
 	out := buf.String()
 
 	for _, want := range []string{
-		"1 succeeded", "1 failed", "1 skipped", "4s total",
+		"2 succeeded", "1 failed", "1 skipped", "4s total",
 		"build", "test", "deploy",
 		"exit 2", "2.0 KB output", "assertion failed", "skipped",
+		"log: logs/build.log",
+		"1 timed out", "task timeout of 1s exceeded",
+		"exit 3",
+		"main.go:12  unused variable",
 	} {
 		if !strings.Contains(out, want) {
 			t.Errorf("summary missing %q\n---\n%s", want, out)
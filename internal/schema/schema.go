@@ -3,7 +3,6 @@
 package schema
 
 import (
-	"fmt"
 	"maps"
 	"slices"
 
@@ -11,6 +10,7 @@ import (
 	"github.com/taskctl/taskctl/internal/tmpl"
 	"github.com/taskctl/taskctl/scheduler"
 	"github.com/taskctl/taskctl/task"
+	"github.com/taskctl/taskctl/variables"
 )
 
 // ListResponse is the top-level document produced by `taskctl --output json list`.
@@ -20,6 +20,10 @@ type ListResponse struct {
 	Pipelines     []PipelineSummary `json:"pipelines"`
 	Contexts      []string          `json:"contexts"`
 	Watchers      []string          `json:"watchers"`
+	// Profiles are the profiles the config declares, Profile the one
+	// overlaid on it.
+	Profiles []string `json:"profiles"`
+	Profile  string   `json:"profile,omitempty"`
 }
 
 // TaskSummary is a brief description of a task, as listed by `list`.
@@ -37,16 +41,19 @@ type PipelineSummary struct {
 
 // TaskDetail is the full description of a task, as produced by `taskctl --output json show`.
 type TaskDetail struct {
-	Name           string            `json:"name"`
-	Description    string            `json:"description,omitempty"`
-	Context        string            `json:"context,omitempty"`
-	Commands       []string          `json:"commands"`
-	Env            map[string]string `json:"env"`
-	Variables      map[string]string `json:"variables"`
-	Dir            string            `json:"dir,omitempty"`
-	TimeoutSeconds *float64          `json:"timeout_seconds,omitempty"`
-	AllowFailure   bool              `json:"allow_failure"`
-	Condition      string            `json:"condition,omitempty"`
+	Name                  string            `json:"name"`
+	Description           string            `json:"description,omitempty"`
+	Context               string            `json:"context,omitempty"`
+	Commands              []string          `json:"commands"`
+	Env                   map[string]string `json:"env"`
+	Variables             map[string]string `json:"variables"`
+	Dir                   string            `json:"dir,omitempty"`
+	TimeoutSeconds        *float64          `json:"timeout_seconds,omitempty"`
+	CommandTimeoutSeconds *float64          `json:"command_timeout_seconds,omitempty"`
+	SuccessCodes          []int             `json:"success_codes,omitempty"`
+	AllowFailure          bool              `json:"allow_failure"`
+	AllowFailureExitCodes []int             `json:"allow_failure_exit_codes,omitempty"`
+	Condition             string            `json:"condition,omitempty"`
 }
 
 // PipelineDetail is the full description of a pipeline, as produced by `taskctl --output json show`.
@@ -59,12 +66,14 @@ type PipelineDetail struct {
 // Exactly one of Task or Pipeline is set: Task names the task the stage runs,
 // Pipeline marks the stage as a nested sub-pipeline.
 type StageDetail struct {
-	Name         string   `json:"name"`
-	Task         string   `json:"task,omitempty"`
-	Pipeline     string   `json:"pipeline,omitempty"`
-	DependsOn    []string `json:"depends_on"`
-	Condition    string   `json:"condition,omitempty"`
-	AllowFailure bool     `json:"allow_failure"`
+	Name                  string   `json:"name"`
+	Task                  string   `json:"task,omitempty"`
+	Pipeline              string   `json:"pipeline,omitempty"`
+	DependsOn             []string `json:"depends_on"`
+	Condition             string   `json:"condition,omitempty"`
+	SuccessCodes          []int    `json:"success_codes,omitempty"`
+	AllowFailure          bool     `json:"allow_failure"`
+	AllowFailureExitCodes []int    `json:"allow_failure_exit_codes,omitempty"`
 }
 
 // NewTaskSummary builds a TaskSummary from a task.Task.
@@ -79,24 +88,31 @@ func NewTaskSummary(t *task.Task) TaskSummary {
 // NewTaskDetail builds a TaskDetail from a task.Task. vars carries the
 // config-level variables (e.g. Root, TempDir) merged under the task's own,
 // used to render templated fields such as dir; templates that need runtime
-// values are left as-is.
+// values, computed ones included, are left as-is. Computed variables and env
+// are shown as declared, "sh: command".
 func NewTaskDetail(t *task.Task, vars map[string]any) TaskDetail {
 	detail := TaskDetail{
-		Name:         t.Name,
-		Description:  t.Description,
-		Context:      t.Context,
-		Commands:     collections.OrEmpty(t.Commands),
-		Env:          stringifyMap(t.Env.Map()),
-		Variables:    stringifyMap(t.Variables.Map()),
-		Dir:          renderOrRaw(t.Dir, vars),
-		AllowFailure: t.AllowFailure,
-		Condition:    t.Condition,
+		Name:                  t.Name,
+		Description:           t.Description,
+		Context:               t.Context,
+		Commands:              collections.OrEmpty(t.Commands),
+		Env:                   stringifyMap(t.Env.Map()),
+		Variables:             stringifyMap(t.Variables.Map()),
+		Dir:                   renderOrRaw(t.Dir, vars),
+		SuccessCodes:          t.SuccessCodes,
+		AllowFailure:          t.AllowFailure,
+		AllowFailureExitCodes: t.AllowFailureExitCodes,
+		Condition:             t.Condition,
 	}
 
 	if t.Timeout != nil {
 		seconds := t.Timeout.Seconds()
 		detail.TimeoutSeconds = &seconds
 	}
+	if t.CommandTimeout != nil {
+		seconds := t.CommandTimeout.Seconds()
+		detail.CommandTimeoutSeconds = &seconds
+	}
 
 	return detail
 }
@@ -123,12 +139,14 @@ func NewPipelineDetail(name string, g *scheduler.ExecutionGraph) PipelineDetail
 		}
 
 		stages = append(stages, StageDetail{
-			Name:         stage.Name,
-			Task:         taskName,
-			Pipeline:     pipelineName,
-			DependsOn:    collections.OrEmpty(stage.DependsOn),
-			Condition:    stage.Condition,
-			AllowFailure: stage.AllowFailure,
+			Name:                  stage.Name,
+			Task:                  taskName,
+			Pipeline:              pipelineName,
+			DependsOn:             collections.OrEmpty(stage.DependsOn),
+			Condition:             stage.Condition,
+			SuccessCodes:          stage.SuccessCodes,
+			AllowFailure:          stage.AllowFailure,
+			AllowFailureExitCodes: stage.AllowFailureExitCodes,
 		})
 	}
 
@@ -155,6 +173,11 @@ func sortedStageNames(nodes map[string]*scheduler.Stage) []string {
 // renderOrRaw renders s as a template with vars, falling back to the raw
 // string when rendering fails (e.g. the template needs runtime-only values).
 func renderOrRaw(s string, vars map[string]any) string {
+	vars = maps.Clone(vars)
+	maps.DeleteFunc(vars, func(_ string, v any) bool {
+		_, computed := v.(variables.Command)
+		return computed
+	})
 	rendered, err := tmpl.RenderString(s, vars)
 	if err != nil {
 		return s
@@ -166,7 +189,7 @@ func renderOrRaw(s string, vars map[string]any) string {
 func stringifyMap(m map[string]any) map[string]string {
 	result := make(map[string]string, len(m))
 	for k, v := range m {
-		result[k] = fmt.Sprint(v)
+		result[k] = variables.Format(v)
 	}
 
 	return result
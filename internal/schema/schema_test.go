@@ -8,6 +8,7 @@ import (
 
 	"github.com/taskctl/taskctl/scheduler"
 	"github.com/taskctl/taskctl/task"
+	"github.com/taskctl/taskctl/variables"
 )
 
 func buildTestTask() *task.Task {
@@ -23,6 +24,8 @@ func buildTestTask() *task.Task {
 	t.Variables = t.Variables.With("VAR1", "value1")
 	timeout := 5 * time.Second
 	t.Timeout = &timeout
+	commandTimeout := 2 * time.Second
+	t.CommandTimeout = &commandTimeout
 
 	return t
 }
@@ -86,6 +89,9 @@ func TestNewTaskDetail(t *testing.T) {
 	if detail.TimeoutSeconds == nil || *detail.TimeoutSeconds != 5 {
 		t.Errorf("expected timeout_seconds 5, got %+v", detail.TimeoutSeconds)
 	}
+	if detail.CommandTimeoutSeconds == nil || *detail.CommandTimeoutSeconds != 2 {
+		t.Errorf("expected command_timeout_seconds 2, got %+v", detail.CommandTimeoutSeconds)
+	}
 	if !detail.AllowFailure {
 		t.Errorf("expected allow_failure true")
 	}
@@ -124,6 +130,22 @@ func TestNewTaskDetailRendersDir(t *testing.T) {
 	if detail.Dir != "{{.Root}}/sub" {
 		t.Errorf("expected raw dir on render failure, got %q", detail.Dir)
 	}
+
+	// Computed values are only known when the task runs.
+	detail = NewTaskDetail(tk, map[string]any{"Root": variables.Command{Script: "git rev-parse --show-toplevel"}})
+	if detail.Dir != "{{.Root}}/sub" {
+		t.Errorf("expected raw dir with a computed variable, got %q", detail.Dir)
+	}
+}
+
+func TestNewTaskDetailComputedValues(t *testing.T) {
+	tk := buildTestTask()
+	tk.Env.Set("COMMIT", variables.Command{Script: "git rev-parse HEAD"})
+
+	detail := NewTaskDetail(tk, nil)
+	if got := detail.Env["COMMIT"]; got != "sh: git rev-parse HEAD" {
+		t.Errorf("expected the computed env entry as declared, got %q", got)
+	}
 }
 
 func TestNewTaskDetailOmitsEmptyOptionalFields(t *testing.T) {
@@ -0,0 +1,329 @@
+package tmpl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/taskctl/taskctl/internal/iox"
+)
+
+// funcs are the functions templates can call. Functions taking the value
+// they work on take it last, so it can be piped: {{ .Stdout | trim }},
+// {{ .ArgsList | join "," }}.
+var funcs = template.FuncMap{
+	// Values
+	"default":  defaultValue,
+	"required": required,
+	"fail":     fail,
+	"list":     func(items ...any) []any { return items },
+	"dict":     dict,
+	"first":    first,
+	"last":     last,
+
+	// Strings
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+	"lines":      lines,
+	"join":       join,
+	"shellQuote": shellQuote,
+
+	// Regular expressions
+	"regexMatch":   regexMatch,
+	"regexFind":    regexFind,
+	"regexReplace": regexReplace,
+
+	// Environment and files; RenderStringEnv binds env to another environment
+	"env":        os.Getenv,
+	"readFile":   readFile,
+	"fileExists": fileExists,
+	"sha256sum":  sha256sum,
+	"sha256file": sha256file,
+
+	// Paths
+	"base":     filepath.Base,
+	"dir":      filepath.Dir,
+	"ext":      filepath.Ext,
+	"clean":    filepath.Clean,
+	"abs":      filepath.Abs,
+	"pathJoin": filepath.Join,
+
+	// Encoding
+	"toJson":       toJSON,
+	"toPrettyJson": toPrettyJSON,
+	"fromJson":     fromJSON,
+	"toYaml":       toYAML,
+	"fromYaml":     fromYAML,
+	"b64enc":       func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"b64dec":       b64dec,
+
+	// Time
+	"now":       time.Now,
+	"date":      func(layout string, t time.Time) string { return t.Format(layout) },
+	"unixEpoch": func(t time.Time) int64 { return t.Unix() },
+
+	// Versions
+	"semver":        normalizeSemver,
+	"semverCompare": semverCompare,
+}
+
+// empty reports whether value is the zero value of a string, collection or
+// bool; other values, numbers included, are never empty.
+func empty(value any) bool {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Invalid:
+		return true
+	default:
+		return false
+	}
+}
+
+// defaultValue returns value, or arg when value is empty.
+func defaultValue(arg any, value any) any {
+	if value != nil && empty(value) {
+		return arg
+	}
+
+	return value
+}
+
+// required returns value, failing with message when it is empty.
+func required(message string, value any) (any, error) {
+	if empty(value) {
+		return nil, errors.New(message)
+	}
+
+	return value, nil
+}
+
+// fail fails the rendering with message.
+func fail(message string) (string, error) {
+	return "", errors.New(message)
+}
+
+// dict builds a map from its arguments, alternately keys and values.
+func dict(pairs ...any) (map[string]any, error) {
+	if len(pairs)%2 != 0 {
+		return nil, errors.New("dict needs an even number of arguments")
+	}
+
+	m := make(map[string]any, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		m[fmt.Sprint(pairs[i])] = pairs[i+1]
+	}
+
+	return m, nil
+}
+
+// items returns the elements of a slice or array.
+func items(list any) ([]any, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("%T is not a list", list)
+	}
+
+	out := make([]any, v.Len())
+	for i := range out {
+		out[i] = v.Index(i).Interface()
+	}
+
+	return out, nil
+}
+
+// first returns the first element of list, or nil when it is empty.
+func first(list any) (any, error) {
+	l, err := items(list)
+	if err != nil || len(l) == 0 {
+		return nil, err
+	}
+
+	return l[0], nil
+}
+
+// last returns the last element of list, or nil when it is empty.
+func last(list any) (any, error) {
+	l, err := items(list)
+	if err != nil || len(l) == 0 {
+		return nil, err
+	}
+
+	return l[len(l)-1], nil
+}
+
+// lines splits s into its lines, without their line endings.
+func lines(s string) []string {
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "\n"), "\r")
+	if s == "" {
+		return []string{}
+	}
+
+	l := strings.Split(s, "\n")
+	for i := range l {
+		l[i] = strings.TrimSuffix(l[i], "\r")
+	}
+
+	return l
+}
+
+// join joins the elements of list, formatted as with print, with sep.
+func join(sep string, list any) (string, error) {
+	l, err := items(list)
+	if err != nil {
+		return "", err
+	}
+
+	s := make([]string, len(l))
+	for i, item := range l {
+		s[i] = fmt.Sprint(item)
+	}
+
+	return strings.Join(s, sep), nil
+}
+
+// shellQuote quotes a string, or each element of a list, so the shell reads
+// it as a single word; list elements are separated by spaces.
+func shellQuote(value any) (string, error) {
+	quote := func(s string) string {
+		if s != "" && strings.IndexFunc(s, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,+@%", r))
+		}) < 0 {
+			return s
+		}
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	}
+
+	if s, ok := value.(string); ok {
+		return quote(s), nil
+	}
+
+	l, err := items(value)
+	if err != nil {
+		return "", err
+	}
+	words := make([]string, len(l))
+	for i, item := range l {
+		words[i] = quote(fmt.Sprint(item))
+	}
+
+	return strings.Join(words, " "), nil
+}
+
+func regexMatch(re, s string) (bool, error) {
+	return regexp.MatchString(re, s)
+}
+
+// regexFind returns the first match of re in s, or "".
+func regexFind(re, s string) (string, error) {
+	r, err := regexp.Compile(re)
+	if err != nil {
+		return "", err
+	}
+
+	return r.FindString(s), nil
+}
+
+// regexReplace replaces the matches of re in s with repl, in which $1 or
+// ${name} stand for submatches.
+func regexReplace(re, repl, s string) (string, error) {
+	r, err := regexp.Compile(re)
+	if err != nil {
+		return "", err
+	}
+
+	return r.ReplaceAllString(s, repl), nil
+}
+
+func readFile(name string) (string, error) {
+	b, err := os.ReadFile(name)
+	return string(b), err
+}
+
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+func sha256sum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func sha256file(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer iox.Close(f)
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func toJSON(v any) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+func toPrettyJSON(v any) (string, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	return string(b), err
+}
+
+func fromJSON(s string) (any, error) {
+	var v any
+	err := json.Unmarshal([]byte(s), &v)
+	return v, err
+}
+
+func toYAML(v any) (string, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n"), enc.Close()
+}
+
+func fromYAML(s string) (any, error) {
+	var v any
+	err := yaml.Unmarshal([]byte(s), &v)
+	return v, err
+}
+
+func b64dec(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	return string(b), err
+}
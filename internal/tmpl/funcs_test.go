@@ -0,0 +1,115 @@
+package tmpl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderString_funcs(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "data.txt")
+	if err := os.WriteFile(file, []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TASKCTL_TMPL_TEST", "from env")
+
+	vars := map[string]any{
+		"ArgsList": []string{"a b", "c", "it's"},
+		"Stdout":   "  v1.2.3\n",
+		"Empty":    "",
+		"File":     file,
+		"Json":     `{"name":"taskctl","tags":["a","b"]}`,
+		"Yaml":     "name: taskctl\nport: 8080\n",
+		"Lines":    "one\r\ntwo\n",
+		"Time":     time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC),
+	}
+
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{`{{ .Empty | default "x" }}`, "x"},
+		{`{{ .Stdout | default "x" | trim }}`, "v1.2.3"},
+		{`{{ required "no stdout" .Stdout | trim }}`, "v1.2.3"},
+		{`{{ list 1 2 3 | join "," }}`, "1,2,3"},
+		{`{{ .ArgsList | join "," }}`, "a b,c,it's"},
+		{`{{ .ArgsList | first }}-{{ .ArgsList | last }}`, "a b-it's"},
+		{`{{ .ArgsList | shellQuote }}`, `'a b' c 'it'\''s'`},
+		{`{{ shellQuote "" }}`, "''"},
+		{`{{ .Stdout | trim | trimPrefix "v" }}`, "1.2.3"},
+		{`{{ "file.tar.gz" | trimSuffix ".gz" | upper }}`, "FILE.TAR"},
+		{`{{ "A-B" | lower | replace "-" "_" }}`, "a_b"},
+		{`{{ contains "ell" "hello" }} {{ hasPrefix "he" "hello" }} {{ hasSuffix "x" "hello" }}`, "true true false"},
+		{`{{ split "," "a,b" | len }}`, "2"},
+		{`{{ range lines .Lines }}[{{ . }}]{{ end }}`, "[one][two]"},
+		{`{{ regexMatch "^v[0-9]" (trim .Stdout) }}`, "true"},
+		{`{{ regexFind "[0-9]+\\.[0-9]+" .Stdout }}`, "1.2"},
+		{`{{ regexReplace "v(\\d+)\\.(\\d+).*" "$1-$2" (trim .Stdout) }}`, "1-2"},
+		{`{{ env "TASKCTL_TMPL_TEST" }}`, "from env"},
+		{`{{ readFile .File | trim }} {{ fileExists .File }} {{ fileExists "/nonexistent" }}`, "hello true false"},
+		{`{{ sha256sum "hello\n" }}`, "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"},
+		{`{{ sha256file .File }}`, "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"},
+		{`{{ base .File }} {{ ext .File }} {{ dir "/a/b/c" }} {{ clean "/a/../b/" }} {{ pathJoin "a" "b" "c.txt" }}`, "data.txt .txt /a/b /b a/b/c.txt"},
+		{`{{ (fromJson .Json).name }} {{ index (fromJson .Json).tags 1 }}`, "taskctl b"},
+		{`{{ dict "a" 1 "b" (list "x") | toJson }}`, `{"a":1,"b":["x"]}`},
+		{`{{ (fromYaml .Yaml).port }}`, "8080"},
+		{`{{ dict "name" "taskctl" | toYaml }}`, "name: taskctl"},
+		{`{{ "hi" | b64enc }} {{ "aGk=" | b64dec }}`, "aGk= hi"},
+		{`{{ date "2006-01-02" .Time }} {{ unixEpoch .Time }}`, "2025-01-02 1735830245"},
+		{`{{ semver "v1.2" }}`, "1.2.0"},
+		{`{{ semverCompare ">=1.2.0, <2" (trim .Stdout) }}`, "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tmpl, func(t *testing.T) {
+			got, err := RenderString(tt.tmpl, vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderString_funcErrors(t *testing.T) {
+	vars := map[string]any{"Empty": "", "Nil": nil}
+
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{`{{ required "VERSION must be set" .Empty }}`, "VERSION must be set"},
+		{`{{ required "no value" .Nil }}`, "no value"},
+		{`{{ fail "unsupported platform" }}`, "unsupported platform"},
+		{`{{ join "," "abc" }}`, "not a list"},
+		{`{{ dict "a" }}`, "even number"},
+		{`{{ readFile "/nonexistent/file" }}`, "no such file"},
+		{`{{ fromJson "{" }}`, "unexpected end"},
+		{`{{ regexMatch "(" "x" }}`, "missing closing"},
+		{`{{ semver "latest" }}`, "invalid version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tmpl, func(t *testing.T) {
+			_, err := RenderString(tt.tmpl, vars)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("RenderString() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderString_now(t *testing.T) {
+	got, err := RenderString(`{{ now | date "2006" }}`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Now().Format("2006"); got != want {
+		t.Errorf("RenderString() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,174 @@
+package tmpl
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// version is a parsed semantic version. Build metadata is dropped, as it
+// doesn't take part in comparisons.
+type version struct {
+	major, minor, patch uint64
+	pre                 []string
+}
+
+// parseSemver parses a semantic version with an optional leading "v". Missing
+// minor and patch numbers are 0, so "v1.2" is 1.2.0.
+func parseSemver(s string) (version, error) {
+	var v version
+	core, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(s), "v"), "V"), "+")
+	core, pre, hasPre := strings.Cut(core, "-")
+	if hasPre {
+		v.pre = strings.Split(pre, ".")
+		for _, id := range v.pre {
+			if id == "" {
+				return version{}, fmt.Errorf("invalid version %q", s)
+			}
+		}
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 {
+		return version{}, fmt.Errorf("invalid version %q", s)
+	}
+	for i, n := range []*uint64{&v.major, &v.minor, &v.patch}[:len(parts)] {
+		var err error
+		if *n, err = strconv.ParseUint(parts[i], 10, 64); err != nil {
+			return version{}, fmt.Errorf("invalid version %q", s)
+		}
+	}
+
+	return v, nil
+}
+
+func (v version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.major, v.minor, v.patch)
+	if len(v.pre) > 0 {
+		s += "-" + strings.Join(v.pre, ".")
+	}
+
+	return s
+}
+
+// compare orders versions as semantic versioning does: a pre-release comes
+// before its release, and pre-release identifiers compare numerically when
+// both are numbers.
+func (v version) compare(o version) int {
+	if c := cmp.Or(cmp.Compare(v.major, o.major), cmp.Compare(v.minor, o.minor), cmp.Compare(v.patch, o.patch)); c != 0 {
+		return c
+	}
+
+	switch {
+	case len(v.pre) == 0 && len(o.pre) == 0:
+		return 0
+	case len(v.pre) == 0:
+		return 1
+	case len(o.pre) == 0:
+		return -1
+	}
+
+	for i := range min(len(v.pre), len(o.pre)) {
+		a, aErr := strconv.ParseUint(v.pre[i], 10, 64)
+		b, bErr := strconv.ParseUint(o.pre[i], 10, 64)
+		var c int
+		switch {
+		case aErr == nil && bErr == nil:
+			c = cmp.Compare(a, b)
+		case aErr == nil:
+			c = -1
+		case bErr == nil:
+			c = 1
+		default:
+			c = strings.Compare(v.pre[i], o.pre[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+
+	return cmp.Compare(len(v.pre), len(o.pre))
+}
+
+// normalizeSemver returns s as MAJOR.MINOR.PATCH[-PRERELEASE], failing when
+// it is not a version.
+func normalizeSemver(s string) (string, error) {
+	v, err := parseSemver(s)
+	if err != nil {
+		return "", err
+	}
+
+	return v.String(), nil
+}
+
+// semverCompare reports whether version s satisfies constraint: comparisons
+// such as ">=1.2.0" that must all hold, separated by commas or spaces, with
+// alternatives separated by "||". The operators are =, !=, >, >=, <, <=,
+// ~ (the same minor version, at least as recent) and ^ (the same major
+// version or, for 0.x, minor version, at least as recent); none means =.
+func semverCompare(constraint, s string) (bool, error) {
+	v, err := parseSemver(s)
+	if err != nil {
+		return false, err
+	}
+
+	for alternative := range strings.SplitSeq(constraint, "||") {
+		ok, err := satisfiesAll(v, alternative)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+
+	return false, nil
+}
+
+func satisfiesAll(v version, constraint string) (bool, error) {
+	fields := strings.FieldsFunc(constraint, func(r rune) bool { return r == ',' || r == ' ' })
+	if len(fields) == 0 {
+		return false, fmt.Errorf("invalid version constraint %q", constraint)
+	}
+
+	// An operator may be separated from its version, as in ">= 1.2".
+	for i := 0; i < len(fields); i++ {
+		op := strings.TrimRight(fields[i], "0123456789.vV-+abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
+		want := fields[i][len(op):]
+		if want == "" && i+1 < len(fields) {
+			i++
+			want = fields[i]
+		}
+
+		w, err := parseSemver(want)
+		if err != nil {
+			return false, fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+		}
+
+		c := v.compare(w)
+		var ok bool
+		switch op {
+		case "", "=", "==":
+			ok = c == 0
+		case "!=":
+			ok = c != 0
+		case ">":
+			ok = c > 0
+		case ">=":
+			ok = c >= 0
+		case "<":
+			ok = c < 0
+		case "<=":
+			ok = c <= 0
+		case "~":
+			ok = c >= 0 && v.major == w.major && v.minor == w.minor
+		case "^":
+			ok = c >= 0 && v.major == w.major && (w.major != 0 || v.minor == w.minor)
+		default:
+			return false, fmt.Errorf("invalid version constraint %q: unknown operator %q", constraint, op)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
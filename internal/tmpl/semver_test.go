@@ -0,0 +1,67 @@
+package tmpl
+
+import "testing"
+
+func Test_semverCompare(t *testing.T) {
+	tests := []struct {
+		constraint, version string
+		want                bool
+	}{
+		{"1.2.3", "v1.2.3", true},
+		{"=1.2", "1.2.0", true},
+		{"!=1.2.3", "1.2.4", true},
+		{">1.2.3", "1.10.0", true},
+		{">=1.2.3", "1.2.3-rc.1", false},
+		{"<1.2.3", "1.2.3-rc.1", true},
+		{"<=2", "2.0.0", true},
+		{">= 1.2, < 2", "1.9.9", true},
+		{">= 1.2 < 2", "2.0.0", false},
+		{"<1 || >=3", "3.1.0", true},
+		{"<1 || >=3", "2.0.0", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{"^1.2.3", "1.9.0", true},
+		{"^1.2.3", "2.0.0", false},
+		{"^0.2.3", "0.3.0", false},
+		{">1.0.0-alpha", "1.0.0-alpha.1", true},
+		{">1.0.0-alpha.2", "1.0.0-alpha.10", true},
+		{">1.0.0-alpha.beta", "1.0.0-beta", true},
+		{"<1.0.0-rc.1", "1.0.0-beta.11", true},
+		{"=1.0.0", "1.0.0+build.5", true},
+	}
+
+	for _, tt := range tests {
+		got, err := semverCompare(tt.constraint, tt.version)
+		if err != nil {
+			t.Errorf("semverCompare(%q, %q) error = %v", tt.constraint, tt.version, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("semverCompare(%q, %q) = %v, want %v", tt.constraint, tt.version, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct{ constraint, version string }{
+		{">=1.2", "latest"},
+		{"", "1.0.0"},
+		{"=>1.2", "1.2.0"},
+		{">=x", "1.2.0"},
+		{">=1.2.3.4", "1.2.0"},
+	} {
+		if _, err := semverCompare(tt.constraint, tt.version); err == nil {
+			t.Errorf("semverCompare(%q, %q) expected an error", tt.constraint, tt.version)
+		}
+	}
+}
+
+func Test_normalizeSemver(t *testing.T) {
+	for in, want := range map[string]string{
+		"v1":               "1.0.0",
+		"1.2":              "1.2.0",
+		"V1.2.3-rc.1+b.42": "1.2.3-rc.1",
+	} {
+		if got, err := normalizeSemver(in); err != nil || got != want {
+			t.Errorf("normalizeSemver(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+}
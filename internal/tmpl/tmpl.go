@@ -3,34 +3,38 @@ package tmpl
 
 import (
 	"bytes"
-	"reflect"
+	"maps"
+	"os"
+	"slices"
+	"strings"
 	"text/template"
+	"text/template/parse"
 )
 
 // RenderString parses given string as a template and executes it with provided params
 func RenderString(tmpl string, variables map[string]any) (string, error) {
-	funcMap := template.FuncMap{
-		"default": func(arg any, value any) any {
-			v := reflect.ValueOf(value)
-			switch v.Kind() {
-			case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
-				if v.Len() == 0 {
-					return arg
-				}
-			case reflect.Bool:
-				if !v.Bool() {
-					return arg
-				}
-			default:
-				return value
+	return render(tmpl, variables, os.Getenv)
+}
+
+// RenderStringEnv renders tmpl like RenderString, with env reading environ,
+// a list of KEY=value entries such as a task's environment, rather than
+// taskctl's own.
+func RenderStringEnv(tmpl string, variables map[string]any, environ []string) (string, error) {
+	return render(tmpl, variables, func(name string) string {
+		var value string
+		for _, kv := range environ {
+			if k, v, ok := strings.Cut(kv, "="); ok && k == name {
+				value = v
 			}
+		}
 
-			return value
-		},
-	}
+		return value
+	})
+}
 
+func render(tmpl string, variables map[string]any, env func(name string) string) (string, error) {
 	var buf bytes.Buffer
-	t, err := template.New("interpolate").Funcs(funcMap).Option("missingkey=error").Parse(tmpl)
+	t, err := template.New("interpolate").Funcs(funcs).Funcs(template.FuncMap{"env": env}).Option("missingkey=error").Parse(tmpl)
 	if err != nil {
 		return "", err
 	}
@@ -39,3 +43,89 @@ func RenderString(tmpl string, variables map[string]any) (string, error) {
 
 	return buf.String(), err
 }
+
+// Uses reports whether tmpl may read the variable name: through .name or
+// $.name, or through the whole data, such as {{ . }} or {{ index . "name" }}.
+// A template that doesn't parse is assumed to use every variable.
+func Uses(tmpl, name string) bool {
+	names, all := References(tmpl)
+
+	return all || slices.Contains(names, name)
+}
+
+// References returns the variables tmpl reads through .name or $.name, sorted.
+// all reports that it may read any of them, through the whole data or as it
+// doesn't parse (see Uses).
+func References(tmpl string) (names []string, all bool) {
+	t, err := template.New("references").Funcs(funcs).Parse(tmpl)
+	if err != nil {
+		return nil, true
+	}
+
+	refs := &references{names: make(map[string]bool)}
+	for _, t := range t.Templates() {
+		if t.Tree != nil {
+			refs.walk(t.Root)
+		}
+	}
+
+	return slices.Sorted(maps.Keys(refs.names)), refs.all
+}
+
+// references collects what References returns. A dot rebound by range or
+// with is taken as the data's, which errs on the side of reading more.
+type references struct {
+	names map[string]bool
+	all   bool
+}
+
+func (r *references) walk(n parse.Node) {
+	switch n := n.(type) {
+	case *parse.DotNode:
+		r.all = true
+	case *parse.FieldNode:
+		r.names[n.Ident[0]] = true
+	case *parse.VariableNode:
+		switch {
+		case n.Ident[0] != "$":
+		case len(n.Ident) == 1:
+			r.all = true
+		default:
+			r.names[n.Ident[1]] = true
+		}
+	case *parse.ChainNode:
+		r.walk(n.Node)
+	case *parse.ListNode:
+		if n != nil {
+			for _, n := range n.Nodes {
+				r.walk(n)
+			}
+		}
+	case *parse.ActionNode:
+		r.walk(n.Pipe)
+	case *parse.PipeNode:
+		if n != nil {
+			for _, n := range n.Cmds {
+				r.walk(n)
+			}
+		}
+	case *parse.CommandNode:
+		for _, n := range n.Args {
+			r.walk(n)
+		}
+	case *parse.IfNode:
+		r.walk(n.Pipe)
+		r.walk(n.List)
+		r.walk(n.ElseList)
+	case *parse.RangeNode:
+		r.walk(n.Pipe)
+		r.walk(n.List)
+		r.walk(n.ElseList)
+	case *parse.WithNode:
+		r.walk(n.Pipe)
+		r.walk(n.List)
+		r.walk(n.ElseList)
+	case *parse.TemplateNode:
+		r.walk(n.Pipe)
+	}
+}
@@ -1,6 +1,9 @@
 package tmpl
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestRenderString(t *testing.T) {
 	type args struct {
@@ -34,3 +37,50 @@ func TestRenderString(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderStringEnv(t *testing.T) {
+	t.Setenv("TASKCTL_TMPL_HOST", "host")
+
+	got, err := RenderStringEnv(`{{ env "A" }} {{ env "B" }} [{{ env "TASKCTL_TMPL_HOST" }}]`, nil, []string{"A=1", "B=x=y", "A=2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "2 x=y []"; got != want {
+		t.Errorf("RenderStringEnv() got = %q, want %q", got, want)
+	}
+}
+
+func TestUses(t *testing.T) {
+	tests := []struct {
+		tmpl string
+		want bool
+	}{
+		{tmpl: "echo done", want: false},
+		{tmpl: "echo {{ .Output }}", want: true},
+		{tmpl: "echo {{ .Output | trim }}", want: true},
+		{tmpl: "echo {{ $.Output }}", want: true},
+		{tmpl: "{{ if .Debug }}{{ .Output }}{{ end }}", want: true},
+		{tmpl: "{{ range .List }}{{ . }}{{ end }}", want: true},
+		{tmpl: `{{ index . "Output" }}`, want: true},
+		{tmpl: "{{ $v := .Task }}{{ $v.Name }}", want: false},
+		{tmpl: "echo Output {{ .Task.Output }} {{ .Outputs }}", want: false},
+		{tmpl: "{{ .Output", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tmpl, func(t *testing.T) {
+			if got := Uses(tt.tmpl, "Output"); got != tt.want {
+				t.Errorf("Uses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReferences(t *testing.T) {
+	names, all := References(`{{ .Image }}:{{ $.Version | default "dev" }} {{ with .Task }}{{ .Name }}{{ end }}`)
+	if all || !reflect.DeepEqual(names, []string{"Image", "Name", "Task", "Version"}) {
+		t.Errorf("References() = %v, %v", names, all)
+	}
+	if _, all := References("{{ toJSON $ }}"); !all {
+		t.Error("a template passing the whole data on must read every variable")
+	}
+}
@@ -17,4 +17,5 @@ var (
 	StyleBold    = lipgloss.NewStyle().Bold(true)
 	StylePrefix  = lipgloss.NewStyle().Foreground(lipgloss.Color("6")) // cyan, task-name prefix
 	StyleSpinner = lipgloss.NewStyle().Foreground(lipgloss.Color("3")) // yellow, dashboard spinner
+	StyleWarning = lipgloss.NewStyle().Foreground(lipgloss.Color("3")) // yellow
 )
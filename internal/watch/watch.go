@@ -133,10 +133,12 @@ func (w *Watcher) Run(r *runner.TaskRunner) (err error) {
 		w.runMu.Lock()
 		defer w.runMu.Unlock()
 		// Clone so w.task stays a pristine definition; Run mutates run state.
-		err := w.r.Run(w.task.Clone())
+		t := w.task.Clone()
+		err := w.r.Run(t)
 		if err != nil {
 			slog.Error(err.Error())
 		}
+		t.Close()
 	}()
 
 	go func() {
@@ -236,4 +238,5 @@ func (w *Watcher) handle(event fsnotify.Event) {
 	if err != nil {
 		slog.Error(err.Error())
 	}
+	t.Close()
 }
@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/taskctl/taskctl/cmd"
+	"github.com/taskctl/taskctl/executor"
 )
 
 var version = "dev"
@@ -18,6 +19,9 @@ var version = "dev"
 var skillTemplate string
 
 func main() {
+	// A command started under resource limits runs through taskctl first.
+	executor.RunLimitsShim()
+
 	cmd.SetSkillTemplate(skillTemplate)
 
 	if err := cmd.Run(version); err != nil {
@@ -0,0 +1,20 @@
+package process
+
+import "fmt"
+
+// Annotation is a note a command attaches to a location in a file, e.g. a
+// lint finding; Line is 0 when the note is about the whole file.
+type Annotation struct {
+	File    string
+	Line    int
+	Message string
+}
+
+// Location renders the annotated location as "file:line", or just the file.
+func (a Annotation) Location() string {
+	if a.Line > 0 {
+		return fmt.Sprintf("%s:%d", a.File, a.Line)
+	}
+
+	return a.File
+}
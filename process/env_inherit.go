@@ -0,0 +1,71 @@
+package process
+
+import (
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// EnvInherit selects the host environment variables a job's environment
+// starts from, before the job's own env is applied. A nil *EnvInherit
+// inherits all of them.
+type EnvInherit struct {
+	// All inherits every host variable; Names is ignored.
+	All bool
+	// Names lists the host variables to inherit. Empty inherits none.
+	Names []string
+}
+
+// Merge returns o when it is set, e otherwise: a task's setting replaces its
+// context's. Either may be nil.
+func (e *EnvInherit) Merge(o *EnvInherit) *EnvInherit {
+	if o != nil {
+		return o
+	}
+
+	return e
+}
+
+// Inherits reports whether name is inherited from the host environment.
+func (e *EnvInherit) Inherits(name string) bool {
+	if e == nil || e.All {
+		return true
+	}
+
+	return slices.ContainsFunc(e.Names, func(n string) bool {
+		// Windows environment variable names are case-insensitive.
+		if runtime.GOOS == "windows" {
+			return strings.EqualFold(n, name)
+		}
+		return n == name
+	})
+}
+
+// Filter returns the "key=value" entries of environ that e inherits.
+func (e *EnvInherit) Filter(environ []string) []string {
+	if e == nil || e.All {
+		return environ
+	}
+
+	filtered := make([]string, 0, len(e.Names))
+	for _, kv := range environ {
+		if k, _, ok := strings.Cut(kv, "="); ok && e.Inherits(k) {
+			filtered = append(filtered, kv)
+		}
+	}
+
+	return filtered
+}
+
+// String renders e as in the config: "all", "none" or the names joined by
+// commas.
+func (e *EnvInherit) String() string {
+	switch {
+	case e == nil || e.All:
+		return "all"
+	case len(e.Names) == 0:
+		return "none"
+	default:
+		return strings.Join(e.Names, ",")
+	}
+}
@@ -0,0 +1,36 @@
+package process
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEnvInherit_Filter(t *testing.T) {
+	environ := []string{"PATH=/bin", "HOME=/root", "SECRET=x"}
+
+	tests := []struct {
+		inherit *EnvInherit
+		want    []string
+		str     string
+	}{
+		{nil, environ, "all"},
+		{&EnvInherit{All: true, Names: []string{"PATH"}}, environ, "all"},
+		{&EnvInherit{}, []string{}, "none"},
+		{&EnvInherit{Names: []string{"PATH", "HOME"}}, []string{"PATH=/bin", "HOME=/root"}, "PATH,HOME"},
+	}
+	for _, tt := range tests {
+		if got := tt.inherit.Filter(environ); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Filter() = %q, want %q", tt.str, got, tt.want)
+		}
+		if got := tt.inherit.String(); got != tt.str {
+			t.Errorf("String() = %q, want %q", got, tt.str)
+		}
+	}
+}
+
+func TestEnvInherit_Merge(t *testing.T) {
+	ctx, task := &EnvInherit{}, &EnvInherit{Names: []string{"PATH"}}
+	if ctx.Merge(task) != task || ctx.Merge(nil) != ctx || (*EnvInherit)(nil).Merge(task) != task {
+		t.Error("a set EnvInherit must replace the one it is merged into")
+	}
+}
@@ -0,0 +1,74 @@
+// Package process describes how the processes of a task run, their resource
+// limits and the host environment they inherit, and what they report back.
+package process
+
+import "time"
+
+// Limits restricts the resources of every process a job starts, including the
+// processes those start. Zero fields are not limited.
+//
+// AddressSpace, CPUTime and OpenFiles are rlimits and Nice and IOClass/IOLevel
+// the scheduling priorities, set in each process before it executes the
+// command, so the processes the command starts inherit them. Memory and CPUs
+// are cgroup v2 limits. The executor enforces them on Linux only.
+type Limits struct {
+	// Memory caps the resident memory of the process tree (memory.max), with
+	// swapping disabled; exceeding it gets the process OOM-killed.
+	Memory uint64
+	// AddressSpace caps the virtual memory of each process (RLIMIT_AS);
+	// allocations beyond it fail.
+	AddressSpace uint64
+	// CPUTime caps the CPU time of each process (RLIMIT_CPU).
+	CPUTime time.Duration
+	// OpenFiles caps the number of open file descriptors (RLIMIT_NOFILE).
+	OpenFiles uint64
+	// Nice is the scheduling niceness, from -20 (highest priority) to 19;
+	// nil leaves it as is, so 0 can override another Limits' niceness.
+	Nice *int
+	// IOClass is the I/O scheduling class: "realtime", "best-effort" or
+	// "idle". IOLevel is the priority within the class, from 0 (highest) to 7.
+	IOClass string
+	IOLevel int
+	// CPUs caps the CPU bandwidth of the process tree in CPUs (cpu.max).
+	CPUs float64
+}
+
+// IsZero reports whether l limits nothing.
+func (l *Limits) IsZero() bool {
+	return l == nil || *l == Limits{}
+}
+
+// Merge returns l overridden by every limit o sets. Either may be nil.
+func (l *Limits) Merge(o *Limits) *Limits {
+	if o.IsZero() {
+		return l
+	}
+	if l.IsZero() {
+		return o
+	}
+
+	m := *l
+	if o.Memory != 0 {
+		m.Memory = o.Memory
+	}
+	if o.AddressSpace != 0 {
+		m.AddressSpace = o.AddressSpace
+	}
+	if o.CPUTime != 0 {
+		m.CPUTime = o.CPUTime
+	}
+	if o.OpenFiles != 0 {
+		m.OpenFiles = o.OpenFiles
+	}
+	if o.Nice != nil {
+		m.Nice = o.Nice
+	}
+	if o.IOClass != "" {
+		m.IOClass, m.IOLevel = o.IOClass, o.IOLevel
+	}
+	if o.CPUs != 0 {
+		m.CPUs = o.CPUs
+	}
+
+	return &m
+}
@@ -0,0 +1,28 @@
+package process
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimits_Merge(t *testing.T) {
+	five, zero := 5, 0
+	ctx := &Limits{Memory: 1 << 30, Nice: &five, IOClass: "idle"}
+	task := &Limits{Nice: &zero, CPUTime: time.Minute}
+
+	got := ctx.Merge(task)
+	want := Limits{Memory: 1 << 30, Nice: &zero, CPUTime: time.Minute, IOClass: "idle"}
+	if *got != want {
+		t.Errorf("Merge() = %+v, want %+v", *got, want)
+	}
+	if got := ctx.Merge(&Limits{CPUTime: time.Minute}); got.Nice != &five {
+		t.Errorf("a Limits without nice must keep the other's, got %v", got.Nice)
+	}
+
+	if (*Limits)(nil).Merge(task) != task || ctx.Merge(nil) != ctx {
+		t.Error("merging with nil must return the other limits")
+	}
+	if !(*Limits)(nil).IsZero() || !(&Limits{}).IsZero() || ctx.IsZero() {
+		t.Error("IsZero")
+	}
+}
@@ -3,11 +3,13 @@ package runner
 import (
 	"fmt"
 	"io"
+	"os"
 	"reflect"
 	"strings"
-	"time"
 
 	"github.com/taskctl/taskctl/executor"
+	"github.com/taskctl/taskctl/internal/envutil"
+	"github.com/taskctl/taskctl/internal/iox"
 	"github.com/taskctl/taskctl/internal/tmpl"
 	"github.com/taskctl/taskctl/task"
 	"github.com/taskctl/taskctl/variables"
@@ -22,17 +24,42 @@ func newTaskCompiler() *taskCompiler {
 	return &taskCompiler{variables: variables.NewVariables()}
 }
 
+// configureJob applies the limits, host env inheritance and builtins of the
+// context and the task, which overrides it, and the task's TTY to job.
+func configureJob(job *executor.Job, executionContext *ExecutionContext, t *task.Task) {
+	job.Limits = executionContext.Limits.Merge(t.Limits)
+	job.EnvInherit = executionContext.EnvInherit.Merge(t.EnvInherit)
+	job.Builtins = executionContext.Builtins
+	if t.Builtins != nil {
+		job.Builtins = t.Builtins
+	}
+	job.TTY = t.TTY
+}
+
+// hostEnviron returns taskctl's environment, which jobs inherit from.
+func hostEnviron() []string {
+	return envutil.SanitizeEnviron(os.Environ())
+}
+
+// reportTo makes r the Reporter of every job of the list starting at job.
+func reportTo(job *executor.Job, r executor.Reporter) {
+	for ; job != nil; job = job.Next {
+		job.Reporter = r
+	}
+}
+
 // compileTask compiles task into Job (linked list of commands) executed by Executor
-func (tc *taskCompiler) compileTask(t *task.Task, executionContext *ExecutionContext, stdin io.Reader, stdout, stderr io.Writer, env, vars variables.Container) (*executor.Job, error) {
+func (tc *taskCompiler) compileTask(t *task.Task, executionContext *ExecutionContext, stdin io.Reader, stdout, stderr io.Writer, logs *taskLogs, env, vars variables.Container) (*executor.Job, error) {
 	vars = t.Variables.Merge(vars)
 	var job, prev *executor.Job
 
+	environ := (&executor.Job{Env: env, EnvInherit: executionContext.EnvInherit.Merge(t.EnvInherit)}).Environ(hostEnviron())
 	for k, v := range vars.Map() {
 		if reflect.ValueOf(v).Kind() != reflect.String {
 			continue
 		}
 
-		v, err := tmpl.RenderString(v.(string), vars.Map())
+		v, err := tmpl.RenderStringEnv(v.(string), vars.Map(), environ)
 		if err != nil {
 			return nil, err
 		}
@@ -40,15 +67,26 @@ func (tc *taskCompiler) compileTask(t *task.Task, executionContext *ExecutionCon
 	}
 
 	for _, variant := range t.GetVariations() {
+		variantStdout, variantStderr := stdout, stderr
+		if logs != nil {
+			lw, err := logs.variation(variant)
+			if err != nil {
+				return nil, err
+			}
+			if t.TTY {
+				lw = iox.StripANSI(lw)
+			}
+			variantStdout, variantStderr = io.MultiWriter(stdout, lw), io.MultiWriter(stderr, lw)
+		}
+
 		for _, command := range t.Commands {
 			j, err := tc.compileCommand(
 				command,
 				executionContext,
-				t.Dir,
-				t.Timeout,
+				t,
 				stdin,
-				stdout,
-				stderr,
+				variantStdout,
+				variantStderr,
 				env.Merge(variables.FromMap(variant)),
 				vars,
 			)
@@ -75,14 +113,13 @@ func (tc *taskCompiler) compileTask(t *task.Task, executionContext *ExecutionCon
 func (tc *taskCompiler) compileCommand(
 	command string,
 	executionCtx *ExecutionContext,
-	dir string,
-	timeout *time.Duration,
+	t *task.Task,
 	stdin io.Reader,
 	stdout, stderr io.Writer,
 	env, vars variables.Container,
 ) (*executor.Job, error) {
 	j := &executor.Job{
-		Timeout: timeout,
+		Timeout: t.CommandTimeout,
 		Env:     env,
 		Stdin:   stdin,
 		Stdout:  stdout,
@@ -101,14 +138,16 @@ func (tc *taskCompiler) compileCommand(
 
 	j.Command = strings.Join(c, " ")
 
+	configureJob(j, executionCtx, t)
+
 	var err error
-	if dir != "" {
-		j.Dir = dir
+	if t.Dir != "" {
+		j.Dir = t.Dir
 	} else if executionCtx.Dir != "" {
 		j.Dir = executionCtx.Dir
 	}
 
-	j.Dir, err = tmpl.RenderString(j.Dir, j.Vars.Map())
+	j.Dir, err = tmpl.RenderStringEnv(j.Dir, j.Vars.Map(), j.Environ(hostEnviron()))
 	if err != nil {
 		return nil, err
 	}
@@ -19,7 +19,7 @@ func TestTaskCompiler_CompileCommand(t *testing.T) {
 	job, err := tc.compileCommand(
 		"echo 1",
 		NewExecutionContext(&shBin, "/tmp", variables.FromMap(map[string]string{"HOME": "/root"}), nil, nil, nil, nil),
-		"/root", nil,
+		&task.Task{Dir: "/root"},
 		&bytes.Buffer{},
 		&bytes.Buffer{},
 		&bytes.Buffer{},
@@ -42,7 +42,7 @@ func TestTaskCompiler_CompileCommand(t *testing.T) {
 	job, err = tc.compileCommand(
 		"echo 1",
 		quotedContext,
-		"/root", nil,
+		&task.Task{Dir: "/root"},
 		&bytes.Buffer{},
 		&bytes.Buffer{},
 		&bytes.Buffer{},
@@ -68,6 +68,7 @@ func TestTaskCompiler_CompileTask(t *testing.T) {
 		&bytes.Buffer{},
 		&bytes.Buffer{},
 		&bytes.Buffer{},
+		nil,
 		variables.NewVariables(),
 		variables.FromMap(map[string]string{"TestVar": "TestVarValue"}),
 	)
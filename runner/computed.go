@@ -0,0 +1,202 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/taskctl/taskctl/executor"
+	"github.com/taskctl/taskctl/internal/tmpl"
+	"github.com/taskctl/taskctl/variables"
+)
+
+// computedValue is the output of a variables.Command, evaluated at most once.
+type computedValue struct {
+	mu    sync.Mutex
+	done  bool
+	value string
+	err   error
+}
+
+// resolve returns values with its variables.Command values replaced by their
+// output, only those used reports for when it isn't nil. Each script, rendered
+// with the plain values of vars, runs once per context through the embedded
+// shell in the context's dir and env; tasks using the same script share its
+// result, failures included. kind names the values in errors.
+func (c *ExecutionContext) resolve(ctx context.Context, kind string, values, vars variables.Container, used func(name string) bool) (variables.Container, error) {
+	var resolved variables.Container
+	for name, v := range values.Map() {
+		command, ok := v.(variables.Command)
+		if !ok || used != nil && !used(name) {
+			continue
+		}
+
+		if resolved == nil {
+			resolved = values.Merge(variables.NewVariables())
+		}
+		value, err := c.compute(ctx, command, vars)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", kind, name, err)
+		}
+		resolved.Set(name, value)
+	}
+
+	if resolved == nil {
+		return values, nil
+	}
+
+	return resolved, nil
+}
+
+// usedVariables returns a func reporting whether templates read the variable
+// name of vars, directly or through the string variables they read, which
+// compileTask renders as templates too.
+func usedVariables(vars variables.Container, templates ...string) func(name string) bool {
+	used := make(map[string]bool)
+	for len(templates) > 0 {
+		names, all := tmpl.References(templates[0])
+		templates = templates[1:]
+		if all {
+			return nil
+		}
+
+		for _, name := range names {
+			if used[name] {
+				continue
+			}
+			used[name] = true
+			if s, ok := vars.Get(name).(string); ok {
+				templates = append(templates, s)
+			}
+		}
+	}
+
+	return func(name string) bool { return used[name] }
+}
+
+// commandTemplates returns commands along with the other templates rendered
+// to run them in c from dir: the dir, falling back to c's, and c's
+// executable, which wraps them.
+func (c *ExecutionContext) commandTemplates(dir string, commands ...string) []string {
+	if dir == "" {
+		dir = c.Dir
+	}
+	templates := append([]string{dir}, commands...)
+	if c.Executable != nil {
+		templates = append(templates, c.Executable.Bin)
+		templates = append(templates, c.Executable.Args...)
+	}
+
+	return templates
+}
+
+// scripts returns the scripts of the variables.Command values of values.
+func scripts(values variables.Container) []string {
+	var scripts []string
+	for _, v := range values.Map() {
+		if command, ok := v.(variables.Command); ok {
+			scripts = append(scripts, command.Script)
+		}
+	}
+
+	return scripts
+}
+
+func (c *ExecutionContext) compute(ctx context.Context, command variables.Command, vars variables.Container) (string, error) {
+	job := &executor.Job{
+		Command:    command.Script,
+		Dir:        c.Dir,
+		Env:        withoutCommands(c.Env),
+		Vars:       withoutCommands(vars),
+		Limits:     c.Limits,
+		EnvInherit: c.EnvInherit,
+		Builtins:   c.Builtins,
+	}
+	script, err := tmpl.RenderStringEnv(command.Script, job.Vars.Map(), job.Environ(hostEnviron()))
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	if c.computed == nil {
+		c.computed = make(map[string]*computedValue)
+	}
+	cv := c.computed[script]
+	if cv == nil {
+		cv = &computedValue{}
+		c.computed[script] = cv
+	}
+	c.mu.Unlock()
+
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	if cv.done {
+		return cv.value, cv.err
+	}
+
+	var stdout, stderr bytes.Buffer
+	ex, err := executor.NewDefaultExecutor(nil, &stdout, &stderr)
+	if err != nil {
+		return "", err
+	}
+	ex.DiscardOutput = true
+
+	_, err = ex.Execute(ctx, job)
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		err = fmt.Errorf("sh %q: %w", script, err)
+	}
+
+	// A cancelled run says nothing about the command, so it isn't cached.
+	if ctx.Err() != nil {
+		return "", err
+	}
+	cv.done, cv.value, cv.err = true, strings.TrimRight(stdout.String(), "\r\n"), err
+
+	return cv.value, cv.err
+}
+
+// declared returns values with its variables.Command values replaced by the
+// way they are declared, "sh: command", for a dry run, which computes none.
+func declared(values variables.Container) variables.Container {
+	if values == nil {
+		return nil
+	}
+
+	var plain variables.Container
+	for k, v := range values.Map() {
+		if command, ok := v.(variables.Command); ok {
+			if plain == nil {
+				plain = values.Merge(variables.NewVariables())
+			}
+			plain.Set(k, command.String())
+		}
+	}
+
+	if plain == nil {
+		return values
+	}
+
+	return plain
+}
+
+// withoutCommands returns vars without its variables.Command values, which
+// are yet to be computed.
+func withoutCommands(vars variables.Container) variables.Container {
+	if vars == nil {
+		return variables.NewVariables()
+	}
+
+	plain := variables.NewVariables()
+	for k, v := range vars.Map() {
+		if _, ok := v.(variables.Command); !ok {
+			plain.Set(k, v)
+		}
+	}
+
+	return plain
+}
@@ -0,0 +1,180 @@
+package runner
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	taskpkg "github.com/taskctl/taskctl/task"
+	"github.com/taskctl/taskctl/variables"
+)
+
+func TestTaskRunner_ComputedValues(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "counter")
+	vars := variables.NewVariables()
+	vars.Set("Counter", counter)
+	vars.Set("Version", variables.Command{Script: `echo x >> {{ .Counter }}; echo v1.2.3`})
+
+	runner, err := NewTaskRunner(WithVariables(vars))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+	defer runner.Finish()
+
+	for _, name := range []string{"build", "release"} {
+		tsk := taskpkg.FromCommands(`printf "%s %s" {{ .Version }} "$COMMIT"`)
+		tsk.Name = name
+		tsk.Env = variables.NewVariables()
+		tsk.Env.Set("COMMIT", variables.Command{Script: "printf '%s\\n\\n' {{ .Version | trimPrefix \"v\" }}-abc"})
+		if err := runner.Run(tsk); err != nil {
+			t.Fatal(err)
+		}
+		if want := "v1.2.3 1.2.3-abc"; tsk.Stdout() != want {
+			t.Errorf("%s: unexpected output %q, want %q", name, tsk.Stdout(), want)
+		}
+	}
+
+	b, err := os.ReadFile(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(b), "x"); got != 1 {
+		t.Errorf("the command ran %d times, want once per run", got)
+	}
+}
+
+func TestTaskRunner_ComputedValuesContextEnv(t *testing.T) {
+	env := variables.FromMap(map[string]string{"GREETING": "hello"})
+	env.Set("LOUD", variables.Command{Script: `echo "$GREETING" | tr a-z A-Z`})
+	c := NewExecutionContext(nil, t.TempDir(), env, nil, nil, nil, nil)
+
+	runner, err := NewTaskRunner(WithContexts(map[string]*ExecutionContext{"local": c}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+	defer runner.Finish()
+
+	tsk := taskpkg.FromCommands(`printf "$LOUD"`)
+	tsk.Context = "local"
+	if err := runner.Run(tsk); err != nil {
+		t.Fatal(err)
+	}
+	if tsk.Stdout() != "HELLO" {
+		t.Errorf("unexpected output %q", tsk.Stdout())
+	}
+}
+
+func TestTaskRunner_ComputedValuesError(t *testing.T) {
+	runner, err := NewTaskRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+	defer runner.Finish()
+
+	tsk := taskpkg.FromCommands("echo {{ .Tag }}")
+	tsk.Variables = variables.NewVariables()
+	tsk.Variables.Set("Tag", variables.Command{Script: "echo no tags >&2; exit 3"})
+	err = runner.Run(tsk)
+	if err == nil || !strings.Contains(err.Error(), `variable Tag: sh "echo no tags >&2; exit 3": exit status 3: no tags`) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if !tsk.Errored {
+		t.Error("a failed computed value must fail the task")
+	}
+}
+
+func TestTaskRunner_ComputedValuesUnused(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "counter")
+	vars := variables.NewVariables()
+	vars.Set("Counter", counter)
+	for _, name := range []string{"Unused", "Checked", "Image", "Version"} {
+		vars.Set(name, variables.Command{Script: "echo " + name + " >> {{ .Counter }}; echo " + name})
+	}
+	vars.Set("Ref", "{{ .Image }}")
+
+	runner, err := NewTaskRunner(WithVariables(vars))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+	defer runner.Finish()
+
+	skipped := taskpkg.FromCommands("echo {{ .Version }}")
+	skipped.Condition = "test {{ .Checked }} = no"
+	if err := runner.Run(skipped); err != nil || !skipped.Skipped {
+		t.Fatalf("the task must be skipped, got %v", err)
+	}
+	build := taskpkg.FromCommands("echo {{ .Ref }}")
+	if err := runner.Run(build); err != nil {
+		t.Fatal(err)
+	}
+	if build.Stdout() != "Image\n" {
+		t.Errorf("unexpected output %q", build.Stdout())
+	}
+
+	b, err := os.ReadFile(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != "Checked\nImage\n" {
+		t.Errorf("only the values the condition and commands run read must be computed, got %q", got)
+	}
+}
+
+func TestTaskRunner_ComputedValuesDryRun(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "counter")
+	vars := variables.NewVariables()
+	vars.Set("Counter", counter)
+	vars.Set("Version", variables.Command{Script: "echo x >> {{ .Counter }}; echo v1"})
+	vars.Set("Checked", variables.Command{Script: "echo x >> {{ .Counter }}; echo yes"})
+
+	runner, err := NewTaskRunner(WithVariables(vars))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.DryRun = true
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+	defer runner.Finish()
+
+	tsk := taskpkg.FromCommands(`echo {{ .Version }} "$COMMIT"`)
+	tsk.Condition = "test {{ .Checked }} = yes"
+	tsk.Env = variables.NewVariables()
+	tsk.Env.Set("COMMIT", variables.Command{Script: "echo x >> {{ .Counter }}; echo abc"})
+	if err := runner.Run(tsk); err != nil {
+		t.Fatal(err)
+	}
+
+	plan := runner.Plan()
+	if len(plan) != 1 {
+		t.Fatalf("unexpected plan %+v", plan)
+	}
+	v := plan[0].Variations[0]
+	if want := `echo sh: echo x >> ` + counter + `; echo v1 "$COMMIT"`; v.Commands[0] != want {
+		t.Errorf("unexpected command %q, want %q", v.Commands[0], want)
+	}
+	if want := "sh: echo x >> {{ .Counter }}; echo abc"; v.Env["COMMIT"] != want {
+		t.Errorf("unexpected env COMMIT %q, want %q", v.Env["COMMIT"], want)
+	}
+	if _, err := os.Stat(counter); !os.IsNotExist(err) {
+		t.Error("a dry run must not compute values")
+	}
+
+	// A condition run by the dry run computes the values it reads, and those
+	// only.
+	runner.DryRunConditions = true
+	if err := runner.Run(tsk); err != nil || tsk.Skipped {
+		t.Fatalf("the condition must be met, got %v", err)
+	}
+	b, err := os.ReadFile(counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(b), "x"); got != 2 {
+		t.Errorf("want the condition's variable and the env computed, got %d commands run", got)
+	}
+}
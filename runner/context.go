@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"github.com/taskctl/taskctl/executor"
+	"github.com/taskctl/taskctl/process"
 
 	"github.com/taskctl/taskctl/variables"
 )
@@ -24,6 +25,14 @@ type ExecutionContext struct {
 	Env        variables.Container
 	Variables  variables.Container
 	Quote      string
+	// Limits restricts the resources of every process started in the context.
+	Limits *process.Limits
+	// EnvInherit selects the host environment variables visible in the
+	// context; nil inherits all of them.
+	EnvInherit *process.EnvInherit
+	// Builtins lists the executor.BuiltinSets run in place of binaries on
+	// PATH in the context.
+	Builtins []string
 
 	up     []string
 	down   []string
@@ -31,6 +40,9 @@ type ExecutionContext struct {
 	after  []string
 
 	startupError error
+	// computed holds the values of the context's variables.Command values,
+	// by rendered script (see resolve).
+	computed map[string]*computedValue
 
 	onceUp   sync.Once
 	onceDown sync.Once
@@ -119,16 +131,29 @@ func (c *ExecutionContext) After() error {
 
 func (c *ExecutionContext) runServiceCommand(ctx context.Context, command string) (err error) {
 	slog.Debug(fmt.Sprintf("running context service command: %s", command))
+	used := usedVariables(c.Variables, append(c.commandTemplates("", command), scripts(c.Env)...)...)
+	vars, err := c.resolve(ctx, "variable", c.Variables, c.Variables, used)
+	if err != nil {
+		return err
+	}
+	env, err := c.resolve(ctx, "env", c.Env, vars, nil)
+	if err != nil {
+		return err
+	}
+
 	ex, err := executor.NewDefaultExecutor(nil, nil, nil)
 	if err != nil {
 		return err
 	}
 
 	out, err := ex.Execute(ctx, &executor.Job{
-		Command: command,
-		Dir:     c.Dir,
-		Env:     c.Env,
-		Vars:    c.Variables,
+		Command:    command,
+		Dir:        c.Dir,
+		Env:        env,
+		Vars:       vars,
+		Limits:     c.Limits,
+		EnvInherit: c.EnvInherit,
+		Builtins:   c.Builtins,
 	})
 	if err != nil {
 		if out != nil {
@@ -148,6 +173,27 @@ func defaultContext() *ExecutionContext {
 	}
 }
 
+// WithLimits is functional option to set Limits for ExecutionContext
+func WithLimits(limits *process.Limits) ExecutionContextOption {
+	return func(c *ExecutionContext) {
+		c.Limits = limits
+	}
+}
+
+// WithEnvInherit is functional option to set EnvInherit for ExecutionContext
+func WithEnvInherit(inherit *process.EnvInherit) ExecutionContextOption {
+	return func(c *ExecutionContext) {
+		c.EnvInherit = inherit
+	}
+}
+
+// WithBuiltins is functional option to set Builtins for ExecutionContext
+func WithBuiltins(builtins []string) ExecutionContextOption {
+	return func(c *ExecutionContext) {
+		c.Builtins = builtins
+	}
+}
+
 // WithQuote is functional option to set Quote for ExecutionContext
 func WithQuote(quote string) ExecutionContextOption {
 	return func(c *ExecutionContext) {
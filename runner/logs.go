@@ -0,0 +1,272 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/taskctl/taskctl/task"
+)
+
+// logTimeFormat stamps log file names. It sorts lexically and avoids
+// characters that are not allowed in Windows file names.
+const logTimeFormat = "20060102T150405"
+
+// taskLogs owns the on-disk logs of a single task run: one file per variation,
+// each holding both of the variation's output streams. A nil *taskLogs means
+// on-disk logging is off; every method is a no-op on it.
+type taskLogs struct {
+	r     *TaskRunner
+	t     *task.Task
+	label string
+	stamp string
+
+	files    []*os.File
+	prefixed []*linePrefixWriter
+}
+
+func (r *TaskRunner) newTaskLogs(t *task.Task) *taskLogs {
+	if r.LogDir == "" {
+		return nil
+	}
+
+	return &taskLogs{r: r, t: t, label: taskLabel(t), stamp: time.Now().Format(logTimeFormat)}
+}
+
+// variation creates the log file for one of the task's variations and returns
+// a writer feeding it (and the combined run log, when enabled).
+func (l *taskLogs) variation(variant map[string]string) (io.Writer, error) {
+	if l == nil {
+		return io.Discard, nil
+	}
+
+	label := l.label
+	base := l.stamp + "-" + logFileName(label)
+	if v := variationKey(variant); v != "" {
+		base += "-" + logFileName(v)
+	}
+
+	f, err := createLogFile(l.r.LogDir, base)
+	if err != nil {
+		return nil, err
+	}
+	l.files = append(l.files, f)
+	l.t.LogFiles = append(l.t.LogFiles, f.Name())
+
+	if !l.r.LogCombined {
+		return f, nil
+	}
+
+	combined, err := l.r.combinedLog()
+	if err != nil {
+		return nil, err
+	}
+
+	if v := variationKey(variant); v != "" {
+		label += " (" + v + ")"
+	}
+	pw := &linePrefixWriter{w: combined, prefix: []byte("[" + label + "] ")}
+	l.prefixed = append(l.prefixed, pw)
+
+	return io.MultiWriter(f, pw), nil
+}
+
+// close flushes pending combined-log lines and closes the task's log files.
+func (l *taskLogs) close() {
+	if l == nil {
+		return
+	}
+
+	for _, pw := range l.prefixed {
+		if err := pw.Flush(); err != nil {
+			slog.Error(err.Error())
+		}
+	}
+
+	for _, f := range l.files {
+		if err := f.Close(); err != nil {
+			slog.Error(err.Error())
+		}
+	}
+}
+
+// combinedLog lazily opens the run-wide log that interleaves every task's
+// output. It is opened in append mode so a runner finished between targets
+// keeps writing to the same file.
+func (r *TaskRunner) combinedLog() (*lockedWriter, error) {
+	r.combinedMu.Lock()
+	defer r.combinedMu.Unlock()
+
+	if r.combined != nil {
+		return r.combined, nil
+	}
+
+	if err := os.MkdirAll(r.LogDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	name := filepath.Join(r.LogDir, r.start.Format(logTimeFormat)+"-combined.log")
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	r.combined = &lockedWriter{f: f}
+	return r.combined, nil
+}
+
+func (r *TaskRunner) closeCombinedLog() {
+	r.combinedMu.Lock()
+	defer r.combinedMu.Unlock()
+
+	if r.combined == nil {
+		return
+	}
+
+	if err := r.combined.f.Close(); err != nil {
+		slog.Error(err.Error())
+	}
+	r.combined = nil
+}
+
+// CombinedLogFile returns the path of the run-wide combined log, or an empty
+// string when none has been written.
+func (r *TaskRunner) CombinedLogFile() string {
+	if !r.LogCombined || r.LogDir == "" {
+		return ""
+	}
+
+	name := filepath.Join(r.LogDir, r.start.Format(logTimeFormat)+"-combined.log")
+	if _, err := os.Stat(name); err != nil {
+		return ""
+	}
+
+	return name
+}
+
+// createLogFile creates dir/base.log, adding a numeric suffix when a file of
+// that name already exists (e.g. the same stage of a pipeline nested twice).
+func createLogFile(dir, base string) (*os.File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	for i := 0; ; i++ {
+		name := base + ".log"
+		if i > 0 {
+			name = fmt.Sprintf("%s-%d.log", base, i)
+		}
+
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+
+		return f, err
+	}
+}
+
+// taskLabel names a task by the pipeline stage running it, or by itself when
+// run directly.
+func taskLabel(t *task.Task) string {
+	if t.Stage != "" {
+		return t.Stage
+	}
+
+	return t.Name
+}
+
+// variationKey renders a variation as sorted "KEY=value" pairs, empty for the
+// implicit single variation of a task that declares none.
+func variationKey(variant map[string]string) string {
+	pairs := make([]string, 0, len(variant))
+	for _, k := range slices.Sorted(maps.Keys(variant)) {
+		pairs = append(pairs, k+"="+variant[k])
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// logFileName replaces every character that is not portable in a file name.
+func logFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == '.' || r == '=' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// lockedWriter serializes writes from concurrently running tasks.
+type lockedWriter struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.f.Write(p)
+}
+
+// linePrefixWriter writes complete lines to w, each led by prefix, carrying a
+// trailing partial line over to the next write so every line is attributed
+// once. Stdout and stderr of a task share one writer, hence the mutex.
+type linePrefixWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	prefix []byte
+	buf    []byte
+}
+
+func (w *linePrefixWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		idx := bytes.IndexByte(w.buf, '\n')
+		if idx < 0 {
+			break
+		}
+
+		if err := w.writeLine(w.buf[:idx+1]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[idx+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush writes out a pending partial line, terminating it with a newline.
+func (w *linePrefixWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	err := w.writeLine(append(w.buf, '\n'))
+	w.buf = nil
+
+	return err
+}
+
+func (w *linePrefixWriter) writeLine(line []byte) error {
+	_, err := w.w.Write(append(slices.Clip(w.prefix), line...))
+	return err
+}
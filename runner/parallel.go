@@ -0,0 +1,199 @@
+package runner
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/taskctl/taskctl/executor"
+	"github.com/taskctl/taskctl/internal/output"
+	"github.com/taskctl/taskctl/task"
+	"github.com/taskctl/taskctl/variables"
+)
+
+// maxUnitLabel caps the length of a command shown in a parallel unit's name.
+const maxUnitLabel = 40
+
+// parallelUnit is one variation or command of a parallel task, compiled and
+// ready to run as a task of its own.
+type parallelUnit struct {
+	t    *task.Task
+	out  *output.TaskOutput
+	logs *taskLogs
+	job  *executor.Job
+}
+
+// parallelUnits splits a parallel task into the tasks its variations or, with
+// a single variation, its commands run as. It returns nil when the task runs
+// serially.
+func parallelUnits(t *task.Task) []*task.Task {
+	if !t.Parallel || t.Interactive {
+		return nil
+	}
+
+	label := taskLabel(t)
+
+	var units []*task.Task
+	if len(t.Variations) > 1 {
+		for _, variant := range t.Variations {
+			u := newUnit(t, label+" ("+variationKey(variant)+")")
+			u.Variations = []map[string]string{variant}
+			units = append(units, u)
+		}
+		return units
+	}
+
+	if len(t.Commands) < 2 {
+		return nil
+	}
+
+	for _, command := range t.Commands {
+		u := newUnit(t, label+" ("+commandLabel(command)+")")
+		u.Commands = []string{command}
+		units = append(units, u)
+	}
+
+	return units
+}
+
+func newUnit(t *task.Task, name string) *task.Task {
+	u := t.Clone()
+	u.Name = name
+	u.Stage = ""
+	u.Parallel = false
+
+	return u
+}
+
+// commandLabel shortens a command to its first line, truncated.
+func commandLabel(command string) string {
+	command, _, _ = strings.Cut(strings.TrimSpace(command), "\n")
+	if r := []rune(command); len(r) > maxUnitLabel {
+		return string(r[:maxUnitLabel-1]) + "…"
+	}
+
+	return command
+}
+
+// compileParallel compiles every unit of t with its own output decorator and
+// logs, and returns the function that runs them, at most t.ParallelLimit at a
+// time. Units don't share shell state, and each sees an empty .Output.
+func (r *TaskRunner) compileParallel(t *task.Task, units []*task.Task, execContext *ExecutionContext, env, vars variables.Container) (func(ctx context.Context, cause string) error, error) {
+	label := taskLabel(t)
+
+	compiled := make([]*parallelUnit, 0, len(units))
+	for _, u := range units {
+		out, err := output.NewTaskOutput(u, r.OutputFormat, r.Stdout, r.Stderr)
+		if err != nil {
+			closeUnits(compiled)
+			return nil, err
+		}
+
+		// Log files are named after the task, as when it runs serially.
+		logs := r.newTaskLogs(u)
+		if logs != nil {
+			logs.label = label
+		}
+
+		job, err := r.compiler.compileTask(u, execContext, nil, out.Stdout(), out.Stderr(), logs, env, vars)
+		if err != nil {
+			logs.close()
+			closeUnits(compiled)
+			return nil, err
+		}
+		reportTo(job, out)
+
+		compiled = append(compiled, &parallelUnit{t: u, out: out, logs: logs, job: job})
+	}
+
+	return func(ctx context.Context, cause string) error {
+		t.Units = units
+		return r.runParallel(ctx, t, compiled, cause)
+	}, nil
+}
+
+func closeUnits(units []*parallelUnit) {
+	for _, u := range units {
+		u.logs.close()
+	}
+}
+
+// runParallel runs the units and folds their results into t: its logs join
+// the units' in order, and it fails with the first unit (in order) that failed.
+func (r *TaskRunner) runParallel(ctx context.Context, t *task.Task, units []*parallelUnit, cause string) error {
+	limit := t.ParallelLimit
+	if limit <= 0 {
+		limit = len(units)
+	}
+	sem := make(chan struct{}, limit)
+
+	t.Start = time.Now()
+
+	var wg sync.WaitGroup
+	for _, u := range units {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			r.runUnit(ctx, u, cause)
+		})
+	}
+	wg.Wait()
+
+	t.End = time.Now()
+
+	for _, u := range units {
+		t.Log.Stdout.Join(&u.t.Log.Stdout)
+		t.Log.Stderr.Join(&u.t.Log.Stderr)
+		t.LogFiles = append(t.LogFiles, u.t.LogFiles...)
+		t.Annotations = append(t.Annotations, u.t.Annotations...)
+		if len(u.t.Outputs) > 0 {
+			if t.Outputs == nil {
+				t.Outputs = make(map[string]string)
+			}
+			maps.Copy(t.Outputs, u.t.Outputs)
+		}
+
+		if u.t.ExitCode > 0 && t.ExitCode <= 0 {
+			t.ExitCode = u.t.ExitCode
+		}
+		if u.t.Errored && !t.Errored {
+			t.Errored = true
+			t.TimedOut = u.t.TimedOut
+			t.Error = u.t.Error
+			t.ExitCode = u.t.ExitCode
+		}
+	}
+
+	return t.Error
+}
+
+func (r *TaskRunner) runUnit(ctx context.Context, u *parallelUnit, cause string) {
+	defer func() {
+		if err := u.out.Finish(); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+	defer u.logs.close()
+
+	if err := ctx.Err(); err != nil {
+		u.t.Errored = true
+		u.t.Error = err
+		return
+	}
+
+	if err := u.out.Start(); err != nil {
+		u.t.Errored = true
+		u.t.Error = err
+		return
+	}
+
+	_ = r.execute(ctx, u.t, u.job, cause)
+
+	if !u.t.Errored && u.t.ExitCode < 0 {
+		u.t.ExitCode = 0
+	}
+}
@@ -0,0 +1,155 @@
+package runner
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	taskpkg "github.com/taskctl/taskctl/task"
+)
+
+func TestTaskRunner_ParallelVariations(t *testing.T) {
+	runner, err := NewTaskRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+
+	tsk := taskpkg.FromCommands("sleep 0.3", "echo $GOOS")
+	tsk.Name = "build"
+	tsk.Variations = []map[string]string{{"GOOS": "linux"}, {"GOOS": "darwin"}, {"GOOS": "windows"}}
+	tsk.Parallel = true
+
+	start := time.Now()
+	if err = runner.Run(tsk); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 800*time.Millisecond {
+		t.Errorf("variations didn't run concurrently: took %s", d)
+	}
+
+	if len(tsk.Units) != 3 {
+		t.Fatalf("expected a unit per variation, got %d", len(tsk.Units))
+	}
+	for i, goos := range []string{"linux", "darwin", "windows"} {
+		u := tsk.Units[i]
+		if u.Name != "build (GOOS="+goos+")" {
+			t.Errorf("unexpected unit name %q", u.Name)
+		}
+		if u.Stdout() != goos+"\n" || u.ExitCode != 0 || u.Start.IsZero() {
+			t.Errorf("unit %s: stdout %q, exit code %d", u.Name, u.Stdout(), u.ExitCode)
+		}
+	}
+
+	if tsk.Stdout() != "linux\ndarwin\nwindows\n" || tsk.ExitCode != 0 {
+		t.Errorf("unexpected task result: stdout %q, exit code %d", tsk.Stdout(), tsk.ExitCode)
+	}
+}
+
+func TestTaskRunner_ParallelLimit(t *testing.T) {
+	runner, err := NewTaskRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+
+	tsk := taskpkg.FromCommands("sleep 0.2")
+	tsk.Variations = []map[string]string{{"N": "1"}, {"N": "2"}}
+	tsk.Parallel = true
+	tsk.ParallelLimit = 1
+
+	start := time.Now()
+	if err = runner.Run(tsk); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 400*time.Millisecond {
+		t.Errorf("limit of 1 must run variations one at a time: took %s", d)
+	}
+}
+
+func TestTaskRunner_ParallelCommands(t *testing.T) {
+	runner, err := NewTaskRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+
+	tsk := taskpkg.FromCommands("echo vet", "echo fmt; exit 3")
+	tsk.Name = "lint"
+	tsk.Stage = "check"
+	tsk.Parallel = true
+
+	if err = runner.Run(tsk); err == nil {
+		t.Fatal("expected the failing command to fail the task")
+	}
+
+	if len(tsk.Units) != 2 {
+		t.Fatalf("expected a unit per command, got %d", len(tsk.Units))
+	}
+	if tsk.Units[0].Name != "check (echo vet)" || tsk.Units[0].Errored {
+		t.Errorf("unexpected first unit %q (errored %v)", tsk.Units[0].Name, tsk.Units[0].Errored)
+	}
+	if !tsk.Units[1].Errored || tsk.Units[1].ExitCode != 3 {
+		t.Errorf("second unit: errored %v, exit code %d", tsk.Units[1].Errored, tsk.Units[1].ExitCode)
+	}
+	if !tsk.Errored || tsk.ExitCode != 3 {
+		t.Errorf("task: errored %v, exit code %d", tsk.Errored, tsk.ExitCode)
+	}
+}
+
+func TestTaskRunner_ParallelReports(t *testing.T) {
+	runner, err := NewTaskRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+
+	tsk := taskpkg.FromCommands("taskctl-set-output vet ok", "taskctl-annotate main.go:3 unformatted")
+	tsk.Name = "lint"
+	tsk.Parallel = true
+
+	if err = runner.Run(tsk); err != nil {
+		t.Fatal(err)
+	}
+
+	if tsk.Outputs["vet"] != "ok" {
+		t.Errorf("the task must carry its units' outputs, got %v", tsk.Outputs)
+	}
+	if len(tsk.Annotations) != 1 || tsk.Annotations[0].Location() != "main.go:3" {
+		t.Errorf("the task must carry its units' annotations, got %+v", tsk.Annotations)
+	}
+}
+
+func TestTaskRunner_ParallelSingleCommandRunsSerially(t *testing.T) {
+	runner, err := NewTaskRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+
+	tsk := taskpkg.FromCommands("echo 1")
+	tsk.Parallel = true
+
+	if err = runner.Run(tsk); err != nil {
+		t.Fatal(err)
+	}
+	if tsk.Units != nil {
+		t.Errorf("a single command has nothing to run in parallel, got %d units", len(tsk.Units))
+	}
+}
+
+func Test_commandLabel(t *testing.T) {
+	cases := map[string]string{
+		"go vet ./...":                      "go vet ./...",
+		"  make lint\nmake fmt":             "make lint",
+		strings.Repeat("a", maxUnitLabel):   strings.Repeat("a", maxUnitLabel),
+		strings.Repeat("a", maxUnitLabel+1): strings.Repeat("a", maxUnitLabel-1) + "…",
+	}
+
+	for command, want := range cases {
+		if got := commandLabel(command); got != want {
+			t.Errorf("commandLabel(%q) = %q, want %q", command, got, want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package runner
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/taskctl/taskctl/executor"
+	"github.com/taskctl/taskctl/internal/envutil"
+	"github.com/taskctl/taskctl/internal/tmpl"
+	"github.com/taskctl/taskctl/task"
+	"github.com/taskctl/taskctl/variables"
+)
+
+// PlannedTask describes how a dry run would run a task: its commands
+// rendered as they would execute, per variation.
+type PlannedTask struct {
+	// Name is the task's name, or the stage's when run by a pipeline.
+	Name    string `json:"name"`
+	Task    string `json:"task"`
+	Context string `json:"context,omitempty"`
+	// Condition is not evaluated by a dry run unless ConditionEvaluated is
+	// set: the task runs only if it succeeds at run time.
+	Condition string `json:"condition,omitempty"`
+	// ConditionEvaluated reports that the dry run executed the condition, and
+	// found it met (see TaskRunner.DryRunConditions).
+	ConditionEvaluated bool               `json:"condition_evaluated,omitempty"`
+	Before             []string           `json:"before,omitempty"`
+	After              []string           `json:"after,omitempty"`
+	Parallel           bool               `json:"parallel,omitempty"`
+	ParallelLimit      int                `json:"parallel_limit,omitempty"`
+	Variations         []PlannedVariation `json:"variations"`
+}
+
+// PlannedVariation is one variation of a PlannedTask.
+type PlannedVariation struct {
+	// Variation holds the variation's env variables; empty for a task without
+	// variations.
+	Variation map[string]string `json:"variation,omitempty"`
+	Dir       string            `json:"dir"`
+	// Env holds the variables the commands' environment adds to or changes in
+	// the part of taskctl's own it inherits.
+	Env      map[string]string `json:"env"`
+	Commands []string          `json:"commands"`
+}
+
+// plan collects the tasks a dry run planned, in the order they were run.
+type plan struct {
+	mu    sync.Mutex
+	tasks []PlannedTask
+}
+
+func (p *plan) add(pt PlannedTask) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.tasks = append(p.tasks, pt)
+}
+
+// Plan returns the tasks a dry run planned so far, in the order they were run.
+// It is empty unless DryRun is set.
+func (r *TaskRunner) Plan() []PlannedTask {
+	r.plan.mu.Lock()
+	defer r.plan.mu.Unlock()
+
+	return slices.Clone(r.plan.tasks)
+}
+
+// planTask renders t's commands the way they would execute in execContext.
+func (r *TaskRunner) planTask(t *task.Task, execContext *ExecutionContext, env, vars variables.Container) (PlannedTask, error) {
+	pt := PlannedTask{
+		Name:               taskLabel(t),
+		Task:               t.Name,
+		Context:            t.Context,
+		Condition:          t.Condition,
+		ConditionEvaluated: t.Condition != "" && r.DryRunConditions,
+		Parallel:           t.Parallel,
+		ParallelLimit:      t.ParallelLimit,
+	}
+
+	var err error
+	if pt.Before, err = r.planCommands(t.Before, t, execContext, env, vars); err != nil {
+		return pt, err
+	}
+	if pt.After, err = r.planCommands(t.After, t, execContext, env, vars); err != nil {
+		return pt, err
+	}
+
+	host := hostEnviron()
+	cwd, err := os.Getwd()
+	if err != nil {
+		return pt, err
+	}
+
+	for _, variant := range t.GetVariations() {
+		v := t.Clone()
+		v.Variations = []map[string]string{variant}
+
+		job, err := r.compiler.compileTask(v, execContext, nil, nil, nil, nil, env, vars)
+		if err != nil {
+			return pt, err
+		}
+
+		pv := PlannedVariation{Variation: variant, Dir: cwd, Env: map[string]string{}, Commands: []string{}}
+		if job != nil {
+			if job.Dir != "" {
+				pv.Dir = job.Dir
+			}
+			pv.Env = envDiff(job.EnvInherit.Filter(host), envutil.ConvertToMapOfStrings(job.Env.Map()))
+		}
+
+		for j := job; j != nil; j = j.Next {
+			command, err := renderJob(j)
+			if err != nil {
+				return pt, err
+			}
+			pv.Commands = append(pv.Commands, command)
+		}
+		pt.Variations = append(pt.Variations, pv)
+	}
+
+	return pt, nil
+}
+
+func (r *TaskRunner) planCommands(commands []string, t *task.Task, execContext *ExecutionContext, env, vars variables.Container) ([]string, error) {
+	var planned []string
+	for _, command := range commands {
+		job, err := r.compiler.compileCommand(command, execContext, t, nil, nil, nil, env, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		rendered, err := renderJob(job)
+		if err != nil {
+			return nil, err
+		}
+		planned = append(planned, rendered)
+	}
+
+	return planned, nil
+}
+
+// renderJob renders a job's command as the executor would; .Output, only known
+// at run time, is empty.
+func renderJob(j *executor.Job) (string, error) {
+	return tmpl.RenderStringEnv(j.Command, j.Vars.With("Output", "").Map(), j.Environ(hostEnviron()))
+}
+
+// envDiff returns the variables of env that host lacks or sets differently.
+// The env file, created anew for every run, is left out.
+func envDiff(host []string, env map[string]string) map[string]string {
+	hostEnv := make(map[string]string, len(host))
+	for _, kv := range host {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			hostEnv[k] = v
+		}
+	}
+
+	diff := make(map[string]string)
+	for k, v := range env {
+		if hv, ok := hostEnv[k]; k != envFileVar && (!ok || hv != v) {
+			diff[k] = v
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,74 @@
+package runner
+
+import (
+	"io"
+	"maps"
+	"testing"
+
+	"github.com/taskctl/taskctl/variables"
+
+	taskpkg "github.com/taskctl/taskctl/task"
+)
+
+func TestTaskRunner_Plan(t *testing.T) {
+	c := NewExecutionContext(&Binary{Bin: "/bin/sh", Args: []string{"-c"}}, "/tmp", variables.FromMap(map[string]string{"CTX": "1"}), nil, nil, nil, nil)
+	c.Quote = "'"
+
+	runner, err := NewTaskRunner(
+		WithContexts(map[string]*ExecutionContext{"wrapped": c}),
+		WithVariables(variables.FromMap(map[string]string{"Out": "bin"})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+
+	tsk := taskpkg.FromCommands("go build -o {{.Out}}/app-$GOOS")
+	tsk.Name = "build"
+	tsk.Context = "wrapped"
+	tsk.Condition = "test -d ."
+	tsk.Before = []string{"mkdir -p {{.Out}}"}
+	tsk.Variations = []map[string]string{{"GOOS": "linux"}, {"GOOS": "darwin"}}
+
+	runner.DryRun = true
+	if err = runner.Run(tsk); err != nil {
+		t.Fatal(err)
+	}
+
+	plan := runner.Plan()
+	if len(plan) != 1 {
+		t.Fatalf("expected one planned task, got %d", len(plan))
+	}
+
+	pt := plan[0]
+	if pt.Name != "build" || pt.Context != "wrapped" || pt.Condition != "test -d ." {
+		t.Errorf("unexpected planned task %+v", pt)
+	}
+	if len(pt.Before) != 1 || pt.Before[0] != "/bin/sh -c 'mkdir -p bin'" {
+		t.Errorf("unexpected before commands %q", pt.Before)
+	}
+	if len(pt.Variations) != 2 {
+		t.Fatalf("expected a planned variation per variation, got %d", len(pt.Variations))
+	}
+
+	v := pt.Variations[1]
+	if v.Dir != "/tmp" || v.Variation["GOOS"] != "darwin" {
+		t.Errorf("unexpected planned variation %+v", v)
+	}
+	if v.Env["GOOS"] != "darwin" || v.Env["CTX"] != "1" || v.Env[envFileVar] != "" {
+		t.Errorf("unexpected env diff %v", v.Env)
+	}
+	if len(v.Commands) != 1 || v.Commands[0] != "/bin/sh -c 'go build -o bin/app-$GOOS'" {
+		t.Errorf("unexpected planned commands %q", v.Commands)
+	}
+}
+
+func Test_envDiff(t *testing.T) {
+	host := []string{"HOME=/root", "PATH=/bin"}
+	env := map[string]string{"HOME": "/root", "PATH": "/usr/bin", "GOOS": "linux", envFileVar: "/tmp/x"}
+
+	want := map[string]string{"PATH": "/usr/bin", "GOOS": "linux"}
+	if got := envDiff(host, env); !maps.Equal(got, want) {
+		t.Errorf("envDiff() = %v, want %v", got, want)
+	}
+}
@@ -3,10 +3,13 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"slices"
+	"strconv"
 	"sync"
 	"time"
 
@@ -16,6 +19,9 @@ import (
 
 	"github.com/taskctl/taskctl/executor"
 	"github.com/taskctl/taskctl/internal/collections"
+	"github.com/taskctl/taskctl/internal/envutil"
+	"github.com/taskctl/taskctl/internal/tmpl"
+	"github.com/taskctl/taskctl/process"
 
 	"github.com/taskctl/taskctl/variables"
 
@@ -35,6 +41,10 @@ const defaultContextName = "default"
 // TASKCTL_ config vars (e.g. TASKCTL_OUTPUT_FORMAT) that taskctl reads.
 const injectedEnvPrefix = "TASKCTL__"
 
+// envFileVar names the variable holding the path of the file a task's commands
+// append KEY=value entries to, exporting them to the tasks run after it.
+const envFileVar = injectedEnvPrefix + "ENV"
+
 // Runner describes tasks runner interface
 type Runner interface {
 	Run(t *task.Task) error
@@ -46,13 +56,38 @@ type Runner interface {
 type TaskRunner struct {
 	// DryRun makes each task's commands (condition, before, main, after) render
 	// and parse for validation but not execute, so a task with valid commands is
-	// marked completed (an invalid template or command still fails). Context
-	// lifecycle hooks (Up/Down/Before/After) are not skipped.
-	DryRun    bool
+	// marked completed (an invalid template or command still fails), and records
+	// how each task would run in Plan. Context lifecycle hooks
+	// (Up/Down/Before/After) are not skipped.
+	DryRun bool
+	// DryRunConditions makes a dry run execute the tasks' conditions, so a task
+	// whose condition is not met is Skipped rather than planned.
+	DryRunConditions bool
+	// LogDir, when set, makes every task write its output (per variation) to
+	// its own timestamped file in this directory, recorded in Task.LogFiles.
+	LogDir string
+	// LogCombined additionally writes all tasks' output, each line prefixed
+	// with its task, to a single run-wide log in LogDir.
+	LogCombined bool
+	// LogMemoryLimit caps how much of each stream of a task's output is kept
+	// in memory (see task.Task.Log); 0 uses iox.DefaultSpillLimit.
+	LogMemoryLimit int
+	// EnvInherit selects the host environment variables visible to tasks run
+	// without a context; configured contexts carry their own setting.
+	EnvInherit *process.EnvInherit
+	// Deadline, when set, stops every task still running when it is reached,
+	// reporting it as timed out.
+	Deadline time.Time
+
 	contexts  map[string]*ExecutionContext
 	variables variables.Container
 	env       variables.Container
 
+	// defaultContext is the context of tasks without one when the config
+	// defines no default context.
+	defaultContext     *ExecutionContext
+	defaultContextOnce sync.Once
+
 	ctx         context.Context
 	cancelFunc  context.CancelFunc
 	cancelMutex sync.RWMutex
@@ -60,6 +95,7 @@ type TaskRunner struct {
 	doneCh      chan struct{}
 
 	results collections.SyncMap[string, taskResult]
+	plan    plan
 
 	compiler *taskCompiler
 
@@ -68,6 +104,10 @@ type TaskRunner struct {
 	OutputFormat   string
 
 	cleanupList collections.SyncMap[string, *ExecutionContext]
+
+	start      time.Time
+	combinedMu sync.Mutex
+	combined   *lockedWriter
 }
 
 // taskInfo and contextInfo are the template-facing views of the running task and
@@ -76,15 +116,17 @@ type TaskRunner struct {
 // static task metadata is exposed — not runtime state (exit code, logs), the
 // variable/env containers, or the raw (unrendered) command slices.
 type taskInfo struct {
-	Name         string
-	Description  string
-	Dir          string
-	Context      string
-	Condition    string
-	Timeout      *time.Duration
-	AllowFailure bool
-	Interactive  bool
-	ExportAs     string
+	Name           string
+	Description    string
+	Dir            string
+	Context        string
+	Condition      string
+	Timeout        *time.Duration
+	CommandTimeout *time.Duration
+	AllowFailure   bool
+	Interactive    bool
+	TTY            bool
+	ExportAs       string
 }
 
 type contextInfo struct {
@@ -94,11 +136,26 @@ type contextInfo struct {
 }
 
 // taskResult is the template-facing view of a completed task's result
-// (.Tasks.<Name>). Exported fields so text/template can read them.
+// (.Tasks.<Name>). Exported fields so text/template can read them; the output
+// is read through methods, only when a template uses it, as it may have
+// spilled to disk.
 type taskResult struct {
-	Stdout   string
-	Stderr   string
 	ExitCode int16
+	// Outputs are those the task's commands set with taskctl-set-output.
+	Outputs map[string]string
+
+	t *task.Task
+}
+
+// Stdout returns the task's whole stdout, read back into memory if it
+// spilled.
+func (r taskResult) Stdout() string {
+	return r.t.Stdout()
+}
+
+// Stderr returns the task's whole stderr, like Stdout.
+func (r taskResult) Stderr() string {
+	return r.t.Stderr()
 }
 
 // NewTaskRunner creates new TaskRunner instance
@@ -112,6 +169,7 @@ func NewTaskRunner(opts ...Opts) (*TaskRunner, error) {
 		variables:    variables.NewVariables(),
 		env:          variables.NewVariables(),
 		doneCh:       make(chan struct{}, 1),
+		start:        time.Now(),
 	}
 
 	r.ctx, r.cancelFunc = context.WithCancel(context.Background())
@@ -120,7 +178,7 @@ func NewTaskRunner(opts ...Opts) (*TaskRunner, error) {
 		o(r)
 	}
 
-	r.env = variables.FromMap(map[string]string{injectedEnvPrefix + "ARGS": r.variables.Get("Args").(string)})
+	r.env = r.env.With(injectedEnvPrefix+"ARGS", r.variables.Get("Args").(string))
 
 	return r, nil
 }
@@ -163,6 +221,8 @@ func (r *TaskRunner) Run(t *task.Task) (err error) {
 		stdin = r.Stdin
 	}
 
+	t.Log.Stdout.SetLimit(r.LogMemoryLimit)
+	t.Log.Stderr.SetLimit(r.LogMemoryLimit)
 	taskOutput, err := output.NewTaskOutput(t, outputFormat, r.Stdout, r.Stderr)
 	if err != nil {
 		return err
@@ -182,15 +242,17 @@ func (r *TaskRunner) Run(t *task.Task) (err error) {
 
 	vars := r.variables.Merge(execContext.Variables).Merge(t.Variables)
 	vars.Set("Task", taskInfo{
-		Name:         t.Name,
-		Description:  t.Description,
-		Dir:          t.Dir,
-		Context:      t.Context,
-		Condition:    t.Condition,
-		Timeout:      t.Timeout,
-		AllowFailure: t.AllowFailure,
-		Interactive:  t.Interactive,
-		ExportAs:     t.ExportAs,
+		Name:           t.Name,
+		Description:    t.Description,
+		Dir:            t.Dir,
+		Context:        t.Context,
+		Condition:      t.Condition,
+		Timeout:        t.Timeout,
+		CommandTimeout: t.CommandTimeout,
+		AllowFailure:   t.AllowFailure,
+		Interactive:    t.Interactive,
+		TTY:            t.TTY,
+		ExportAs:       t.ExportAs,
 	})
 	vars.Set("Context", contextInfo{Name: t.Context, Dir: execContext.Dir, Executable: execContext.Executable})
 	vars.Set("Tasks", r.results.Snapshot())
@@ -199,7 +261,39 @@ func (r *TaskRunner) Run(t *task.Task) (err error) {
 	env = env.With(injectedEnvPrefix+"TASK_NAME", t.Name)
 	env = env.Merge(t.Env)
 
-	meets, err := r.checkTaskCondition(t, env, vars)
+	ctx, cancel, cause := r.taskContext(t)
+	defer cancel()
+
+	// A timeout outside the task's commands (in the condition, before or
+	// after) times the task out as well.
+	defer func() {
+		switch {
+		case err == nil || t.TimedOut:
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			t.TimedOut, t.Errored = true, true
+			err = timeoutError(cause)
+		case errors.Is(err, executor.ErrTimedOut):
+			t.TimedOut, t.Errored = true, true
+		}
+	}()
+
+	// Computed variables run only when a template of the task reads them,
+	// those of the commands once the condition is met; computed env entries
+	// are the task's environment, condition included. A dry run runs none but
+	// those of a condition it runs, and plans the others as declared.
+	if r.DryRun && !r.DryRunConditions {
+		vars, env = declared(vars), declared(env)
+	} else {
+		used := usedVariables(vars, append(execContext.commandTemplates(t.Dir, t.Condition), scripts(env)...)...)
+		if vars, err = execContext.resolve(ctx, "variable", vars, vars, used); err != nil {
+			return err
+		}
+		if env, err = execContext.resolve(ctx, "env", env, vars, nil); err != nil {
+			return err
+		}
+	}
+
+	meets, err := r.checkTaskCondition(ctx, t, env, vars)
 	if err != nil {
 		return err
 	}
@@ -210,12 +304,54 @@ func (r *TaskRunner) Run(t *task.Task) (err error) {
 		return nil
 	}
 
-	err = r.before(r.ctx, t, env, vars)
+	if r.DryRun {
+		vars = declared(vars)
+	} else {
+		commands := slices.Concat(t.Commands, t.Before, t.After, t.OnFailure, t.Finally)
+		used := usedVariables(vars, execContext.commandTemplates(t.Dir, commands...)...)
+		if vars, err = execContext.resolve(ctx, "variable", vars, vars, used); err != nil {
+			return err
+		}
+	}
+
+	if r.DryRun {
+		pt, err := r.planTask(t, execContext, env, vars)
+		if err != nil {
+			return err
+		}
+		r.plan.add(pt)
+	}
+
+	envFile, err := newEnvFile()
 	if err != nil {
 		return err
 	}
+	defer removeEnvFile(envFile)
+	env = env.With(envFileVar, envFile)
 
-	job, err := r.compiler.compileTask(t, execContext, stdin, taskOutput.Stdout(), taskOutput.Stderr(), env, vars)
+	// Registered after the env file's removal so the hooks can still use it;
+	// err is the task's final result.
+	defer func() {
+		r.runHooks(t, env, vars, err)
+	}()
+
+	err = r.before(ctx, t, env, vars)
+	if err != nil {
+		return err
+	}
+
+	var run func(ctx context.Context, cause string) error
+	if units := parallelUnits(t); units != nil {
+		run, err = r.compileParallel(t, units, execContext, env, vars)
+	} else {
+		logs := r.newTaskLogs(t)
+		defer logs.close()
+
+		var job *executor.Job
+		job, err = r.compiler.compileTask(t, execContext, stdin, taskOutput.Stdout(), taskOutput.Stderr(), logs, env, vars)
+		reportTo(job, taskOutput)
+		run = func(ctx context.Context, cause string) error { return r.execute(ctx, t, job, cause) }
+	}
 	if err != nil {
 		return err
 	}
@@ -225,7 +361,15 @@ func (r *TaskRunner) Run(t *task.Task) (err error) {
 		return err
 	}
 
-	err = r.execute(r.ctx, t, job)
+	err = run(ctx, cause)
+
+	// Entries written by a failed task are exported too: a later stage allowed
+	// to run after the failure may need them.
+	if exportErr := r.exportEnvFile(envFile); exportErr != nil && err == nil {
+		t.Errored = true
+		t.Error = exportErr
+		err = exportErr
+	}
 
 	// execute leaves a succeeded task's exit code at -1; normalize it before the
 	// result is stored and the footer is written. Failures keep their real code.
@@ -238,7 +382,43 @@ func (r *TaskRunner) Run(t *task.Task) (err error) {
 		return err
 	}
 
-	return r.after(r.ctx, t, env, vars)
+	return r.after(ctx, t, env, vars)
+}
+
+// taskContext derives the context t runs in from the runner's, ending at the
+// earliest of the runner's Deadline, t's Deadline and t's Timeout, and
+// describes what set that deadline.
+func (r *TaskRunner) taskContext(t *task.Task) (context.Context, context.CancelFunc, string) {
+	var deadline time.Time
+	var cause string
+	earliest := func(d time.Time, c string) {
+		if !d.IsZero() && (deadline.IsZero() || d.Before(deadline)) {
+			deadline, cause = d, c
+		}
+	}
+
+	earliest(r.Deadline, "run deadline")
+	earliest(t.Deadline, t.DeadlineCause)
+	if t.Timeout != nil {
+		earliest(time.Now().Add(*t.Timeout), fmt.Sprintf("task timeout of %s", *t.Timeout))
+	}
+
+	if deadline.IsZero() {
+		ctx, cancel := context.WithCancel(r.ctx)
+		return ctx, cancel, ""
+	}
+
+	ctx, cancel := context.WithDeadline(r.ctx, deadline)
+	return ctx, cancel, cause
+}
+
+// timeoutError reports a task stopped by the deadline cause describes.
+func timeoutError(cause string) error {
+	if cause == "" {
+		return executor.ErrTimedOut
+	}
+
+	return fmt.Errorf("%w: %s exceeded", executor.ErrTimedOut, cause)
 }
 
 // Cancel cancels execution
@@ -259,6 +439,7 @@ func (r *TaskRunner) Finish() {
 		value.Down()
 		return true
 	})
+	r.closeCombinedLog()
 	output.Close()
 }
 
@@ -273,7 +454,7 @@ func (r *TaskRunner) before(ctx context.Context, t *task.Task, env, vars variabl
 	}
 
 	for _, command := range t.Before {
-		job, err := r.compiler.compileCommand(command, execContext, t.Dir, t.Timeout, nil, r.Stdout, r.Stderr, env, vars)
+		job, err := r.compiler.compileCommand(command, execContext, t, nil, r.Stdout, r.Stderr, env, vars)
 		if err != nil {
 			return fmt.Errorf("\"before\" command compilation failed: %w", err)
 		}
@@ -304,7 +485,7 @@ func (r *TaskRunner) after(ctx context.Context, t *task.Task, env, vars variable
 	}
 
 	for _, command := range t.After {
-		job, err := r.compiler.compileCommand(command, execContext, t.Dir, t.Timeout, nil, r.Stdout, r.Stderr, env, vars)
+		job, err := r.compiler.compileCommand(command, execContext, t, nil, r.Stdout, r.Stderr, env, vars)
 		if err != nil {
 			return fmt.Errorf("\"after\" command compilation failed: %w", err)
 		}
@@ -324,6 +505,59 @@ func (r *TaskRunner) after(ctx context.Context, t *task.Task, env, vars variable
 	return nil
 }
 
+// runHooks runs the task's on_failure commands when taskErr is set, then its
+// finally commands, with the task's exit code and error message in the
+// .ExitCode and .Error variables and the TASKCTL__EXIT_CODE and TASKCTL__ERROR
+// environment variables. Like context cleanup they run even when the run was
+// canceled. Their failures are logged, never returned, so they can't mask the
+// task's own result.
+func (r *TaskRunner) runHooks(t *task.Task, env, vars variables.Container, taskErr error) {
+	if len(t.OnFailure) == 0 && len(t.Finally) == 0 {
+		return
+	}
+
+	ctx := context.Background()
+	execContext, err := r.contextForTask(ctx, t)
+	if err != nil {
+		slog.Error(err.Error())
+		return
+	}
+
+	var errMsg string
+	if taskErr != nil {
+		errMsg = taskErr.Error()
+	}
+
+	env = env.With(injectedEnvPrefix+"EXIT_CODE", strconv.Itoa(int(t.ExitCode))).With(injectedEnvPrefix+"ERROR", errMsg)
+	vars = vars.With("ExitCode", t.ExitCode).With("Error", errMsg)
+
+	if taskErr != nil {
+		r.runHook(ctx, "on_failure", t.OnFailure, t, execContext, env, vars)
+	}
+	r.runHook(ctx, "finally", t.Finally, t, execContext, env, vars)
+}
+
+func (r *TaskRunner) runHook(ctx context.Context, name string, commands []string, t *task.Task, execContext *ExecutionContext, env, vars variables.Container) {
+	for _, command := range commands {
+		job, err := r.compiler.compileCommand(command, execContext, t, nil, r.Stdout, r.Stderr, env, vars)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("%q command compilation failed: %s", name, err))
+			continue
+		}
+
+		exec, err := executor.NewDefaultExecutor(job.Stdin, job.Stdout, job.Stderr)
+		if err != nil {
+			slog.Warn(err.Error())
+			continue
+		}
+		exec.DryRun = r.DryRun
+
+		if _, err := exec.Execute(ctx, job); err != nil {
+			slog.Warn(fmt.Sprintf("%q command failed: %s", name, err))
+		}
+	}
+}
+
 func (r *TaskRunner) contextForTask(ctx context.Context, t *task.Task) (c *ExecutionContext, err error) {
 	name := t.Context
 	if name == "" {
@@ -338,7 +572,13 @@ func (r *TaskRunner) contextForTask(ctx context.Context, t *task.Task) (c *Execu
 	case t.Context != "":
 		return nil, fmt.Errorf("no such context %q", t.Context)
 	default:
-		c = defaultContext()
+		// One context serves every such task, so they share its computed
+		// values.
+		r.defaultContextOnce.Do(func() {
+			r.defaultContext = defaultContext()
+			r.defaultContext.EnvInherit = r.EnvInherit
+		})
+		c = r.defaultContext
 	}
 
 	err = c.Up(ctx)
@@ -354,7 +594,7 @@ func (r *TaskRunner) contextForTask(ctx context.Context, t *task.Task) (c *Execu
 	return c, nil
 }
 
-func (r *TaskRunner) checkTaskCondition(t *task.Task, env, vars variables.Container) (bool, error) {
+func (r *TaskRunner) checkTaskCondition(ctx context.Context, t *task.Task, env, vars variables.Container) (bool, error) {
 	if t.Condition == "" {
 		return true, nil
 	}
@@ -364,7 +604,7 @@ func (r *TaskRunner) checkTaskCondition(t *task.Task, env, vars variables.Contai
 		return false, err
 	}
 
-	job, err := r.compiler.compileCommand(t.Condition, executionContext, t.Dir, t.Timeout, nil, r.Stdout, r.Stderr, env, vars)
+	job, err := r.compiler.compileCommand(t.Condition, executionContext, t, nil, r.Stdout, r.Stderr, env, vars)
 	if err != nil {
 		return false, err
 	}
@@ -373,11 +613,11 @@ func (r *TaskRunner) checkTaskCondition(t *task.Task, env, vars variables.Contai
 	if err != nil {
 		return false, err
 	}
-	exec.DryRun = r.DryRun
+	exec.DryRun = r.DryRun && !r.DryRunConditions
 
-	_, err = exec.Execute(context.Background(), job)
+	_, err = exec.Execute(ctx, job)
 	if err != nil {
-		if _, ok := executor.IsExitStatus(err); ok {
+		if _, ok := executor.IsExitStatus(err); ok && !errors.Is(err, executor.ErrTimedOut) {
 			return false, nil
 		}
 
@@ -387,42 +627,113 @@ func (r *TaskRunner) checkTaskCondition(t *task.Task, env, vars variables.Contai
 	return true, nil
 }
 
-func (r *TaskRunner) storeTaskResult(t *task.Task) {
-	stdout := t.Stdout()
-	stderr := t.Stderr()
+// newEnvFile creates the empty file a task's commands export variables to.
+func newEnvFile() (string, error) {
+	f, err := os.CreateTemp("", "taskctl-env-")
+	if err != nil {
+		return "", err
+	}
+
+	return f.Name(), f.Close()
+}
+
+func removeEnvFile(name string) {
+	if err := os.Remove(name); err != nil {
+		slog.Debug(err.Error())
+	}
+}
+
+// exportEnvFile adds the variables a task's commands wrote to its env file to
+// the environment of every task run after it.
+func (r *TaskRunner) exportEnvFile(name string) error {
+	envs, err := envutil.ReadExportFile(name)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", envFileVar, err)
+	}
+
+	for k, v := range envs {
+		r.env.Set(k, v)
+	}
 
+	return nil
+}
+
+// storeTaskResult makes t's result available to the tasks run after it. Its
+// output is only read when used: exportAs reads the whole stdout back into
+// memory here, however much of it spilled, as do .Tasks.<Name>.Stdout and
+// .Stderr when a template renders them.
+func (r *TaskRunner) storeTaskResult(t *task.Task) {
 	if t.ExportAs != "" {
-		r.env.Set(t.ExportAs, stdout)
+		r.env.Set(t.ExportAs, t.Stdout())
 	}
 
 	r.results.Store(cases.Title(language.English).String(t.Name), taskResult{
-		Stdout:   stdout,
-		Stderr:   stderr,
 		ExitCode: t.ExitCode,
+		Outputs:  t.Outputs,
+		t:        t,
 	})
 }
 
-func (r *TaskRunner) execute(ctx context.Context, t *task.Task, job *executor.Job) error {
-	exec, err := executor.NewDefaultExecutor(job.Stdin, job.Stdout, job.Stderr)
-	if err != nil {
-		return err
-	}
-	exec.DryRun = r.DryRun
+// execute runs the jobs of t in order. cause describes the deadline of ctx, if
+// any, for the error of a task it stops.
+func (r *TaskRunner) execute(ctx context.Context, t *task.Task, job *executor.Job, cause string) error {
+	var exec *executor.DefaultExecutor
 
 	t.Start = time.Now()
 	var prevOutput []byte
 	for nextJob := job; nextJob != nil; nextJob = nextJob.Next {
 		var err error
+
+		// Each variation may write to its own log file; a job whose writers
+		// differ from the previous one starts a new variation and gets a
+		// fresh executor.
+		if exec == nil || nextJob.Stdout != job.Stdout || nextJob.Stderr != job.Stderr {
+			exec, err = executor.NewDefaultExecutor(nextJob.Stdin, nextJob.Stdout, nextJob.Stderr)
+			if err != nil {
+				return err
+			}
+			exec.DryRun = r.DryRun
+			job = nextJob
+		}
+
 		nextJob.Vars.Set("Output", string(prevOutput))
 
+		// A command's output is only kept for a next command that reads it
+		// as .Output; the task's log has it anyway.
+		exec.DiscardOutput = nextJob.Next == nil || !tmpl.Uses(nextJob.Next.Command, "Output")
+		exec.OutputLimit = r.LogMemoryLimit
 		prevOutput, err = exec.Execute(ctx, nextJob)
+
+		// A timed out command's exit status is whatever the kill left it
+		// with: it neither succeeds nor is allowed to fail.
+		timedOut := errors.Is(err, executor.ErrTimedOut)
+		code, exited := commandExitCode(err)
+		switch {
+		case !exited, r.DryRun:
+		case timedOut:
+			t.ExitCode = int16(code)
+		default:
+			if t.Succeeds(code) {
+				err = nil
+			} else if err == nil {
+				err = fmt.Errorf("exit status %d is not one of the success codes %v", code, t.SuccessCodes)
+			}
+			// Keep the raw code of a command that did not exit 0, even when
+			// it counts as a success.
+			if code != 0 || err != nil {
+				t.ExitCode = int16(code)
+			}
+		}
+
 		if err != nil {
 			slog.Debug(err.Error())
-			if status, ok := executor.IsExitStatus(err); ok {
-				t.ExitCode = int16(status)
-				if t.AllowFailure {
-					continue
+			if timedOut {
+				t.TimedOut = true
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					err = timeoutError(cause)
 				}
+			} else if exited && t.AllowsFailure(code) {
+				continue
 			}
 			t.Errored = true
 			t.Error = err
@@ -435,6 +746,18 @@ func (r *TaskRunner) execute(ctx context.Context, t *task.Task, job *executor.Jo
 	return nil
 }
 
+// commandExitCode returns the exit code of a command that returned err: 0 for
+// nil, the status of an exit status error. exited is false for errors that
+// are not a command's exit status (a bad template, a canceled context).
+func commandExitCode(err error) (code int, exited bool) {
+	if err == nil {
+		return 0, true
+	}
+
+	status, ok := executor.IsExitStatus(err)
+	return int(status), ok
+}
+
 // Opts is a task runner configuration function.
 type Opts func(*TaskRunner)
 
@@ -445,6 +768,13 @@ func WithContexts(contexts map[string]*ExecutionContext) Opts {
 	}
 }
 
+// WithEnv sets the env of every task, under the env of its context
+func WithEnv(env variables.Container) Opts {
+	return func(runner *TaskRunner) {
+		runner.env = env
+	}
+}
+
 // WithVariables adds provided variables to task runner
 func WithVariables(variables variables.Container) Opts {
 	return func(runner *TaskRunner) {
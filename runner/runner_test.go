@@ -1,12 +1,19 @@
 package runner
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"runtime"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/taskctl/taskctl/executor"
+	"github.com/taskctl/taskctl/process"
 	"github.com/taskctl/taskctl/variables"
 
 	taskpkg "github.com/taskctl/taskctl/task"
@@ -141,15 +148,38 @@ func TestTaskRunner_DryRun(t *testing.T) {
 		t.Errorf("dry-run must produce no command output, got %q", out)
 	}
 
-	// A condition that would normally skip the task is not evaluated either, so
-	// the task is completed rather than skipped.
+	// A condition is not run either, so the task is planned.
 	conditional := taskpkg.FromCommands("echo hi")
+	conditional.Name = "conditional"
 	conditional.Condition = "exit 1"
 	if err = runner.Run(conditional); err != nil {
 		t.Fatal(err)
 	}
 	if conditional.Skipped {
-		t.Error("dry-run must not evaluate the condition; the task is completed, not skipped")
+		t.Error("dry-run must not evaluate the condition")
+	}
+	if !slices.ContainsFunc(runner.Plan(), func(pt PlannedTask) bool {
+		return pt.Name == "conditional" && !pt.ConditionEvaluated
+	}) {
+		t.Error("a task with a condition must be planned, its condition not evaluated")
+	}
+
+	// DryRunConditions runs the condition, so the plan leaves out the tasks
+	// it skips.
+	runner.DryRunConditions = true
+	skipped := taskpkg.FromCommands("echo hi")
+	skipped.Name = "skipped"
+	skipped.Condition = "exit 1"
+	if err = runner.Run(skipped); err != nil {
+		t.Fatal(err)
+	}
+	if !skipped.Skipped {
+		t.Error("DryRunConditions must evaluate the condition and skip the task")
+	}
+	for _, pt := range runner.Plan() {
+		if pt.Name == "skipped" {
+			t.Error("a skipped task must not be planned")
+		}
 	}
 
 	runner.Finish()
@@ -322,6 +352,90 @@ func TestTaskRunner_NoEnvExportWithoutExportAs(t *testing.T) {
 	}
 }
 
+func TestTaskRunner_EnvFileExportsToLaterTasks(t *testing.T) {
+	runner, err := NewTaskRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+	defer runner.Finish()
+
+	producer := taskpkg.FromCommands(
+		`echo "VERSION=1.2.3" >> "$TASKCTL__ENV"`,
+		`printf 'NOTES<<EOF\nfirst line\nsecond line\nEOF\n' >> "$TASKCTL__ENV"`,
+	)
+	producer.Name = "producer"
+	if err := runner.Run(producer); err != nil {
+		t.Fatal(err)
+	}
+
+	consumer := taskpkg.FromCommands(`printf "[%s|%s]" "$VERSION" "$NOTES"`)
+	consumer.Name = "consumer"
+	if err := runner.Run(consumer); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := consumer.Stdout(), "[1.2.3|first line\nsecond line]"; got != want {
+		t.Errorf("exported env = %q, want %q", got, want)
+	}
+}
+
+func TestTaskRunner_EnvFileInvalidFailsTask(t *testing.T) {
+	runner, err := NewTaskRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+	defer runner.Finish()
+
+	tsk := taskpkg.FromCommands(`echo "not an entry" >> "$TASKCTL__ENV"`)
+	tsk.Name = "broken"
+	if err := runner.Run(tsk); err == nil || !strings.Contains(err.Error(), "TASKCTL__ENV") {
+		t.Fatalf("expected an env file error, got %v", err)
+	}
+	if !tsk.Errored {
+		t.Error("task must be marked as errored")
+	}
+}
+
+func TestTaskRunner_OnFailureAndFinally(t *testing.T) {
+	runner, err := NewTaskRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	runner.Stdout, runner.Stderr = &out, io.Discard
+	defer runner.Finish()
+
+	failing := taskpkg.FromCommands("exit 3")
+	failing.Name = "failing"
+	failing.OnFailure = []string{`echo "on_failure {{ .ExitCode }} $TASKCTL__EXIT_CODE {{ .Error }}"`, "exit 1"}
+	failing.Finally = []string{`echo "finally $TASKCTL__EXIT_CODE"`}
+
+	err = runner.Run(failing)
+	if code, ok := executor.IsExitStatus(err); !ok || code != 3 {
+		t.Fatalf("the task's own failure must be returned, got %v", err)
+	}
+	if failing.ExitCode != 3 {
+		t.Errorf("ExitCode = %d, want 3", failing.ExitCode)
+	}
+	if got, want := out.String(), "on_failure 3 3 exit status 3\nfinally 3\n"; got != want {
+		t.Errorf("hooks output = %q, want %q", got, want)
+	}
+
+	out.Reset()
+	passing := taskpkg.FromCommands("true")
+	passing.Name = "passing"
+	passing.OnFailure = []string{"echo on_failure"}
+	passing.Finally = []string{`echo "finally {{ .ExitCode }} [{{ .Error }}]"`, "exit 1"}
+	if err := runner.Run(passing); err != nil {
+		t.Fatalf("a failing finally command must not fail the task: %v", err)
+	}
+	if got, want := out.String(), "finally 0 []\n"; got != want {
+		t.Errorf("hooks output = %q, want %q", got, want)
+	}
+}
+
 func TestTaskRunner_InjectsPrefixedTaskName(t *testing.T) {
 	runner, err := NewTaskRunner()
 	if err != nil {
@@ -385,6 +499,92 @@ func TestTaskRunner_TasksStdoutVariable(t *testing.T) {
 	}
 }
 
+func TestTaskRunner_TasksOutputsVariable(t *testing.T) {
+	runner, err := NewTaskRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+	defer runner.Finish()
+
+	producer := taskpkg.FromCommands("taskctl-set-output version 1.2.3", "echo abc | taskctl-set-output commit")
+	producer.Name = "producer"
+	if err := runner.Run(producer); err != nil {
+		t.Fatal(err)
+	}
+	if producer.Outputs["version"] != "1.2.3" || producer.Outputs["commit"] != "abc" {
+		t.Errorf("unexpected outputs %v", producer.Outputs)
+	}
+
+	consumer := taskpkg.FromCommands(`printf "[{{ .Tasks.Producer.Outputs.version }}]"`)
+	consumer.Name = "consumer"
+	if err := runner.Run(consumer); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := consumer.Stdout(); !strings.Contains(got, "[1.2.3]") {
+		t.Errorf(".Tasks.<Name>.Outputs must expose producer outputs: got %q", got)
+	}
+}
+
+func TestTaskRunner_LogMemoryLimit(t *testing.T) {
+	runner, err := NewTaskRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+	runner.LogMemoryLimit = 64
+	defer runner.Finish()
+
+	long := strings.Repeat("x", 100)
+	producer := taskpkg.FromCommands("echo "+long, `printf "[{{ .Output }}]"`, "echo "+long+" >&2; false")
+	producer.Name = "producer"
+	producer.ExportAs = "PRODUCED"
+	producer.AllowFailure = true
+	if err := runner.Run(producer); err != nil {
+		t.Fatal(err)
+	}
+	if !producer.Log.Stdout.Spilled() || !producer.Log.Stderr.Spilled() {
+		t.Fatal("the task's logs didn't spill past the limit")
+	}
+	if want := long + "\n[" + long + "\n]"; producer.Stdout() != want {
+		t.Errorf("unexpected stdout %q, want %q", producer.Stdout(), want)
+	}
+
+	consumer := taskpkg.FromCommands(`printf "{{ len .Tasks.Producer.Stdout }} {{ len .Tasks.Producer.Stderr }} ${#PRODUCED}"`)
+	consumer.Name = "consumer"
+	if err := runner.Run(consumer); err != nil {
+		t.Fatal(err)
+	}
+	if got := consumer.Stdout(); got != "204 101 204" {
+		t.Errorf("unexpected .Tasks and exportAs lengths %q", got)
+	}
+}
+
+func TestTaskRunner_TemplateFuncs(t *testing.T) {
+	dir := t.TempDir()
+	runner, err := NewTaskRunner(WithVariables(variables.FromMap(map[string]string{"Root": dir + "/"})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+	runner.variables.Set("ArgsList", []string{"a b", "c"})
+	defer runner.Finish()
+
+	tsk := taskpkg.FromCommands(`printf "%s|" {{ .ArgsList | shellQuote }} {{ .Name | upper }} "$(pwd)"`)
+	tsk.Name = "funcs"
+	tsk.Dir = "{{ clean .Root }}"
+	tsk.Condition = `[ "{{ semverCompare ">=1.2" "1.10.0" }}" = true ]`
+	tsk.Variables = variables.FromMap(map[string]string{"Name": `{{ "v1.2.3" | trimPrefix "v" }}`})
+	if err := runner.Run(tsk); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "a b|c|1.2.3|" + dir + "|"; tsk.Stdout() != want {
+		t.Errorf("unexpected output %q, want %q", tsk.Stdout(), want)
+	}
+}
+
 func TestTaskRunner_PredefinedTaskVars(t *testing.T) {
 	runner, err := NewTaskRunner()
 	if err != nil {
@@ -441,3 +641,305 @@ func ExampleTaskRunner_Run() {
 	}
 	fmt.Println(t.Stdout())
 }
+
+func TestTaskRunner_LogDir(t *testing.T) {
+	dir := t.TempDir()
+
+	runner, err := NewTaskRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.LogDir = dir
+	runner.LogCombined = true
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+
+	tsk := taskpkg.FromCommands("echo out-$GOOS", "echo err-$GOOS >&2")
+	tsk.Name = "build:all"
+	tsk.Variations = []map[string]string{{"GOOS": "linux"}, {"GOOS": "darwin"}}
+	if err = runner.Run(tsk); err != nil {
+		t.Fatal(err)
+	}
+	runner.Finish()
+
+	if len(tsk.LogFiles) != 2 {
+		t.Fatalf("expected one log file per variation, got %v", tsk.LogFiles)
+	}
+
+	for i, goos := range []string{"linux", "darwin"} {
+		f := tsk.LogFiles[i]
+		if !strings.HasPrefix(f, dir) || !strings.HasSuffix(f, "-build_all-GOOS="+goos+".log") {
+			t.Errorf("unexpected log file name %q", f)
+		}
+
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "out-"+goos+"\nerr-"+goos+"\n" {
+			t.Errorf("log %s: got %q", f, data)
+		}
+	}
+
+	combined := runner.CombinedLogFile()
+	if combined == "" {
+		t.Fatal("expected a combined log")
+	}
+	data, err := os.ReadFile(combined)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "[build:all (GOOS=darwin)] err-darwin\n") {
+		t.Errorf("combined log lines must be prefixed with the task, got %q", data)
+	}
+}
+
+func TestTaskRunner_TTY(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("pseudo-terminals are supported on Linux only")
+	}
+
+	runner, err := NewTaskRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	runner.Stdout, runner.Stderr = &out, io.Discard
+	runner.LogDir = t.TempDir()
+	defer runner.Finish()
+
+	tsk := taskpkg.FromCommands(`sh -c '[ -t 1 ] && printf "\033[1mtty\033[0m\n"'`)
+	tsk.Name = "colors"
+	tsk.TTY = true
+	if err := runner.Run(tsk); err != nil {
+		t.Fatal(err)
+	}
+
+	// The raw output keeps the escape sequences; the log and log files don't.
+	if out.String() != "\x1b[1mtty\x1b[0m\n" {
+		t.Errorf("raw output = %q", out.String())
+	}
+	if tsk.Stdout() != "tty\n" {
+		t.Errorf("task log = %q", tsk.Stdout())
+	}
+	if data, err := os.ReadFile(tsk.LogFiles[0]); err != nil || string(data) != "tty\n" {
+		t.Errorf("log file = %q, %v", data, err)
+	}
+}
+
+func TestTaskRunner_EnvInherit(t *testing.T) {
+	t.Setenv("TASKCTL_TEST_HOST", "host")
+
+	c := NewExecutionContext(nil, "", variables.NewVariables(), nil, nil, nil, nil, WithEnvInherit(&process.EnvInherit{}))
+	runner, err := NewTaskRunner(WithContexts(map[string]*ExecutionContext{"hermetic": c}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+	runner.EnvInherit = &process.EnvInherit{Names: []string{"TASKCTL_TEST_HOST"}}
+	defer runner.Finish()
+
+	command := `printf "[%s]" "${TASKCTL_TEST_HOST:-unset}"`
+	tests := []struct {
+		name, context string
+		inherit       *process.EnvInherit
+		want          string
+	}{
+		{"runner default", "", nil, "[host]"},
+		{"context", "hermetic", nil, "[unset]"},
+		{"task overrides context", "hermetic", &process.EnvInherit{All: true}, "[host]"},
+	}
+	for _, tt := range tests {
+		tsk := taskpkg.FromCommands(command)
+		tsk.Name = tt.name
+		tsk.Context = tt.context
+		tsk.EnvInherit = tt.inherit
+		if err := runner.Run(tsk); err != nil {
+			t.Fatal(err)
+		}
+		if got := tsk.Stdout(); !strings.Contains(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTaskRunner_TemplateEnv(t *testing.T) {
+	t.Setenv("TASKCTL_TEST_HOST", "host")
+
+	c := NewExecutionContext(nil, "", variables.NewVariables(), nil, nil, nil, nil, WithEnvInherit(&process.EnvInherit{}))
+	runner, err := NewTaskRunner(WithContexts(map[string]*ExecutionContext{"hermetic": c}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+	defer runner.Finish()
+
+	// env reads the task's environment, in commands and variables alike: its
+	// env entries, and of the host's only those it inherits.
+	tsk := taskpkg.FromCommands(`printf "[%s] {{ .Greeting }}" '{{ env "TASKCTL_TEST_HOST" }}'`)
+	tsk.Context = "hermetic"
+	tsk.Env = variables.FromMap(map[string]string{"GREETING": "hello"})
+	tsk.Variables = variables.FromMap(map[string]string{"Greeting": `{{ env "GREETING" }}`})
+	if err := runner.Run(tsk); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tsk.Stdout(), "[] hello"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTaskRunner_Builtins(t *testing.T) {
+	// No binaries on PATH: cat only runs as a builtin.
+	env := variables.FromMap(map[string]string{"PATH": t.TempDir()})
+	c := NewExecutionContext(nil, "", env, nil, nil, nil, nil, WithBuiltins([]string{"coreutils"}))
+	runner, err := NewTaskRunner(WithContexts(map[string]*ExecutionContext{"scratch": c}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+	defer runner.Finish()
+
+	tsk := taskpkg.FromCommands("echo builtin | cat")
+	tsk.Name = "context"
+	tsk.Context = "scratch"
+	if err := runner.Run(tsk); err != nil || !strings.Contains(tsk.Stdout(), "builtin") {
+		t.Errorf("the context's builtins must apply to its tasks, got %v, %q", err, tsk.Stdout())
+	}
+
+	tsk = taskpkg.FromCommands("echo builtin | cat")
+	tsk.Name = "task overrides context"
+	tsk.Context = "scratch"
+	tsk.Builtins = []string{}
+	if code, ok := executor.IsExitStatus(runner.Run(tsk)); !ok || code != 127 {
+		t.Errorf("a task's builtins must override its context's, got exit %d", code)
+	}
+}
+
+func TestTaskRunner_Timeout(t *testing.T) {
+	runner, err := NewTaskRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+	defer runner.Finish()
+
+	// No command reaches the timeout on its own; together they do.
+	slow := taskpkg.FromCommands("sleep 0.2", "sleep 0.2", "sleep 5")
+	slow.Name = "slow"
+	timeout := 300 * time.Millisecond
+	slow.Timeout = &timeout
+	slow.AllowFailure = true
+
+	err = runner.Run(slow)
+	if !errors.Is(err, executor.ErrTimedOut) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	if !slow.TimedOut || !slow.Errored {
+		t.Errorf("TimedOut = %t, Errored = %t, want both set", slow.TimedOut, slow.Errored)
+	}
+	if got, want := err.Error(), "timed out: task timeout of 300ms exceeded"; got != want {
+		t.Errorf("error = %q, want %q", got, want)
+	}
+
+	perCommand := taskpkg.FromCommands("sleep 5", "echo unreachable")
+	perCommand.Name = "per-command"
+	commandTimeout := 50 * time.Millisecond
+	perCommand.CommandTimeout = &commandTimeout
+
+	err = runner.Run(perCommand)
+	if !errors.Is(err, executor.ErrTimedOut) || !perCommand.TimedOut {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	if got, want := err.Error(), "command timed out after 50ms"; got != want {
+		t.Errorf("error = %q, want %q", got, want)
+	}
+}
+
+func TestTaskRunner_Deadline(t *testing.T) {
+	runner, err := NewTaskRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+	runner.Deadline = time.Now().Add(50 * time.Millisecond)
+	defer runner.Finish()
+
+	// The condition runs under the deadline too.
+	tsk := taskpkg.FromCommands("true")
+	tsk.Name = "slow-condition"
+	tsk.Condition = "sleep 5"
+
+	err = runner.Run(tsk)
+	if !errors.Is(err, executor.ErrTimedOut) || !tsk.TimedOut {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	if got, want := err.Error(), "timed out: run deadline exceeded"; got != want {
+		t.Errorf("error = %q, want %q", got, want)
+	}
+}
+
+func TestTaskRunner_ExitCodes(t *testing.T) {
+	runner, err := NewTaskRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	runner.Stdout, runner.Stderr = &out, io.Discard
+	defer runner.Finish()
+
+	issues := taskpkg.FromCommands("exit 3", "echo next")
+	issues.Name = "issues"
+	issues.SuccessCodes = []int{0, 3}
+	if err := runner.Run(issues); err != nil {
+		t.Fatalf("a success code must not fail the task: %v", err)
+	}
+	if issues.ExitCode != 3 || out.String() != "next\n" {
+		t.Errorf("ExitCode = %d, output = %q; want the raw code 3 and the next command run", issues.ExitCode, out.String())
+	}
+
+	notFound := taskpkg.FromCommands("true")
+	notFound.Name = "not-found"
+	notFound.SuccessCodes = []int{1}
+	err = runner.Run(notFound)
+	if err == nil || notFound.ExitCode != 0 || !notFound.Errored {
+		t.Errorf("exit 0 must fail when success codes don't list it, got %v (exit %d)", err, notFound.ExitCode)
+	}
+
+	allowed := taskpkg.FromCommands("exit 1")
+	allowed.Name = "allowed"
+	allowed.AllowFailure = true
+	allowed.AllowFailureExitCodes = []int{1}
+	if err := runner.Run(allowed); err != nil || allowed.ExitCode != 1 {
+		t.Errorf("an allowed exit code must not fail the task, got %v (exit %d)", err, allowed.ExitCode)
+	}
+
+	crashed := taskpkg.FromCommands("exit 2")
+	crashed.Name = "crashed"
+	crashed.AllowFailure = true
+	crashed.AllowFailureExitCodes = []int{1}
+	err = runner.Run(crashed)
+	if code, ok := executor.IsExitStatus(err); !ok || code != 2 || crashed.ExitCode != 2 {
+		t.Errorf("an exit code not allowed must fail the task, got %v (exit %d)", err, crashed.ExitCode)
+	}
+}
+
+func TestTaskRunner_WithEnv(t *testing.T) {
+	c := NewExecutionContext(nil, "", variables.FromMap(map[string]string{"TARGET": "context"}), nil, nil, nil, nil)
+	runner, err := NewTaskRunner(
+		WithContexts(map[string]*ExecutionContext{"local": c}),
+		WithEnv(variables.FromMap(map[string]string{"TARGET": "global", "CHANNEL": "global"})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Stdout, runner.Stderr = io.Discard, io.Discard
+	defer runner.Finish()
+
+	tsk := taskpkg.FromCommands(`printf "%s %s" "$TARGET" "$CHANNEL"`)
+	tsk.Context = "local"
+	if err := runner.Run(tsk); err != nil {
+		t.Fatal(err)
+	}
+	if want := "context global"; tsk.Stdout() != want {
+		t.Errorf("unexpected output %q, want %q", tsk.Stdout(), want)
+	}
+}
@@ -3,6 +3,7 @@ package scheduler
 import (
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/taskctl/taskctl/internal/collections"
@@ -13,11 +14,18 @@ var ErrCycleDetected = errors.New("cycle detected")
 
 // ExecutionGraph is a DAG whose nodes are Stages and edges are their dependencies
 type ExecutionGraph struct {
+	// Timeout caps the run of the whole pipeline; stages still running when
+	// it ends time out, and those not started yet are canceled. Zero for none.
+	Timeout time.Duration
+
 	nodes      map[string]*Stage
 	from       map[string][]string
 	to         map[string][]string
-	error      error
 	start, end time.Time
+
+	// mu guards error, set by the scheduling loop and the stages' goroutines.
+	mu    sync.Mutex
+	error error
 }
 
 // NewExecutionGraph creates new ExecutionGraph instance.
@@ -114,9 +122,22 @@ func (g *ExecutionGraph) cycleDfs(t string, visited *collections.Set[string]) er
 
 // LastError returns latest error appeared during stages execution
 func (g *ExecutionGraph) LastError() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
 	return g.error
 }
 
+// setError sets the error of g, unless it has one already when keep is set.
+func (g *ExecutionGraph) setError(err error, keep bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !keep || g.error == nil {
+		g.error = err
+	}
+}
+
 // Duration returns execution duration
 func (g *ExecutionGraph) Duration() time.Duration {
 	if g.end.IsZero() {
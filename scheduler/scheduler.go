@@ -4,12 +4,14 @@ package scheduler
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"os/exec"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/taskctl/taskctl/executor"
 	"github.com/taskctl/taskctl/runner"
 )
 
@@ -21,6 +23,10 @@ func isExitError(err error) bool {
 
 // Scheduler executes ExecutionGraph
 type Scheduler struct {
+	// Deadline, when set, ends every scheduled pipeline: running stages time
+	// out and those not started yet are canceled.
+	Deadline time.Time
+
 	taskRunner runner.Runner
 	pause      time.Duration
 
@@ -39,6 +45,11 @@ func NewScheduler(r runner.Runner) *Scheduler {
 
 // Schedule starts execution of the given ExecutionGraph
 func (s *Scheduler) Schedule(g *ExecutionGraph) error {
+	dl := deadline{at: s.Deadline, cause: "run deadline"}
+	return s.schedule(g, dl.earlier(g.Timeout, fmt.Sprintf("pipeline timeout of %s", g.Timeout)))
+}
+
+func (s *Scheduler) schedule(g *ExecutionGraph, dl deadline) error {
 	g.start = time.Now()
 	defer func() { g.end = time.Now() }()
 
@@ -49,6 +60,10 @@ func (s *Scheduler) Schedule(g *ExecutionGraph) error {
 			break
 		}
 
+		if dl.expired() {
+			cancelWaiting(g, dl)
+		}
+
 		for _, stage := range g.Nodes() {
 			status := stage.ReadStatus()
 			if status != StatusWaiting {
@@ -84,12 +99,16 @@ func (s *Scheduler) Schedule(g *ExecutionGraph) error {
 
 				stage.Start = time.Now()
 
-				err := s.runStage(stage)
+				err := s.runStage(stage, dl.earlier(stage.Timeout, fmt.Sprintf("stage %s timeout of %s", stage.Name, stage.Timeout)))
 				if err != nil {
-					stage.updateStatus(StatusError)
+					if errors.Is(err, executor.ErrTimedOut) {
+						stage.updateStatus(StatusTimedOut)
+					} else {
+						stage.updateStatus(StatusError)
+					}
 
-					if !stage.AllowFailure {
-						g.error = err
+					if !stage.AllowsFailure() {
+						g.setError(err, false)
 						return
 					}
 				}
@@ -130,21 +149,31 @@ func (s *Scheduler) isDone(p *ExecutionGraph) bool {
 	return true
 }
 
-func (s *Scheduler) runStage(stage *Stage) error {
+// runStage runs the stage's task or nested pipeline, which must end by dl.
+func (s *Scheduler) runStage(stage *Stage, dl deadline) error {
 	if stage.Pipeline != nil {
-		return s.Schedule(stage.Pipeline)
+		return s.schedule(stage.Pipeline, dl.earlier(stage.Pipeline.Timeout, fmt.Sprintf("pipeline %s timeout of %s", stage.Name, stage.Pipeline.Timeout)))
 	}
 
 	// Tasks are shared between stages that reference the same definition, so
 	// run a per-stage clone: merging stage env/variables into the shared
 	// instance would leak them into other stages and race under concurrency.
 	t := stage.Task.Clone()
+	t.Stage = stage.Name
 	stage.Task = t
 
 	if stage.Dir != "" {
 		t.Dir = stage.Dir
 	}
 
+	if stage.SuccessCodes != nil {
+		t.SuccessCodes = stage.SuccessCodes
+	}
+
+	if !dl.at.IsZero() {
+		t.Deadline, t.DeadlineCause = dl.at, dl.cause
+	}
+
 	if stage.Env != nil {
 		if t.Env == nil {
 			t.Env = stage.Env
@@ -176,8 +205,8 @@ func checkStatus(p *ExecutionGraph, stage *Stage) (ready bool) {
 		switch depStage.ReadStatus() {
 		case StatusDone, StatusSkipped:
 			continue
-		case StatusError:
-			if !depStage.AllowFailure {
+		case StatusError, StatusTimedOut:
+			if !depStage.AllowsFailure() {
 				ready = false
 				stage.updateStatus(StatusCanceled)
 			}
@@ -192,6 +221,47 @@ func checkStatus(p *ExecutionGraph, stage *Stage) (ready bool) {
 	return ready
 }
 
+// deadline is when a pipeline or stage must end, and what set it.
+type deadline struct {
+	at    time.Time
+	cause string
+}
+
+// earlier returns whichever ends first of d and a deadline timeout from now,
+// set by cause. A zero timeout sets no deadline.
+func (d deadline) earlier(timeout time.Duration, cause string) deadline {
+	if timeout <= 0 {
+		return d
+	}
+
+	at := time.Now().Add(timeout)
+	if d.at.IsZero() || at.Before(d.at) {
+		return deadline{at: at, cause: cause}
+	}
+
+	return d
+}
+
+func (d deadline) expired() bool {
+	return !d.at.IsZero() && !time.Now().Before(d.at)
+}
+
+// cancelWaiting cancels the stages of g that have not started by the time dl
+// ran out, failing g with a timeout if none of its stages did already.
+func cancelWaiting(g *ExecutionGraph, dl deadline) {
+	canceled := false
+	for _, stage := range g.Nodes() {
+		if stage.ReadStatus() == StatusWaiting {
+			stage.updateStatus(StatusCanceled)
+			canceled = true
+		}
+	}
+
+	if canceled {
+		g.setError(fmt.Errorf("%w: %s exceeded", executor.ErrTimedOut, dl.cause), true)
+	}
+}
+
 func checkStageCondition(condition string) (bool, error) {
 	cmd := exec.CommandContext(context.Background(), condition)
 	err := cmd.Run()
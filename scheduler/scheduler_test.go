@@ -3,8 +3,12 @@ package scheduler
 import (
 	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/taskctl/taskctl/executor"
 	"github.com/taskctl/taskctl/variables"
 
 	"github.com/taskctl/taskctl/runner"
@@ -313,3 +317,131 @@ func ExampleScheduler_Schedule() {
 		fmt.Println(err)
 	}
 }
+
+func TestScheduler_StageTimeout(t *testing.T) {
+	r, err := runner.NewTaskRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Stdout, r.Stderr = io.Discard, io.Discard
+
+	stage1 := &Stage{
+		Name:    "stage1",
+		Task:    task.FromCommands("sleep 5"),
+		Timeout: 50 * time.Millisecond,
+	}
+	stage2 := &Stage{
+		Name:      "stage2",
+		Task:      task.FromCommands("true"),
+		DependsOn: []string{"stage1"},
+	}
+
+	graph, err := NewExecutionGraph(stage1, stage2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = NewScheduler(r).Schedule(graph)
+	if !errors.Is(err, executor.ErrTimedOut) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "stage stage1 timeout of 50ms") {
+		t.Errorf("the error must name the stage timeout, got %q", err)
+	}
+	if stage1.ReadStatus() != StatusTimedOut || stage2.ReadStatus() != StatusCanceled {
+		t.Errorf("statuses = %d, %d", stage1.ReadStatus(), stage2.ReadStatus())
+	}
+}
+
+func TestScheduler_PipelineTimeout(t *testing.T) {
+	r, err := runner.NewTaskRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Stdout, r.Stderr = io.Discard, io.Discard
+
+	stage1 := &Stage{Name: "stage1", Task: task.FromCommands("sleep 5")}
+	stage2 := &Stage{Name: "stage2", Task: task.FromCommands("true"), DependsOn: []string{"stage1"}}
+
+	graph, err := NewExecutionGraph(stage1, stage2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph.Timeout = 50 * time.Millisecond
+
+	err = NewScheduler(r).Schedule(graph)
+	if !errors.Is(err, executor.ErrTimedOut) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	if stage1.ReadStatus() != StatusTimedOut {
+		t.Errorf("stage1 status = %d, want timed out", stage1.ReadStatus())
+	}
+	if stage2.ReadStatus() != StatusCanceled {
+		t.Errorf("stage2 status = %d, want canceled", stage2.ReadStatus())
+	}
+}
+
+func TestScheduler_AllowFailureExitCodes(t *testing.T) {
+	r, err := runner.NewTaskRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Stdout, r.Stderr = io.Discard, io.Discard
+
+	for _, tt := range []struct {
+		command  string
+		exitCode int16
+		canceled bool
+	}{
+		{command: "exit 1", exitCode: 1},
+		{command: "exit 2", exitCode: 2, canceled: true},
+	} {
+		lint := &Stage{
+			Name:                  "lint",
+			Task:                  task.FromCommands(tt.command),
+			AllowFailure:          true,
+			AllowFailureExitCodes: []int{1},
+		}
+		next := &Stage{Name: "next", Task: task.FromCommands("true"), DependsOn: []string{"lint"}}
+
+		graph, err := NewExecutionGraph(lint, next)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = NewScheduler(r).Schedule(graph)
+		if canceled := next.ReadStatus() == StatusCanceled; canceled != tt.canceled || (err != nil) != tt.canceled {
+			t.Errorf("%s: next canceled = %t, err = %v", tt.command, canceled, err)
+		}
+		if lint.Task.ExitCode != tt.exitCode {
+			t.Errorf("%s: exit code %d, want %d", tt.command, lint.Task.ExitCode, tt.exitCode)
+		}
+	}
+}
+
+func TestScheduler_StageSuccessCodes(t *testing.T) {
+	r, err := runner.NewTaskRunner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Stdout, r.Stderr = io.Discard, io.Discard
+
+	shared := task.FromCommands("exit 3")
+	lenient := &Stage{Name: "lenient", Task: shared, SuccessCodes: []int{0, 3}}
+	strict := &Stage{Name: "strict", Task: shared}
+
+	graph, err := NewExecutionGraph(lenient, strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewScheduler(r).Schedule(graph); err == nil {
+		t.Error("the stage without success codes must fail")
+	}
+	if lenient.ReadStatus() != StatusDone || lenient.Task.ExitCode != 3 {
+		t.Errorf("lenient: status %d, exit code %d", lenient.ReadStatus(), lenient.Task.ExitCode)
+	}
+	if strict.ReadStatus() != StatusError {
+		t.Errorf("strict: status %d, want error", strict.ReadStatus())
+	}
+}
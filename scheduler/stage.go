@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"slices"
 	"sync/atomic"
 	"time"
 
@@ -17,6 +18,7 @@ const (
 	StatusDone
 	StatusError
 	StatusCanceled
+	StatusTimedOut
 )
 
 // Stage is a structure that describes execution stage
@@ -28,9 +30,17 @@ type Stage struct {
 	DependsOn    []string
 	Dir          string
 	AllowFailure bool
-	status       atomic.Int32
-	Env          variables.Container
-	Variables    variables.Container
+	// AllowFailureExitCodes narrows AllowFailure to a task failing with one
+	// of these exit codes.
+	AllowFailureExitCodes []int
+	// SuccessCodes, when set, replaces the success codes of the stage's task.
+	SuccessCodes []int
+	// Timeout caps the stage's run: its task's or, for a stage running a
+	// pipeline, the whole nested pipeline's. Zero for none.
+	Timeout   time.Duration
+	status    atomic.Int32
+	Env       variables.Container
+	Variables variables.Container
 
 	Start time.Time
 	End   time.Time
@@ -46,6 +56,20 @@ func (s *Stage) ReadStatus() int32 {
 	return s.status.Load()
 }
 
+// AllowsFailure reports whether the pipeline goes on after the stage failed.
+func (s *Stage) AllowsFailure() bool {
+	if !s.AllowFailure {
+		return false
+	}
+	if len(s.AllowFailureExitCodes) == 0 {
+		return true
+	}
+
+	// Only a task's own exit status can match: a nested pipeline has none,
+	// and a timed out task's is whatever the kill left it with.
+	return s.Task != nil && !s.Task.TimedOut && slices.Contains(s.AllowFailureExitCodes, int(s.Task.ExitCode))
+}
+
 // Duration returns stage's execution duration
 func (s *Stage) Duration() time.Duration {
 	return s.End.Sub(s.Start)
@@ -0,0 +1,105 @@
+package task
+
+import (
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/taskctl/taskctl/internal/iox"
+)
+
+// LogBuffer holds one stream of a task's output. Past its limit the output
+// spills to a temporary file rather than grow in memory; Task.Close removes
+// it. The zero value is an empty buffer ready to use. It is not safe for
+// concurrent use.
+type LogBuffer struct {
+	buf iox.SpillBuffer
+	// parts hold the output preceding buf's, read from them (see Join).
+	parts []*LogBuffer
+}
+
+// SetLimit sets how many bytes of output are kept in memory before the rest
+// spills to a file: 0 for the default of 16 MiB, a negative limit to keep it
+// all in memory.
+func (b *LogBuffer) SetLimit(limit int) {
+	b.buf.Limit = limit
+}
+
+// Join makes the output of parts, in order, precede b's own, without copying
+// it: b reads it from them.
+func (b *LogBuffer) Join(parts ...*LogBuffer) {
+	b.parts = append(b.parts, parts...)
+}
+
+// Write appends p to the output.
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	return b.buf.Write(p)
+}
+
+// WriteString appends s to the output.
+func (b *LogBuffer) WriteString(s string) (int, error) {
+	return b.buf.WriteString(s)
+}
+
+// Len returns the size of the output in bytes.
+func (b *LogBuffer) Len() int {
+	n := b.buf.Len()
+	for _, p := range b.parts {
+		n += p.Len()
+	}
+
+	return n
+}
+
+// Spilled reports whether some of the output was moved to a file.
+func (b *LogBuffer) Spilled() bool {
+	for _, p := range b.parts {
+		if p.Spilled() {
+			return true
+		}
+	}
+
+	return b.buf.Spilled()
+}
+
+// String returns the whole output, read back from the files it spilled to.
+func (b *LogBuffer) String() string {
+	var s strings.Builder
+	s.Grow(b.Len())
+	_, _ = b.WriteTo(&s)
+
+	return s.String()
+}
+
+// WriteTo writes the whole output to w without reading it all into memory.
+func (b *LogBuffer) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, p := range b.parts {
+		n, err := p.WriteTo(w)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+
+	n, err := b.buf.WriteTo(w)
+
+	return total + n, err
+}
+
+// Tail returns the last n bytes of the output, or all of it when shorter.
+func (b *LogBuffer) Tail(n int) []byte {
+	tail := b.buf.Tail(n)
+	for i := len(b.parts) - 1; i >= 0 && len(tail) < n; i-- {
+		tail = slices.Concat(b.parts[i].Tail(n-len(tail)), tail)
+	}
+
+	return tail
+}
+
+// reset empties the buffer, removing the file it spilled to, and forgets the
+// parts it joined.
+func (b *LogBuffer) reset() {
+	b.buf.Reset()
+	b.parts = nil
+}
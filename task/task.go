@@ -4,32 +4,81 @@ package task
 import (
 	"bufio"
 	"bytes"
-	"io"
+	"slices"
 	"time"
 
+	"github.com/taskctl/taskctl/internal/iox"
+	"github.com/taskctl/taskctl/process"
 	"github.com/taskctl/taskctl/variables"
 )
 
 // Task is a structure that describes task, its commands, environment, working directory etc.
 // After task completes it provides task's execution status, exit code, stdout and stderr
 type Task struct {
-	Commands     []string // Commands to run
-	Context      string
-	Env          variables.Container
-	Variables    variables.Container
-	Variations   []map[string]string
-	Dir          string
-	Timeout      *time.Duration
-	AllowFailure bool
-	After        []string
-	Before       []string
-	Interactive  bool
+	Commands   []string // Commands to run
+	Context    string
+	Env        variables.Container
+	Variables  variables.Container
+	Variations []map[string]string
+	Dir        string
+	// Timeout caps the whole run of the task, from its condition to its
+	// after commands; CommandTimeout caps each command on its own.
+	Timeout        *time.Duration
+	CommandTimeout *time.Duration
+	After          []string
+	Before         []string
+	Interactive    bool
+	// TTY attaches the task's commands to a pseudo-terminal (see
+	// executor.Job.TTY).
+	TTY bool
+
+	// SuccessCodes lists the exit codes a command succeeds with; empty for
+	// just 0. AllowFailure lets the task go on past a failed command or, when
+	// AllowFailureExitCodes is set, past one exiting with any of those codes.
+	SuccessCodes          []int
+	AllowFailure          bool
+	AllowFailureExitCodes []int
+
+	// OnFailure runs when the task fails, Finally after it whether it failed
+	// or not; neither changes the task's result.
+	OnFailure []string
+	Finally   []string
+
+	// Limits restricts the resources of every process the task starts,
+	// overriding those of its context.
+	Limits *process.Limits
+	// EnvInherit selects the host environment variables the task's commands
+	// see, overriding its context's setting; nil defers to the context.
+	EnvInherit *process.EnvInherit
+	// Builtins lists the executor.BuiltinSets the task's commands run in
+	// place of binaries on PATH, overriding its context's; nil defers to the
+	// context.
+	Builtins []string
+
+	// Parallel runs the task's variations concurrently or, for a task without
+	// variations, its commands; ParallelLimit caps how many run at once (0
+	// for no limit). Each of them runs as its own task, kept in Units.
+	Parallel      bool
+	ParallelLimit int
+	Units         []*Task
 
 	Condition string
 	Skipped   bool
 
+	// Deadline, set by whatever runs the task (a pipeline stage, say), stops
+	// the task when reached, like Timeout; DeadlineCause describes what set
+	// it, e.g. "stage build timeout of 5m0s".
+	Deadline      time.Time
+	DeadlineCause string
+	// TimedOut is set, along with Errored, when the task was stopped by a
+	// timeout or deadline.
+	TimedOut bool
+
 	Name        string
 	Description string
+	// Stage is the name of the pipeline stage running this task; empty when
+	// the task is run directly.
+	Stage string
 
 	Start time.Time
 	End   time.Time
@@ -39,10 +88,20 @@ type Task struct {
 	ExitCode int16
 	Errored  bool
 	Error    error
-	Log      struct {
-		Stderr bytes.Buffer
-		Stdout bytes.Buffer
+	// Log holds the task's output, until Close releases it.
+	Log struct {
+		Stderr LogBuffer
+		Stdout LogBuffer
 	}
+	// LogFiles lists the files the task's output was written to when the
+	// runner keeps per-task logs on disk.
+	LogFiles []string
+
+	// Outputs, Annotations and Progress hold what the task's commands
+	// reported through taskctl's builtin commands (see executor.Reporter).
+	Outputs     map[string]string
+	Annotations []process.Annotation
+	Progress    int
 }
 
 // NewTask creates new Task instance
@@ -72,12 +131,37 @@ func (t *Task) Clone() *Task {
 	c.Errored = false
 	c.Error = nil
 	c.Skipped = false
-	c.Log.Stdout = bytes.Buffer{}
-	c.Log.Stderr = bytes.Buffer{}
+	c.TimedOut = false
+	c.Log.Stdout = LogBuffer{buf: iox.SpillBuffer{Limit: t.Log.Stdout.buf.Limit}}
+	c.Log.Stderr = LogBuffer{buf: iox.SpillBuffer{Limit: t.Log.Stderr.buf.Limit}}
+	c.LogFiles = nil
+	c.Units = nil
+	c.Outputs = nil
+	c.Annotations = nil
+	c.Progress = 0
 
 	return &c
 }
 
+// Succeeds reports whether a command exiting with code succeeded.
+func (t *Task) Succeeds(code int) bool {
+	if len(t.SuccessCodes) == 0 {
+		return code == 0
+	}
+
+	return slices.Contains(t.SuccessCodes, code)
+}
+
+// AllowsFailure reports whether a command that failed with exit code code
+// leaves the task going on.
+func (t *Task) AllowsFailure(code int) bool {
+	if !t.AllowFailure {
+		return false
+	}
+
+	return len(t.AllowFailureExitCodes) == 0 || slices.Contains(t.AllowFailureExitCodes, code)
+}
+
 // Duration returns task's execution duration
 func (t *Task) Duration() time.Duration {
 	if t.Start.IsZero() {
@@ -98,10 +182,10 @@ func (t *Task) ErrorMessage() string {
 	}
 
 	if t.Log.Stderr.Len() > 0 {
-		return lastLine(&t.Log.Stderr)
+		return lastLine(t.Log.Stderr.Tail(lastLineBytes))
 	}
 
-	return lastLine(&t.Log.Stdout)
+	return lastLine(t.Log.Stdout.Tail(lastLineBytes))
 }
 
 // WithEnv sets environment variable
@@ -131,8 +215,21 @@ func (t *Task) Stderr() string {
 	return t.Log.Stderr.String()
 }
 
-func lastLine(r io.Reader) (l string) {
-	scanner := bufio.NewScanner(r)
+// Close releases the output of the task and of its units, removing the
+// temporary files it spilled to; Stdout and Stderr are empty afterwards.
+func (t *Task) Close() {
+	for _, u := range t.Units {
+		u.Close()
+	}
+	t.Log.Stdout.reset()
+	t.Log.Stderr.reset()
+}
+
+// lastLineBytes is how much of the end of a log lastLine looks at.
+const lastLineBytes = 64 * 1024
+
+func lastLine(b []byte) (l string) {
+	scanner := bufio.NewScanner(bytes.NewReader(b))
 	for scanner.Scan() {
 		l = scanner.Text()
 	}
@@ -1,6 +1,8 @@
 package task
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 )
@@ -48,12 +50,32 @@ func TestTask_ErrorMessage(t *testing.T) {
 		t.Error()
 	}
 
+	// ErrorMessage leaves the log intact.
 	task.Log.Stdout.Write([]byte("new output"))
-	if task.Stdout() != "new output" {
+	if task.Stdout() != "abc\ndefnew output" {
 		t.Error()
 	}
 }
 
+func TestTask_ErrorMessage_Spilled(t *testing.T) {
+	task := NewTask()
+	task.Errored = true
+	task.Log.Stderr.SetLimit(16)
+	for i := range 100 {
+		fmt.Fprintf(&task.Log.Stderr, "line %d\n", i)
+	}
+
+	if !task.Log.Stderr.Spilled() {
+		t.Fatal("the log didn't spill")
+	}
+	if msg := task.ErrorMessage(); msg != "line 99" {
+		t.Errorf("ErrorMessage() = %q, want %q", msg, "line 99")
+	}
+	if !strings.HasPrefix(task.Stderr(), "line 0\nline 1\n") || task.Log.Stderr.Len() != len(task.Stderr()) {
+		t.Errorf("Stderr() doesn't return the whole spilled log")
+	}
+}
+
 func TestNewTask_WithVariations(t *testing.T) {
 	task := FromCommands("ls /tmp")
 
@@ -66,3 +88,65 @@ func TestNewTask_WithVariations(t *testing.T) {
 		t.Error()
 	}
 }
+
+func TestTask_ExitCodes(t *testing.T) {
+	task := NewTask()
+	if !task.Succeeds(0) || task.Succeeds(1) || task.AllowsFailure(1) {
+		t.Error("by default only 0 succeeds and no failure is allowed")
+	}
+
+	task.SuccessCodes = []int{0, 3}
+	if !task.Succeeds(3) || task.Succeeds(1) {
+		t.Error("success codes were not applied")
+	}
+
+	task.SuccessCodes = []int{1}
+	if task.Succeeds(0) {
+		t.Error("success codes replace 0 when they don't list it")
+	}
+
+	task.AllowFailure = true
+	if !task.AllowsFailure(2) {
+		t.Error("allow_failure without exit codes allows any failure")
+	}
+
+	task.AllowFailureExitCodes = []int{2}
+	if !task.AllowsFailure(2) || task.AllowsFailure(4) {
+		t.Error("allow_failure exit codes were not applied")
+	}
+}
+
+func TestLogBuffer_Join(t *testing.T) {
+	var a, b, log LogBuffer
+	a.SetLimit(4)
+	_, _ = a.WriteString("first\n")
+	_, _ = b.WriteString("second\n")
+	log.Join(&a, &b)
+	_, _ = log.WriteString("own\n")
+
+	if got, want := log.String(), "first\nsecond\nown\n"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if log.Len() != len("first\nsecond\nown\n") || !log.Spilled() {
+		t.Errorf("Len() = %d, Spilled() = %v", log.Len(), log.Spilled())
+	}
+	if got, want := string(log.Tail(10)), "econd\nown\n"; got != want {
+		t.Errorf("Tail(10) = %q, want %q", got, want)
+	}
+}
+
+func TestTask_Close(t *testing.T) {
+	unit := NewTask()
+	unit.Log.Stdout.SetLimit(4)
+	_, _ = unit.Log.Stdout.WriteString("unit output\n")
+
+	task := NewTask()
+	task.Units = []*Task{unit}
+	task.Log.Stdout.Join(&unit.Log.Stdout)
+	_, _ = task.Log.Stderr.WriteString("error\n")
+
+	task.Close()
+	if task.Stdout() != "" || task.Stderr() != "" || unit.Stdout() != "" || unit.Log.Stdout.Spilled() {
+		t.Error("Close must release the output of the task and its units")
+	}
+}
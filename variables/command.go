@@ -0,0 +1,14 @@
+package variables
+
+// Command is a value computed by a shell command, as in
+// version: {sh: "git describe --tags"}. The runner runs Script when a task
+// using the value first runs, once per run and execution context, and uses its
+// output without the trailing newlines.
+type Command struct {
+	Script string
+}
+
+// String describes the value as it is declared.
+func (c Command) String() string {
+	return "sh: " + c.Script
+}
@@ -2,6 +2,10 @@
 package variables
 
 import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
 	"github.com/taskctl/taskctl/internal/collections"
 )
 
@@ -92,3 +96,16 @@ func (vars *Variables) With(key string, value any) Container {
 
 	return dst
 }
+
+// Format formats a value for display: lists and maps as JSON, other values
+// as fmt prints them, so a Command reads "sh: script".
+func Format(v any) string {
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if b, err := json.Marshal(v); err == nil {
+			return string(b)
+		}
+	}
+
+	return fmt.Sprint(v)
+}
@@ -37,3 +37,36 @@ func TestNewVariables(t *testing.T) {
 		t.Fatal()
 	}
 }
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		in   any
+		want string
+	}{
+		{"v1.2.3", "v1.2.3"},
+		{8080, "8080"},
+		{true, "true"},
+		{[]any{"api", "web"}, `["api","web"]`},
+		{[]string{}, "[]"},
+		{map[string]any{"api": 8080}, `{"api":8080}`},
+		{Command{Script: "git describe"}, "sh: git describe"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.in); got != tt.want {
+			t.Errorf("Format(%#v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMergeKeepsTypes(t *testing.T) {
+	vars := NewVariables()
+	vars.Set("Packages", []any{"api"})
+	merged := vars.Merge(NewVariables()).With("Ports", map[string]any{"api": 8080})
+
+	if _, ok := merged.Get("Packages").([]any); !ok {
+		t.Errorf("merge must keep a list, got %#v", merged.Get("Packages"))
+	}
+	if _, ok := merged.Get("Ports").(map[string]any); !ok {
+		t.Errorf("with must keep a map, got %#v", merged.Get("Ports"))
+	}
+}